@@ -4,17 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/hibiken/asynq"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/sirupsen/logrus"
 
+	evmsdk "github.com/vultisig/recipes/sdk/evm"
 	"github.com/vultisig/verifier/plugin"
 	plugin_config "github.com/vultisig/verifier/plugin/config"
 	plugin_metrics "github.com/vultisig/verifier/plugin/metrics"
@@ -25,22 +28,43 @@ import (
 	plugin_server "github.com/vultisig/verifier/plugin/server"
 	"github.com/vultisig/verifier/vault"
 	"github.com/vultisig/verifier/vault_config"
+	vcommon "github.com/vultisig/vultisig-go/common"
 	"golang.org/x/sync/errgroup"
 
 	app_config "github.com/vultisig/app-developer/internal/config"
 	"github.com/vultisig/app-developer/internal/db"
+	"github.com/vultisig/app-developer/internal/evm"
+	"github.com/vultisig/app-developer/internal/health"
+	"github.com/vultisig/app-developer/internal/logging"
+	"github.com/vultisig/app-developer/internal/metrics"
 	app_server "github.com/vultisig/app-developer/internal/server"
+	app_tasks "github.com/vultisig/app-developer/internal/tasks"
+	"github.com/vultisig/app-developer/internal/tracing"
 	"github.com/vultisig/app-developer/spec"
 )
 
 type config struct {
+	logging.Config
 	Server        plugin_server.Config
 	TaskQueueName string `envconfig:"TASK_QUEUE_NAME" default:"default_queue"`
 	Postgres      plugin_config.Database
-	Redis         plugin_config.Redis
-	BlockStorage  vault_config.BlockStorage
-	Verifier      plugin_config.Verifier
-	Fee           app_config.FeeConfig
+	// VerifierPostgres is the verifier's database, read for the plugins
+	// developers registered and who owns them. Without it policies are
+	// accepted for any target plugin.
+	VerifierPostgres plugin_config.Database `envconfig:"VERIFIER_POSTGRES"`
+	Redis            plugin_config.Redis
+	BlockStorage     vault_config.BlockStorage
+	Verifier         plugin_config.Verifier
+	Fee              app_config.FeeConfig
+	Admin            app_config.AdminConfig
+	DeveloperAuth    app_config.DeveloperAuthConfig `envconfig:"DEVELOPER_AUTH"`
+	AddressCache     app_config.AddressCacheConfig  `envconfig:"ADDRESS_CACHE"`
+	// Worker is read for the listing fee task queue and task timeout, so new
+	// policies can be handed to the worker.
+	Worker     app_config.WorkerConfig
+	Metrics    metrics.Config
+	Tracing    tracing.Config
+	HealthPort int `default:"8081"`
 }
 
 func newConfig() (config, error) {
@@ -49,6 +73,10 @@ func newConfig() (config, error) {
 	if err != nil {
 		return config{}, fmt.Errorf("failed to process env var: %w", err)
 	}
+	err = cfg.Fee.Validate()
+	if err != nil {
+		return config{}, err
+	}
 	return cfg, nil
 }
 
@@ -67,7 +95,21 @@ func main() {
 
 	cfg.Server.TaskQueueName = cfg.TaskQueueName
 
-	logger := logrus.New()
+	logger, err := logging.New(cfg.Config, "server")
+	if err != nil {
+		logrus.Fatalf("failed to initialize logger: %v", err)
+	}
+
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing, "server")
+	if err != nil {
+		logger.Fatalf("failed to initialize tracing: %v", err)
+	}
+	defer func() {
+		shutdownErr := shutdownTracing(context.Background())
+		if shutdownErr != nil {
+			logger.Errorf("failed to shut down tracing: %v", shutdownErr)
+		}
+	}()
 
 	redisClient, err := redis.NewRedis(cfg.Redis)
 	if err != nil {
@@ -118,6 +160,35 @@ func main() {
 
 	middlewares := plugin_server.DefaultMiddlewares(logger)
 
+	serverMetrics := plugin_metrics.NewNilPluginServerMetrics()
+	if cfg.Metrics.Enabled {
+		serverMetrics = plugin_metrics.NewPluginServerMetrics()
+	}
+
+	var pluginOwners spec.PluginOwnerLookup
+	if cfg.VerifierPostgres.DSN != "" {
+		verifierPool, err := pgxpool.New(ctx, cfg.VerifierPostgres.DSN)
+		if err != nil {
+			logger.Fatalf("failed to initialize verifier Postgres pool: %v", err)
+		}
+		defer verifierPool.Close()
+		pluginOwners = db.NewVerifierPlugins(verifierPool)
+	} else {
+		logger.Warn("VERIFIER_POSTGRES_DSN not set, target plugin ownership is not checked")
+	}
+
+	addressDeriver := evm.NewVaultAddressDeriver(vaultStorage, cfg.Server.EncryptionSecret, cfg.AddressCache.Secret, pgBackend, logger)
+	pluginSpec := spec.NewSpec(
+		cfg.Fee.VultTokenAddress,
+		cfg.Fee.TreasuryAddress,
+		cfg.Fee.Amount,
+		cfg.Fee.MaxBatchSize,
+		pgBackend,
+		addressDeriver,
+		pluginOwners,
+	)
+	verifierAuth := plugin_server.NewAuth(cfg.Verifier.Token).Middleware
+
 	srv := plugin_server.NewServer(
 		cfg.Server,
 		policyService,
@@ -125,18 +196,52 @@ func main() {
 		vaultStorage,
 		asynqClient,
 		asynqInspector,
-		spec.NewSpec(cfg.Fee.VultTokenAddress, cfg.Fee.TreasuryAddress, cfg.Fee.Amount),
+		pluginSpec,
 		middlewares,
-		plugin_metrics.NewNilPluginServerMetrics(),
+		serverMetrics,
 		logger,
 		nil,
 	)
-	srv.SetAuthMiddleware(plugin_server.NewAuth(cfg.Verifier.Token).Middleware)
+	srv.SetAuthMiddleware(verifierAuth)
 
 	e := srv.GetRouter()
+	e.Use(tracing.EchoMiddleware())
+	e.Use(logging.EchoMiddleware())
+	enqueuer := app_tasks.NewEnqueuer(asynqClient, asynqInspector, cfg.Worker.TaskQueue, cfg.Worker.LeaseDuration)
+	e.Use(app_server.NewPolicyValidationMiddleware(pluginSpec, verifierAuth, enqueuer, logger))
 
-	listingAPI := app_server.NewDeveloperAPI(pgBackend, cfg.Fee, logger)
-	listingAPI.RegisterRoutes(e)
+	ethClient, err := ethclient.Dial(cfg.Fee.EthRpcURL)
+	if err != nil {
+		logger.Fatalf("failed to connect to Ethereum RPC: %v", err)
+	}
+
+	chainID := new(big.Int).SetUint64(cfg.Fee.ChainID)
+	simulator := evm.NewSimulator(evmsdk.NewSDK(chainID, ethClient, ethClient.Client()), vcommon.Ethereum, ethClient)
+
+	listingAPI := app_server.NewDeveloperAPI(pgBackend, policyService, addressDeriver, simulator, cfg.Fee, logger)
+	developerAuth := app_server.NewDeveloperAuth(
+		pgBackend,
+		addressDeriver,
+		pluginSpec.GetPluginID(),
+		cfg.Fee.ChainID,
+		cfg.Verifier.Token,
+		cfg.DeveloperAuth,
+		logger,
+	)
+	developerAuth.RegisterRoutes(e)
+	go developerAuth.PurgeExpiredChallenges(ctx)
+	listingAPI.RegisterRoutes(e, developerAuth.Middleware)
+
+	adminAPI := app_server.NewAdminAPI(
+		pgBackend,
+		ethClient,
+		addressDeriver,
+		pluginSpec.GetPluginID(),
+		cfg.Fee,
+		cfg.Admin,
+		logger,
+	)
+	adminAPI.RegisterRoutes(e)
 
 	go func() {
 		sigCh := make(chan os.Signal, 1)
@@ -146,6 +251,27 @@ func main() {
 		cancel()
 	}()
 
+	healthServer := health.New(cfg.HealthPort)
+	healthServer.AddReadinessCheck("postgres", health.PostgresCheck(pgPool))
+	healthServer.AddReadinessCheck("redis", health.RedisCheck(asynqClient))
+	healthServer.AddReadinessCheck("vault_storage", health.VaultStorageCheck(vaultStorage))
+	go func() {
+		healthErr := healthServer.Start(ctx, logger)
+		if healthErr != nil {
+			logger.Errorf("health server failed: %v", healthErr)
+		}
+	}()
+
+	if cfg.Metrics.Enabled {
+		metricsServer := metrics.New(cfg.Metrics.Port)
+		go func() {
+			metricsErr := metricsServer.Start(ctx, logger)
+			if metricsErr != nil {
+				logger.Errorf("metrics server failed: %v", metricsErr)
+			}
+		}()
+	}
+
 	eg := &errgroup.Group{}
 	eg.Go(func() error {
 		startErr := e.Start(fmt.Sprintf(":%d", cfg.Server.Port))
@@ -8,6 +8,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/sirupsen/logrus"
@@ -20,16 +21,21 @@ import (
 	tx_storage "github.com/vultisig/verifier/plugin/tx_indexer/pkg/storage"
 
 	"github.com/vultisig/app-developer/internal/health"
+	"github.com/vultisig/app-developer/internal/logging"
+	"github.com/vultisig/app-developer/internal/metrics"
 )
 
 type config struct {
+	logging.Config
 	Database         plugin_config.Database
 	EthRpcURL        string        `envconfig:"ETH_RPC_URL" default:"https://ethereum-rpc.publicnode.com"`
 	Interval         time.Duration `default:"15s"`
 	IterationTimeout time.Duration `default:"60s"`
 	MarkLostAfter    time.Duration `default:"30m"`
 	Concurrency      int           `default:"5"`
+	RpcMaxLag        time.Duration `default:"5m"`
 	HealthPort       int           `default:"8083"`
+	Metrics          metrics.Config
 }
 
 func newConfig() (config, error) {
@@ -50,7 +56,10 @@ func main() {
 		logrus.Fatalf("failed to load config: %v", err)
 	}
 
-	logger := logrus.New()
+	logger, err := logging.New(cfg.Config, "tx_indexer")
+	if err != nil {
+		logrus.Fatalf("failed to initialize logger: %v", err)
+	}
 
 	pgPool, err := pgxpool.New(ctx, cfg.Database.DSN)
 	if err != nil {
@@ -76,6 +85,11 @@ func main() {
 		logger.Fatalf("failed to initialize RPCs: %v", err)
 	}
 
+	txIndexerMetrics := plugin_metrics.NewNilTxIndexerMetrics()
+	if cfg.Metrics.Enabled {
+		txIndexerMetrics = plugin_metrics.NewTxIndexerMetrics()
+	}
+
 	worker := tx_indexer.NewWorker(
 		logger,
 		cfg.Interval,
@@ -84,10 +98,17 @@ func main() {
 		cfg.Concurrency,
 		txStorage,
 		rpcs,
-		plugin_metrics.NewNilTxIndexerMetrics(),
+		txIndexerMetrics,
 	)
 
+	ethClient, err := ethclient.Dial(cfg.EthRpcURL)
+	if err != nil {
+		logger.Fatalf("failed to connect to Ethereum RPC: %v", err)
+	}
+
 	healthServer := health.New(cfg.HealthPort)
+	healthServer.AddReadinessCheck("postgres", health.PostgresCheck(pgPool))
+	healthServer.AddReadinessCheck("rpc", health.RPCCheck(ethClient, cfg.RpcMaxLag))
 	go func() {
 		healthErr := healthServer.Start(ctx, logger)
 		if healthErr != nil {
@@ -95,6 +116,16 @@ func main() {
 		}
 	}()
 
+	if cfg.Metrics.Enabled {
+		metricsServer := metrics.New(cfg.Metrics.Port)
+		go func() {
+			metricsErr := metricsServer.Start(ctx, logger)
+			if metricsErr != nil {
+				logger.Errorf("metrics server failed: %v", metricsErr)
+			}
+		}()
+	}
+
 	go func() {
 		sigCh := make(chan os.Signal, 1)
 		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kelseyhightower/envconfig"
@@ -34,19 +35,33 @@ import (
 	"github.com/vultisig/app-developer/internal/db"
 	"github.com/vultisig/app-developer/internal/evm"
 	"github.com/vultisig/app-developer/internal/health"
+	"github.com/vultisig/app-developer/internal/logging"
+	"github.com/vultisig/app-developer/internal/metrics"
+	app_tasks "github.com/vultisig/app-developer/internal/tasks"
+	"github.com/vultisig/app-developer/internal/tracing"
 	"github.com/vultisig/app-developer/internal/worker"
 )
 
 type config struct {
+	logging.Config
 	Postgres           plugin_config.Database
 	Redis              plugin_config.Redis
 	BlockStorage       vault_config.BlockStorage
 	VaultService       vault_config.Config
 	Verifier           plugin_config.Verifier
 	Fee                app_config.FeeConfig
+	Worker             app_config.WorkerConfig
+	Keysign            app_config.KeysignConfig
+	AddressCache       app_config.AddressCacheConfig `envconfig:"ADDRESS_CACHE"`
+	Metrics            metrics.Config
+	Tracing            tracing.Config
 	TaskQueueName      string        `envconfig:"TASK_QUEUE_NAME" default:"default_queue"`
 	ProcessingInterval time.Duration `default:"30s"`
-	HealthPort         int           `default:"8081"`
+	// LivenessMaxMissed is how many processing intervals may pass without a
+	// completed cycle before /healthz starts failing.
+	LivenessMaxMissed int           `default:"10"`
+	RpcMaxLag         time.Duration `default:"5m"`
+	HealthPort        int           `default:"8081"`
 }
 
 func newConfig() (config, error) {
@@ -55,9 +70,26 @@ func newConfig() (config, error) {
 	if err != nil {
 		return config{}, fmt.Errorf("failed to process env var: %w", err)
 	}
+	err = cfg.Fee.Validate()
+	if err != nil {
+		return config{}, err
+	}
+	err = cfg.Keysign.Validate()
+	if err != nil {
+		return config{}, err
+	}
 	return cfg, nil
 }
 
+// defaultWorkerID is unique per process even when replicas share a hostname.
+func defaultWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "worker"
+	}
+	return hostname + "-" + uuid.NewString()[:8]
+}
+
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -67,7 +99,21 @@ func main() {
 		logrus.Fatalf("failed to load config: %v", err)
 	}
 
-	logger := logrus.New()
+	logger, err := logging.New(cfg.Config, "worker")
+	if err != nil {
+		logrus.Fatalf("failed to initialize logger: %v", err)
+	}
+
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing, "worker")
+	if err != nil {
+		logger.Fatalf("failed to initialize tracing: %v", err)
+	}
+	defer func() {
+		shutdownErr := shutdownTracing(context.Background())
+		if shutdownErr != nil {
+			logger.Errorf("failed to shut down tracing: %v", shutdownErr)
+		}
+	}()
 
 	vaultStorage, err := vault.NewBlockStorageImp(cfg.BlockStorage)
 	if err != nil {
@@ -80,6 +126,7 @@ func main() {
 	}
 
 	asynqClient := asynq.NewClient(asynqConnOpt)
+	asynqInspector := asynq.NewInspector(asynqConnOpt)
 
 	queueName := cfg.TaskQueueName
 	if queueName == "" {
@@ -162,30 +209,102 @@ func main() {
 	chainID := new(big.Int).SetUint64(cfg.Fee.ChainID)
 	sdk := evmsdk.NewSDK(chainID, ethClient, ethClient.Client())
 
-	signer := keysign.NewSigner(
-		logger.WithField("pkg", "keysign.Signer").Logger,
-		relay.NewRelayClient(cfg.VaultService.Relay.Server),
+	relayClient := relay.NewRelayClient(cfg.VaultService.Relay.Server)
+	signers := evm.NewSignerPool(
+		logging.WithFields(logger, logrus.Fields{"pkg": "keysign.Signer"}),
+		relayClient,
 		[]keysign.Emitter{
-			keysign.NewPluginEmitter(asynqClient, tasks.TypeKeySignDKLS, queueName),
-			keysign.NewVerifierEmitter(cfg.Verifier.URL, cfg.Verifier.Token),
-		},
-		[]string{
-			cfg.VaultService.LocalPartyPrefix,
-			cfg.Verifier.PartyPrefix,
+			evm.NewRecordingEmitter("plugin", keysign.NewPluginEmitter(asynqClient, tasks.TypeKeySignDKLS, queueName)),
+			evm.NewRecordingEmitter("verifier", evm.NewVerifierEmitter(cfg.Verifier.URL, cfg.Verifier.Token)),
 		},
 	)
+	parties := evm.NewPartyResolver(
+		vaultStorage,
+		cfg.VaultService.EncryptionSecret,
+		cfg.VaultService.LocalPartyPrefix,
+		cfg.Verifier.PartyPrefix,
+		cfg.Keysign.CoSignerPrefixes,
+	)
+
+	feeMetrics := metrics.NewNilListingFeeMetrics()
+	if cfg.Metrics.Enabled {
+		feeMetrics = metrics.NewListingFeeMetrics()
+	}
+
+	broadcastURLs := cfg.Fee.BroadcastRpcURLs
+	if len(broadcastURLs) == 0 {
+		broadcastURLs = []string{cfg.Fee.EthRpcURL}
+	}
+	publicBroadcaster, err := evm.DialBroadcaster(broadcastURLs, cfg.Fee.BroadcastFanOut, feeMetrics, logger)
+	if err != nil {
+		logger.Fatalf("failed to initialize broadcaster: %v", err)
+	}
+	var broadcaster evm.TxBroadcaster = publicBroadcaster
+	if cfg.Fee.PrivateRelayURL != "" {
+		privateRelay, relayErr := evm.DialPrivateRelay(cfg.Fee.PrivateRelayURL)
+		if relayErr != nil {
+			logger.Fatalf("failed to initialize private relay: %v", relayErr)
+		}
+		broadcaster = evm.NewPrivateSubmitter(
+			privateRelay,
+			ethClient,
+			publicBroadcaster,
+			cfg.Fee.PrivateRelayDeadline,
+			cfg.Fee.PrivateRelayFallback,
+			feeMetrics,
+			logger,
+		)
+	}
+
+	nonceManager := evm.NewNonceManager(pgBackend, ethClient, logger)
+	simulator := evm.NewSimulator(sdk, vcommon.Ethereum, ethClient)
+	signerService := evm.NewSignerService(
+		sdk,
+		vcommon.Ethereum,
+		signers,
+		parties,
+		txIndexerService,
+		feeMetrics,
+		nonceManager,
+		simulator,
+		broadcaster,
+		evm.NewKeysignDiagnoser(relayClient),
+		pgBackend,
+		cfg.Keysign.PartyTimeout,
+		logger,
+	)
+
+	if cfg.Worker.ID == "" {
+		cfg.Worker.ID = defaultWorkerID()
+	}
+
+	var leader worker.LeaderElector = worker.NewSoloLeader()
+	if cfg.Worker.LeaderElection {
+		advisoryLeader := pgBackend.NewAdvisoryLeader("app-developer-worker")
+		defer advisoryLeader.Close(context.Background())
+		leader = advisoryLeader
+	}
 
-	signerService := evm.NewSignerService(sdk, vcommon.Ethereum, signer, txIndexerService)
+	enqueuer := app_tasks.NewEnqueuer(asynqClient, asynqInspector, cfg.Worker.TaskQueue, cfg.Worker.LeaseDuration)
+
+	if cfg.ProcessingInterval == 0 {
+		cfg.ProcessingInterval = worker.DefaultSweepInterval
+	}
+	heartbeat := health.NewHeartbeat(cfg.ProcessingInterval * time.Duration(cfg.LivenessMaxMissed))
 
 	consumer := worker.NewConsumer(
 		logger,
 		policyService,
 		signerService,
-		sdk,
+		ethClient,
 		pgBackend,
-		vaultStorage,
-		cfg.VaultService.EncryptionSecret,
+		evm.NewVaultAddressDeriver(vaultStorage, cfg.VaultService.EncryptionSecret, cfg.AddressCache.Secret, pgBackend, logger),
 		cfg.Fee,
+		feeMetrics,
+		heartbeat,
+		cfg.Worker,
+		leader,
+		enqueuer,
 	)
 
 	go func() {
@@ -197,6 +316,11 @@ func main() {
 	}()
 
 	healthServer := health.New(cfg.HealthPort)
+	healthServer.AddReadinessCheck("postgres", health.PostgresCheck(pgPool))
+	healthServer.AddReadinessCheck("redis", health.RedisCheck(asynqClient))
+	healthServer.AddReadinessCheck("rpc", health.RPCCheck(ethClient, cfg.RpcMaxLag))
+	healthServer.AddReadinessCheck("vault_storage", health.VaultStorageCheck(vaultStorage))
+	healthServer.SetLivenessCheck(heartbeat)
 	go func() {
 		healthErr := healthServer.Start(ctx, logger)
 		if healthErr != nil {
@@ -204,11 +328,44 @@ func main() {
 		}
 	}()
 
+	if cfg.Metrics.Enabled {
+		metricsServer := metrics.New(cfg.Metrics.Port)
+		go func() {
+			metricsErr := metricsServer.Start(ctx, logger)
+			if metricsErr != nil {
+				logger.Errorf("metrics server failed: %v", metricsErr)
+			}
+		}()
+	}
+
+	// Fee tasks get their own server so a backlog of payments waiting on keysign
+	// can never starve the keysign tasks themselves.
+	feeServer := asynq.NewServer(
+		asynqConnOpt,
+		asynq.Config{
+			Logger:      logger,
+			Concurrency: cfg.Worker.Concurrency,
+			Queues: map[string]int{
+				cfg.Worker.TaskQueue: 1,
+			},
+		},
+	)
+	feeMux := asynq.NewServeMux()
+	feeMux.HandleFunc(app_tasks.TypeCreateListingFee, consumer.HandleCreateListingFee)
+	feeMux.HandleFunc(app_tasks.TypeExecuteListingFee, consumer.HandleExecuteListingFee)
+	feeMux.HandleFunc(app_tasks.TypeSyncListingFee, consumer.HandleSyncListingFee)
+	err = feeServer.Start(feeMux)
+	if err != nil {
+		logger.Fatalf("failed to start listing fee task server: %v", err)
+	}
+	defer feeServer.Shutdown()
+
+	go consumer.ListenForPolicies(ctx)
 	go consumer.Run(ctx, cfg.ProcessingInterval)
 
 	mux := asynq.NewServeMux()
 	mux.HandleFunc(tasks.TypeKeySignDKLS, vaultService.HandleKeySignDKLS)
-	mux.HandleFunc(tasks.TypeReshareDKLS, vaultService.HandleReshareDKLS)
+	mux.HandleFunc(tasks.TypeReshareDKLS, consumer.WrapReshare(vaultService.HandleReshareDKLS))
 
 	logger.Info("worker started")
 	err = asynqServer.Run(mux)
@@ -1,9 +1,119 @@
 package config
 
+import (
+	"fmt"
+	"time"
+)
+
 type FeeConfig struct {
 	VultTokenAddress string `envconfig:"VULT_TOKEN_ADDRESS" default:"0xb788144DF611029C60b859DF47e79B7726C4DEBa"`
 	TreasuryAddress  string `envconfig:"TREASURY_ADDRESS"`
 	Amount           string
 	EthRpcURL        string `envconfig:"ETH_RPC_URL" default:"https://ethereum-rpc.publicnode.com"`
 	ChainID          uint64 `envconfig:"CHAIN_ID" default:"1"`
+	// PendingTTL is how long a fee stays payable at the amount quoted when its
+	// policy was created. Pending fees without an expiry, such as those from
+	// before expiry existed, are given one on the same basis. Zero disables
+	// expiry.
+	PendingTTL time.Duration `envconfig:"PENDING_TTL" default:"168h"`
+	// MaxBatchSize caps how many pending fees from one vault are paid in a
+	// single transfer. New policies allow a transfer of up to this many fees,
+	// so any of them can pay for the rest. 1 disables batching.
+	MaxBatchSize int `envconfig:"MAX_BATCH_SIZE" default:"10"`
+	// BroadcastRpcURLs are the endpoints signed payments are sent through, in
+	// order of preference. Defaults to EthRpcURL.
+	BroadcastRpcURLs []string `envconfig:"BROADCAST_RPC_URLS"`
+	// BroadcastFanOut sends every payment to all broadcast endpoints at once
+	// instead of failing over between them.
+	BroadcastFanOut bool `envconfig:"BROADCAST_FAN_OUT" default:"false"`
+	// PrivateRelayURL, when set, sends payments through a private relay
+	// accepting eth_sendPrivateTransaction instead of the public mempool.
+	PrivateRelayURL string `envconfig:"PRIVATE_RELAY_URL"`
+	// PrivateRelayDeadline is how long a privately sent payment may take to be
+	// included before PrivateRelayFallback applies.
+	PrivateRelayDeadline time.Duration `envconfig:"PRIVATE_RELAY_DEADLINE" default:"3m"`
+	// PrivateRelayFallback sends payments the relay rejects or does not get
+	// included in time to the broadcast endpoints. Disable it where payments
+	// must never reach the public mempool.
+	PrivateRelayFallback bool `envconfig:"PRIVATE_RELAY_FALLBACK" default:"true"`
+}
+
+// Validate rejects settings that would make every payment fail.
+func (c FeeConfig) Validate() error {
+	if c.PrivateRelayDeadline <= 0 {
+		return fmt.Errorf("PRIVATE_RELAY_DEADLINE must be greater than 0, got %s", c.PrivateRelayDeadline)
+	}
+	return nil
+}
+
+// KeysignConfig controls which parties sign a fee's payment.
+type KeysignConfig struct {
+	// CoSignerPrefixes are the party prefixes of automated co-signers, such as
+	// an organization's treasury signing service, allowed to make up the quorum
+	// of vaults that need more than the plugin and verifier. They join keysign
+	// sessions through the relay on their own.
+	CoSignerPrefixes []string `envconfig:"CO_SIGNER_PREFIXES"`
+	// PartyTimeout bounds how long one keysign waits for its parties to join
+	// and finish signing.
+	PartyTimeout time.Duration `envconfig:"PARTY_TIMEOUT" default:"5m"`
+}
+
+// Validate rejects a PartyTimeout that would time out every keysign at once.
+func (c KeysignConfig) Validate() error {
+	if c.PartyTimeout <= 0 {
+		return fmt.Errorf("PARTY_TIMEOUT must be greater than 0, got %s", c.PartyTimeout)
+	}
+	return nil
+}
+
+// DeveloperAuthConfig lets developers call the developer API directly,
+// authenticating with a signature from their vault. It is disabled while
+// JWTSecret is empty.
+type DeveloperAuthConfig struct {
+	JWTSecret string `envconfig:"JWT_SECRET"`
+	// TokenTTL is how long an issued token is valid.
+	TokenTTL time.Duration `envconfig:"TOKEN_TTL" default:"1h"`
+	// ChallengeTTL is how long a challenge may take to be signed.
+	ChallengeTTL time.Duration `envconfig:"CHALLENGE_TTL" default:"5m"`
+	// RateLimit and RateBurst bound the challenge and token requests per
+	// second from one client IP.
+	RateLimit float64 `envconfig:"RATE_LIMIT" default:"1"`
+	RateBurst int     `envconfig:"RATE_BURST" default:"5"`
+	// PurgeInterval is how often expired challenges are deleted.
+	PurgeInterval time.Duration `envconfig:"PURGE_INTERVAL" default:"1m"`
+}
+
+// AddressCacheConfig holds the key the derived vault addresses are cached
+// under. The server and the worker share the cache, so both must be given the
+// same secret.
+type AddressCacheConfig struct {
+	Secret string `envconfig:"SECRET" required:"true"`
+}
+
+type AdminConfig struct {
+	// Tokens maps operator name to bearer token, e.g. "alice:s3cret,bob:t0ken".
+	Tokens map[string]string
+}
+
+type WorkerConfig struct {
+	// ID names this replica in fee leases. Defaults to the hostname plus a
+	// random suffix.
+	ID            string
+	LeaseDuration time.Duration `envconfig:"LEASE_DURATION" default:"10m"`
+	// TaskQueue must differ from the keysign queue: fee tasks block on keysign,
+	// and sharing workers with the keysign tasks they wait for can deadlock.
+	TaskQueue   string        `envconfig:"TASK_QUEUE" default:"developer_listing_fee_queue"`
+	Concurrency int           `default:"4"`
+	SyncDelay   time.Duration `envconfig:"SYNC_DELAY" default:"1m"`
+	// LeaderElection restricts the singleton stages to one replica. Turn it
+	// off only when running a single worker.
+	LeaderElection bool `envconfig:"LEADER_ELECTION" default:"true"`
+	// DryRun only simulates each fee's payment against the RPC, typically a
+	// fork: policy evaluation, eth_call and eth_estimateGas. No nonce is
+	// reserved and nothing is signed or broadcast, so fees are left pending.
+	DryRun bool `envconfig:"DRY_RUN" default:"false"`
+	// ResharePauseTimeout bounds how long a vault reshare pauses its fees, in
+	// case the worker running it dies before lifting the pause or the vault's
+	// backup cannot be loaded afterwards.
+	ResharePauseTimeout time.Duration `envconfig:"RESHARE_PAUSE_TIMEOUT" default:"15m"`
 }
@@ -0,0 +1,218 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/vultisig/app-developer/internal/db/sqlcgen"
+)
+
+// ErrListingFeeStateConflict is returned when a listing fee is not in a status
+// that allows the requested transition.
+var ErrListingFeeStateConflict = errors.New("listing fee is not in a valid state for this action")
+
+// ErrTxHashInUse is returned when a transaction hash is already recorded
+// against another listing fee.
+var ErrTxHashInUse = errors.New("transaction hash is already used by another listing fee")
+
+const (
+	AdminActionRetry    = "retry"
+	AdminActionMarkPaid = "mark_paid"
+	AdminActionCancel   = "cancel"
+)
+
+type AdminAction struct {
+	Operator string
+	Action   string
+	PolicyID uuid.UUID
+	Reason   string
+	Details  map[string]any
+}
+
+type AdminAuditRecord struct {
+	ID        uuid.UUID
+	Operator  string
+	Action    string
+	PolicyID  uuid.UUID
+	Reason    string
+	Details   json.RawMessage
+	CreatedAt time.Time
+}
+
+type TxIndexerRecord struct {
+	ID            uuid.UUID
+	TxHash        *string
+	Status        string
+	StatusOnchain string
+	Lost          bool
+	BroadcastedAt *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (p *PostgresBackend) ListListingFeesByStatus(ctx context.Context, status string, limit, offset int32) ([]ListingFee, error) {
+	rows, err := p.queries.ListListingFeesByStatus(ctx, sqlcgen.ListListingFeesByStatusParams{
+		Status: status,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list listing fees: %w", err)
+	}
+	return toListingFees(rows), nil
+}
+
+// RetryFailedFee moves a failed fee back to pending so the worker picks it up again.
+func (p *PostgresBackend) RetryFailedFee(ctx context.Context, action AdminAction) error {
+	return p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		changed, err := applyStatusChange(ctx, q, statusChange{
+			policyID:  action.PolicyID,
+			newStatus: "pending",
+			actor:     AdminActor(action.Operator),
+			reason:    &action.Reason,
+		}, func() (int64, error) {
+			return q.RetryFailedListingFee(ctx, action.PolicyID)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to retry listing fee: %w", err)
+		}
+		if !changed {
+			return ErrListingFeeStateConflict
+		}
+		// A retried fee gets a fresh broadcast budget for its signed tx.
+		err = q.ResetListingFeeIntentBroadcastAttempts(ctx, action.PolicyID)
+		if err != nil {
+			return fmt.Errorf("failed to reset broadcast attempts: %w", err)
+		}
+		return insertAdminAudit(ctx, q, action)
+	})
+}
+
+// MarkAsPaidManually settles a fee with a payment made outside the worker. A
+// hash that already pays another fee, including one only signed by the worker,
+// is refused with ErrTxHashInUse.
+func (p *PostgresBackend) MarkAsPaidManually(ctx context.Context, action AdminAction, txHash string, blockNumber int64) error {
+	return p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		claimed, err := q.IsTxHashClaimedByOtherFee(ctx, sqlcgen.IsTxHashClaimedByOtherFeeParams{
+			TxHash:   txHash,
+			PolicyID: action.PolicyID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to check transaction hash: %w", err)
+		}
+		if claimed {
+			return ErrTxHashInUse
+		}
+
+		changed, err := applyStatusChange(ctx, q, statusChange{
+			policyID:  action.PolicyID,
+			newStatus: "paid",
+			actor:     AdminActor(action.Operator),
+			txHash:    &txHash,
+			reason:    &action.Reason,
+		}, func() (int64, error) {
+			return q.MarkAsPaidManually(ctx, sqlcgen.MarkAsPaidManuallyParams{
+				PolicyID:    action.PolicyID,
+				TxHash:      &txHash,
+				BlockNumber: &blockNumber,
+			})
+		})
+		if isUniqueViolation(err) {
+			// A concurrent call recorded the same hash first.
+			return ErrTxHashInUse
+		}
+		if err != nil {
+			return fmt.Errorf("failed to mark listing fee as paid: %w", err)
+		}
+		if !changed {
+			return ErrListingFeeStateConflict
+		}
+		return insertAdminAudit(ctx, q, action)
+	})
+}
+
+// CancelPendingFee cancels a pending fee and deactivates its policy in one transaction.
+func (p *PostgresBackend) CancelPendingFee(ctx context.Context, action AdminAction) error {
+	return p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		err := cancelPendingFee(ctx, q, action.PolicyID, AdminActor(action.Operator), action.Reason, true)
+		if err != nil {
+			return err
+		}
+		return insertAdminAudit(ctx, q, action)
+	})
+}
+
+func (p *PostgresBackend) GetAdminAuditRecords(ctx context.Context, policyID uuid.UUID) ([]AdminAuditRecord, error) {
+	rows, err := p.queries.GetAdminAuditRecordsByPolicyID(ctx, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query admin audit records: %w", err)
+	}
+	records := make([]AdminAuditRecord, len(rows))
+	for i, row := range rows {
+		records[i] = AdminAuditRecord{
+			ID:        row.ID,
+			Operator:  row.Operator,
+			Action:    row.Action,
+			PolicyID:  row.PolicyID,
+			Reason:    row.Reason,
+			Details:   row.Details,
+			CreatedAt: row.CreatedAt,
+		}
+	}
+	return records, nil
+}
+
+func (p *PostgresBackend) GetTxIndexerRecords(ctx context.Context, policyID uuid.UUID) ([]TxIndexerRecord, error) {
+	rows, err := p.queries.GetTxIndexerRecordsByPolicyID(ctx, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tx_indexer records: %w", err)
+	}
+	records := make([]TxIndexerRecord, len(rows))
+	for i, row := range rows {
+		records[i] = TxIndexerRecord{
+			ID:            row.ID,
+			TxHash:        row.TxHash,
+			Status:        row.Status,
+			StatusOnchain: row.StatusOnchain,
+			Lost:          row.Lost,
+			BroadcastedAt: row.BroadcastedAt,
+			CreatedAt:     row.CreatedAt,
+			UpdatedAt:     row.UpdatedAt,
+		}
+	}
+	return records, nil
+}
+
+func insertAdminAudit(ctx context.Context, q *sqlcgen.Queries, action AdminAction) error {
+	details := action.Details
+	if details == nil {
+		details = map[string]any{}
+	}
+	raw, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	err = q.InsertAdminAuditRecord(ctx, sqlcgen.InsertAdminAuditRecordParams{
+		Operator: action.Operator,
+		Action:   action.Action,
+		PolicyID: action.PolicyID,
+		Reason:   action.Reason,
+		Details:  raw,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write admin audit record: %w", err)
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"embed"
 	"fmt"
 
@@ -16,6 +17,7 @@ import (
 var developerMigrations embed.FS
 
 type PostgresBackend struct {
+	pool    *pgxpool.Pool
 	queries *sqlcgen.Queries
 }
 
@@ -27,7 +29,29 @@ func NewPostgresBackend(logger *logrus.Logger, pool *pgxpool.Pool) (*PostgresBac
 	}
 	logger.Info("developer database migrations completed")
 
-	return &PostgresBackend{queries: sqlcgen.New(pool)}, nil
+	return &PostgresBackend{pool: pool, queries: sqlcgen.New(pool)}, nil
+}
+
+// withTx runs fn inside a transaction, committing only if fn succeeds.
+func (p *PostgresBackend) withTx(ctx context.Context, fn func(q *sqlcgen.Queries) error) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	err = fn(p.queries.WithTx(tx))
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
 }
 
 type DeveloperMigrationManager struct {
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vultisig/app-developer/internal/db/sqlcgen"
+)
+
+// GetDerivedAddress returns the encrypted address cached for publicKey, or nil
+// when none is cached.
+func (p *PostgresBackend) GetDerivedAddress(ctx context.Context, publicKey string) ([]byte, error) {
+	encrypted, err := p.queries.GetDerivedAddress(ctx, publicKey)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get derived address: %w", err)
+	}
+	return encrypted, nil
+}
+
+func (p *PostgresBackend) SaveDerivedAddress(ctx context.Context, publicKey string, encrypted []byte) error {
+	err := p.queries.UpsertDerivedAddress(ctx, sqlcgen.UpsertDerivedAddressParams{
+		PublicKey:        publicKey,
+		EncryptedAddress: encrypted,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save derived address: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vultisig/app-developer/internal/db/sqlcgen"
+)
+
+// DeveloperAuthChallenge is a nonce handed to a developer to sign.
+type DeveloperAuthChallenge struct {
+	Nonce     string
+	PublicKey string
+	ExpiresAt time.Time
+}
+
+// CreateDeveloperAuthChallenge stores a new challenge.
+func (p *PostgresBackend) CreateDeveloperAuthChallenge(ctx context.Context, challenge DeveloperAuthChallenge) error {
+	err := p.queries.CreateDeveloperAuthChallenge(ctx, sqlcgen.CreateDeveloperAuthChallengeParams{
+		Nonce:     challenge.Nonce,
+		PublicKey: challenge.PublicKey,
+		ExpiresAt: challenge.ExpiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create auth challenge: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredDeveloperAuthChallenges drops challenges that expired before
+// now and returns how many there were.
+func (p *PostgresBackend) DeleteExpiredDeveloperAuthChallenges(ctx context.Context, now time.Time) (int64, error) {
+	n, err := p.queries.DeleteExpiredDeveloperAuthChallenges(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired auth challenges: %w", err)
+	}
+	return n, nil
+}
+
+// IsKnownDeveloperPublicKey reports whether the vault with publicKey has a
+// listing fee or a policy of this plugin.
+func (p *PostgresBackend) IsKnownDeveloperPublicKey(ctx context.Context, publicKey string) (bool, error) {
+	known, err := p.queries.IsKnownDeveloperPublicKey(ctx, publicKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up public key: %w", err)
+	}
+	return known, nil
+}
+
+// ConsumeDeveloperAuthChallenge removes the challenge with nonce and returns
+// it, or nil if there is none. The caller checks its key and expiry.
+func (p *PostgresBackend) ConsumeDeveloperAuthChallenge(ctx context.Context, nonce string) (*DeveloperAuthChallenge, error) {
+	row, err := p.queries.ConsumeDeveloperAuthChallenge(ctx, nonce)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume auth challenge: %w", err)
+	}
+	return &DeveloperAuthChallenge{
+		Nonce:     nonce,
+		PublicKey: row.PublicKey,
+		ExpiresAt: row.ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vultisig/app-developer/internal/db/sqlcgen"
+)
+
+// NonceReservation is the nonce assigned to one fee's payment.
+type NonceReservation struct {
+	Nonce uint64
+	// GapFilled is set when the nonce sits below the address's high-water
+	// mark, i.e. an earlier reservation was abandoned and its nonce reused.
+	GapFilled bool
+	// ChainAhead is set when the chain had moved past the high-water mark,
+	// e.g. because of a transaction sent from the vault by hand.
+	ChainAhead bool
+}
+
+// ReserveNonce assigns policyID the lowest nonce at or above chainNonce, the
+// address's pending nonce on chain, that no other live fee holds. A fee
+// that already holds such a nonce keeps it. Assignment is serialized per
+// address, so concurrent fees from one vault never share a nonce.
+func (p *PostgresBackend) ReserveNonce(ctx context.Context, policyID uuid.UUID, address string, chainNonce uint64) (*NonceReservation, error) {
+	var res NonceReservation
+	err := p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		err := q.EnsureEvmNonce(ctx, sqlcgen.EnsureEvmNonceParams{
+			Address:   address,
+			NextNonce: int64(chainNonce),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to ensure nonce row: %w", err)
+		}
+		next, err := q.GetEvmNonceForUpdate(ctx, address)
+		if err != nil {
+			return fmt.Errorf("failed to lock nonce row: %w", err)
+		}
+
+		held, err := q.GetHeldNonces(ctx, sqlcgen.GetHeldNoncesParams{
+			Address:  address,
+			MinNonce: int64(chainNonce),
+			PolicyID: policyID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get held nonces: %w", err)
+		}
+
+		var current *int64
+		existing, err := q.GetNonceReservation(ctx, policyID)
+		switch {
+		case err == nil && existing.Address == address:
+			current = &existing.Nonce
+		case err != nil && !errors.Is(err, pgx.ErrNoRows):
+			return fmt.Errorf("failed to get nonce reservation: %w", err)
+		}
+
+		nonce, keep := pickNonce(int64(chainNonce), held, current)
+		if keep {
+			res = NonceReservation{Nonce: uint64(nonce)}
+			return nil
+		}
+
+		err = q.UpsertNonceReservation(ctx, sqlcgen.UpsertNonceReservationParams{
+			PolicyID: policyID,
+			Address:  address,
+			Nonce:    nonce,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to reserve nonce: %w", err)
+		}
+
+		err = q.SetEvmNextNonce(ctx, sqlcgen.SetEvmNextNonceParams{
+			Address:   address,
+			NextNonce: max(next, nonce+1),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to advance next nonce: %w", err)
+		}
+
+		res = NonceReservation{
+			Nonce:      uint64(nonce),
+			GapFilled:  nonce < next,
+			ChainAhead: int64(chainNonce) > next,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// pickNonce chooses a fee's nonce from the chain's pending nonce, the sorted
+// nonces other fees hold and the fee's current reservation on the same
+// address, if any. keep reports that the current reservation still stands.
+func pickNonce(chainNonce int64, held []int64, current *int64) (nonce int64, keep bool) {
+	if current != nil && *current >= chainNonce && !containsNonce(held, *current) {
+		return *current, true
+	}
+
+	nonce = chainNonce
+	for _, h := range held {
+		if h == nonce {
+			nonce++
+		} else if h > nonce {
+			break
+		}
+	}
+	return nonce, false
+}
+
+func containsNonce(nonces []int64, nonce int64) bool {
+	for _, n := range nonces {
+		if n == nonce {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package db
+
+import "testing"
+
+func TestPickNonce(t *testing.T) {
+	nonce := func(n int64) *int64 { return &n }
+
+	tests := []struct {
+		name       string
+		chainNonce int64
+		held       []int64
+		current    *int64
+		want       int64
+		wantKeep   bool
+	}{
+		{name: "first reservation", chainNonce: 5, want: 5},
+		{name: "skips held nonces", chainNonce: 5, held: []int64{5, 6, 8}, want: 7},
+		{name: "reuses a released nonce", chainNonce: 5, held: []int64{6, 7}, want: 5},
+		{name: "keeps its own reservation", chainNonce: 5, held: []int64{5, 7}, current: nonce(6), want: 6, wantKeep: true},
+		{name: "own nonce taken by another fee", chainNonce: 5, held: []int64{5, 6}, current: nonce(6), want: 7},
+		{name: "chain above the reserved nonces", chainNonce: 9, current: nonce(6), want: 9},
+		{name: "chain above some held nonces", chainNonce: 7, held: []int64{7, 8}, current: nonce(6), want: 9},
+		{name: "chain below the reserved nonces", chainNonce: 2, held: []int64{5, 6}, want: 2},
+		{name: "chain below its own reservation", chainNonce: 2, held: []int64{5}, current: nonce(6), want: 6, wantKeep: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, keep := pickNonce(tt.chainNonce, tt.held, tt.current)
+			if got != tt.want || keep != tt.wantKeep {
+				t.Errorf("pickNonce = %d, %t, want %d, %t", got, keep, tt.want, tt.wantKeep)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/vultisig/app-developer/internal/db/sqlcgen"
+)
+
+// RecordKeysignSession links a keysign session and the tx_indexer record it
+// signs to the fee and the correlation ID behind it.
+func (p *PostgresBackend) RecordKeysignSession(ctx context.Context, sessionID string, policyID, txIndexerID uuid.UUID, correlationID string) error {
+	err := p.queries.InsertKeysignSession(ctx, sqlcgen.InsertKeysignSessionParams{
+		SessionID:     sessionID,
+		PolicyID:      policyID,
+		TxIndexerID:   txIndexerID,
+		CorrelationID: correlationID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record keysign session: %w", err)
+	}
+	return nil
+}
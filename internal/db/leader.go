@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/vultisig/app-developer/internal/db/sqlcgen"
+)
+
+// AdvisoryLeader elects a single leader among replicas using a session-level
+// Postgres advisory lock held on a dedicated connection. Leadership is lost
+// when that connection dies, at which point any replica may take over.
+type AdvisoryLeader struct {
+	pool *pgxpool.Pool
+	name string
+
+	mu   sync.Mutex
+	conn *pgxpool.Conn
+}
+
+func (p *PostgresBackend) NewAdvisoryLeader(name string) *AdvisoryLeader {
+	return &AdvisoryLeader{pool: p.pool, name: name}
+}
+
+// IsLeader reports whether this replica holds the lock, trying to take it if not.
+func (l *AdvisoryLeader) IsLeader(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		err := l.conn.Ping(ctx)
+		if err == nil {
+			return true, nil
+		}
+		l.dropConn(ctx)
+	}
+
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	acquired, err := sqlcgen.New(conn).TryAdvisoryLock(ctx, l.name)
+	if err != nil {
+		conn.Release()
+		return false, fmt.Errorf("failed to try advisory lock: %w", err)
+	}
+	if !acquired {
+		conn.Release()
+		return false, nil
+	}
+
+	l.conn = conn
+	return true, nil
+}
+
+// Close gives up leadership.
+func (l *AdvisoryLeader) Close(ctx context.Context) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.conn != nil {
+		l.dropConn(ctx)
+	}
+}
+
+// dropConn closes the lock-holding connection rather than returning it to the
+// pool, which would leave the advisory lock held by an idle connection.
+func (l *AdvisoryLeader) dropConn(ctx context.Context) {
+	_ = l.conn.Hijack().Close(ctx)
+	l.conn = nil
+}
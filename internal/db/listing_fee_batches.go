@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/vultisig/app-developer/internal/db/sqlcgen"
+)
+
+// JoinListingFeeBatch adds up to maxFees of the vault's other pending fees
+// that nothing else pays for to the batch led by leadPolicyID, and returns
+// every pending fee in the batch. The lead's transfer then pays for all of
+// them besides its own.
+func (p *PostgresBackend) JoinListingFeeBatch(ctx context.Context, leadPolicyID uuid.UUID, publicKey string, maxFees int) ([]ListingFee, error) {
+	var batch []ListingFee
+	err := p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		rows, err := q.GetListingFeeBatch(ctx, &leadPolicyID)
+		if err != nil {
+			return fmt.Errorf("failed to get listing fee batch: %w", err)
+		}
+		for _, row := range rows {
+			if row.Status == "pending" {
+				batch = append(batch, *toListingFee(row))
+			}
+		}
+		if len(batch) >= maxFees {
+			return nil
+		}
+
+		joined, err := q.JoinListingFeeBatch(ctx, sqlcgen.JoinListingFeeBatchParams{
+			LeadPolicyID: leadPolicyID,
+			PublicKey:    publicKey,
+			MaxFees:      int32(maxFees - len(batch)),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to join listing fee batch: %w", err)
+		}
+		for _, policyID := range joined {
+			row, err := q.GetListingFeeByPolicyID(ctx, policyID)
+			if err != nil {
+				return fmt.Errorf("failed to get listing fee: %w", err)
+			}
+			batch = append(batch, *toListingFee(row))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// GetListingFeeBatch returns the fees whose payment leadPolicyID's transfer
+// covers, in any status.
+func (p *PostgresBackend) GetListingFeeBatch(ctx context.Context, leadPolicyID uuid.UUID) ([]ListingFee, error) {
+	rows, err := p.queries.GetListingFeeBatch(ctx, &leadPolicyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listing fee batch: %w", err)
+	}
+	return toListingFees(rows), nil
+}
+
+// followBatchLead carries a status change of leadPolicyID over to the fees in
+// its batch: they follow a lead that is paying, and are released to pay on
+// their own once it stops. It must run inside withTx, after the change.
+func followBatchLead(ctx context.Context, q *sqlcgen.Queries, leadPolicyID uuid.UUID, actor string) error {
+	_, err := q.FollowListingFeeBatchLead(ctx, sqlcgen.FollowListingFeeBatchLeadParams{
+		LeadPolicyID: leadPolicyID,
+		Actor:        actor,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update listing fee batch: %w", err)
+	}
+	_, err = q.ReleaseListingFeeBatch(ctx, sqlcgen.ReleaseListingFeeBatchParams{
+		LeadPolicyID: leadPolicyID,
+		Actor:        actor,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release listing fee batch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+)
+
+// testBackend connects to the database in TEST_POSTGRES_DSN and migrates it.
+// Tests that need Postgres are skipped when it is not set.
+func testBackend(t *testing.T) *PostgresBackend {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN not set")
+	}
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to postgres: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	backend, err := NewPostgresBackend(logger, pool)
+	if err != nil {
+		t.Fatalf("NewPostgresBackend: %v", err)
+	}
+	return backend
+}
+
+func TestBatchFollowsLead(t *testing.T) {
+	ctx := context.Background()
+	p := testBackend(t)
+
+	publicKey := "03" + uuid.NewString()
+	createFee := func(publicKey string) uuid.UUID {
+		t.Helper()
+		policyID := uuid.New()
+		_, err := p.CreateListingFee(ctx, ListingFee{
+			PolicyID:       policyID,
+			PublicKey:      publicKey,
+			TargetPluginID: "vultisig-dca-0000",
+			Amount:         big.NewInt(1000000),
+			Destination:    "0x000000000000000000000000000000000000dEaD",
+			Status:         "pending",
+		}, 0)
+		if err != nil {
+			t.Fatalf("CreateListingFee: %v", err)
+		}
+		// created_at orders the batch; keep it distinct between fees.
+		time.Sleep(time.Millisecond)
+		return policyID
+	}
+	lead := createFee(publicKey)
+	first, second, left := createFee(publicKey), createFee(publicKey), createFee(publicKey)
+	otherVault := createFee("03" + uuid.NewString())
+
+	batch, err := p.JoinListingFeeBatch(ctx, lead, publicKey, 2)
+	if err != nil {
+		t.Fatalf("JoinListingFeeBatch: %v", err)
+	}
+	if len(batch) != 2 || batch[0].PolicyID != first || batch[1].PolicyID != second {
+		t.Fatalf("batch = %+v, want the two oldest fees of the vault", batch)
+	}
+
+	assertFee := func(policyID uuid.UUID, status string, txHash *string, inBatch bool) {
+		t.Helper()
+		fee, err := p.GetListingFeeByPolicyID(ctx, policyID)
+		if err != nil {
+			t.Fatalf("GetListingFeeByPolicyID: %v", err)
+		}
+		if fee.Status != status {
+			t.Errorf("fee %s status = %s, want %s", policyID, fee.Status, status)
+		}
+		if (fee.TxHash == nil) != (txHash == nil) || (txHash != nil && *fee.TxHash != *txHash) {
+			t.Errorf("fee %s tx hash = %v, want %v", policyID, fee.TxHash, txHash)
+		}
+		if (fee.BatchPolicyID != nil) != inBatch {
+			t.Errorf("fee %s batch = %v, want in batch %t", policyID, fee.BatchPolicyID, inBatch)
+		}
+	}
+
+	txHash := "0x" + uuid.New().String()
+	err = p.MarkAsSubmitted(ctx, lead, txHash, "")
+	if err != nil {
+		t.Fatalf("MarkAsSubmitted: %v", err)
+	}
+	assertFee(first, "submitted", &txHash, true)
+	assertFee(second, "submitted", &txHash, true)
+	assertFee(left, "pending", nil, false)
+	assertFee(otherVault, "pending", nil, false)
+
+	err = p.MarkAsFailed(ctx, lead, "reverted", nil)
+	if err != nil {
+		t.Fatalf("MarkAsFailed: %v", err)
+	}
+	assertFee(first, "pending", nil, false)
+	assertFee(second, "pending", nil, false)
+	assertFee(left, "pending", nil, false)
+}
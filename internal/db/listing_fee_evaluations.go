@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vultisig/app-developer/internal/db/sqlcgen"
+)
+
+// ListingFeeEvaluation is one recipe engine evaluation of a fee's payment.
+type ListingFeeEvaluation struct {
+	ID       int64
+	PolicyID uuid.UUID
+	Allowed  bool
+	// Report is the evaluation report as JSON.
+	Report    json.RawMessage
+	CreatedAt time.Time
+}
+
+func (p *PostgresBackend) RecordListingFeeEvaluation(ctx context.Context, policyID uuid.UUID, allowed bool, report json.RawMessage) error {
+	err := p.queries.InsertListingFeeEvaluation(ctx, sqlcgen.InsertListingFeeEvaluationParams{
+		PolicyID: policyID,
+		Allowed:  allowed,
+		Report:   report,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record listing fee evaluation: %w", err)
+	}
+	return nil
+}
+
+// GetListingFeeEvaluations returns the fee's evaluations, newest first.
+func (p *PostgresBackend) GetListingFeeEvaluations(ctx context.Context, policyID uuid.UUID) ([]ListingFeeEvaluation, error) {
+	rows, err := p.queries.GetListingFeeEvaluationsByPolicyID(ctx, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query listing fee evaluations: %w", err)
+	}
+	evaluations := make([]ListingFeeEvaluation, len(rows))
+	for i, row := range rows {
+		evaluations[i] = ListingFeeEvaluation{
+			ID:        row.ID,
+			PolicyID:  row.PolicyID,
+			Allowed:   row.Allowed,
+			Report:    row.Report,
+			CreatedAt: row.CreatedAt,
+		}
+	}
+	return evaluations, nil
+}
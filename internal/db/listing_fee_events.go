@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vultisig/app-developer/internal/db/sqlcgen"
+)
+
+const (
+	ActorWorker = "worker"
+	ActorSync   = "sync"
+	ActorAdmin  = "admin"
+	// ActorDeveloper is the payer acting through the developer API.
+	ActorDeveloper = "developer"
+)
+
+// AdminActor identifies an operator in the event log, e.g. "admin:alice".
+func AdminActor(operator string) string {
+	return ActorAdmin + ":" + operator
+}
+
+type ListingFeeEvent struct {
+	ID        int64
+	PolicyID  uuid.UUID
+	OldStatus *string
+	NewStatus string
+	Actor     string
+	TxHash    *string
+	Reason    *string
+	CreatedAt time.Time
+}
+
+func (p *PostgresBackend) GetListingFeeEvents(ctx context.Context, policyID uuid.UUID) ([]ListingFeeEvent, error) {
+	rows, err := p.queries.GetListingFeeEventsByPolicyID(ctx, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query listing fee events: %w", err)
+	}
+	events := make([]ListingFeeEvent, len(rows))
+	for i, row := range rows {
+		events[i] = ListingFeeEvent{
+			ID:        row.ID,
+			PolicyID:  row.PolicyID,
+			OldStatus: row.OldStatus,
+			NewStatus: row.NewStatus,
+			Actor:     row.Actor,
+			TxHash:    row.TxHash,
+			Reason:    row.Reason,
+			CreatedAt: row.CreatedAt,
+		}
+	}
+	return events, nil
+}
+
+type statusChange struct {
+	policyID  uuid.UUID
+	newStatus string
+	actor     string
+	txHash    *string
+	reason    *string
+}
+
+// applyStatusChange locks the fee row, runs update and, if it touched a row,
+// appends the transition to listing_fee_events and carries it over to the
+// fees in the fee's batch. It must run inside withTx.
+func applyStatusChange(
+	ctx context.Context,
+	q *sqlcgen.Queries,
+	change statusChange,
+	update func() (int64, error),
+) (bool, error) {
+	oldStatus, err := q.GetListingFeeStatusForUpdate(ctx, change.policyID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to lock listing fee: %w", err)
+	}
+
+	n, err := update()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	err = q.InsertListingFeeEvent(ctx, sqlcgen.InsertListingFeeEventParams{
+		PolicyID:  change.policyID,
+		OldStatus: &oldStatus,
+		NewStatus: change.newStatus,
+		Actor:     change.actor,
+		TxHash:    change.txHash,
+		Reason:    change.reason,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to record listing fee event: %w", err)
+	}
+	err = followBatchLead(ctx, q, change.policyID, change.actor)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
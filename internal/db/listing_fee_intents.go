@@ -0,0 +1,200 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vultisig/app-developer/internal/db/sqlcgen"
+)
+
+// ListingFeeIntent is the durable record of a fee payment transaction. It is
+// written before signing and lists every hash that was signed for its nonce,
+// so at most one of them can ever be mined.
+type ListingFeeIntent struct {
+	PolicyID    uuid.UUID
+	FromAddress string
+	Nonce       uint64
+	UnsignedTx  []byte
+	TxHashes    []string
+	// SignedTx is the raw signed transaction, set once keysign succeeds.
+	SignedTx          []byte
+	BroadcastAttempts int
+	// BroadcastEndpoint names the endpoint that accepted SignedTx, if any did.
+	BroadcastEndpoint *string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// CreateListingFeeIntent stores intent unless one already exists for the policy,
+// and returns whichever intent is now on record.
+func (p *PostgresBackend) CreateListingFeeIntent(ctx context.Context, intent ListingFeeIntent) (*ListingFeeIntent, error) {
+	_, err := p.queries.CreateListingFeeIntent(ctx, sqlcgen.CreateListingFeeIntentParams{
+		PolicyID:    intent.PolicyID,
+		FromAddress: intent.FromAddress,
+		Nonce:       int64(intent.Nonce),
+		UnsignedTx:  intent.UnsignedTx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create listing fee intent: %w", err)
+	}
+
+	stored, err := p.GetListingFeeIntent(ctx, intent.PolicyID)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("listing fee intent for policy %s disappeared after insert", intent.PolicyID)
+	}
+	return stored, nil
+}
+
+func (p *PostgresBackend) GetListingFeeIntent(ctx context.Context, policyID uuid.UUID) (*ListingFeeIntent, error) {
+	row, err := p.queries.GetListingFeeIntent(ctx, policyID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listing fee intent: %w", err)
+	}
+	return &ListingFeeIntent{
+		PolicyID:          row.PolicyID,
+		FromAddress:       row.FromAddress,
+		Nonce:             uint64(row.Nonce),
+		UnsignedTx:        row.UnsignedTx,
+		TxHashes:          row.TxHashes,
+		SignedTx:          row.SignedTx,
+		BroadcastAttempts: int(row.BroadcastAttempts),
+		BroadcastEndpoint: row.BroadcastEndpoint,
+		CreatedAt:         row.CreatedAt,
+		UpdatedAt:         row.UpdatedAt,
+	}, nil
+}
+
+// RecordIntentSignedTx must succeed before the signed transaction is broadcast.
+// RecordIntentSignedTx returns ErrListingFeeStateConflict once the fee is no
+// longer pending, e.g. after it was cancelled, so the caller must not broadcast.
+func (p *PostgresBackend) RecordIntentSignedTx(ctx context.Context, policyID uuid.UUID, txHash string, signedTx []byte) error {
+	n, err := p.queries.RecordListingFeeIntentSignedTx(ctx, sqlcgen.RecordListingFeeIntentSignedTxParams{
+		PolicyID: policyID,
+		TxHash:   txHash,
+		SignedTx: signedTx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record intent signed tx: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: fee has no intent or is no longer pending", ErrListingFeeStateConflict)
+	}
+	return nil
+}
+
+// RecordIntentBroadcastAttempt counts a broadcast of the intent's signed
+// transaction and returns the attempts so far, this one included. Like
+// RecordIntentSignedTx it returns ErrListingFeeStateConflict once the fee is
+// no longer pending.
+func (p *PostgresBackend) RecordIntentBroadcastAttempt(ctx context.Context, policyID uuid.UUID) (int, error) {
+	attempts, err := p.queries.IncrementListingFeeIntentBroadcastAttempts(ctx, policyID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("%w: fee has no signed intent or is no longer pending", ErrListingFeeStateConflict)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to record broadcast attempt: %w", err)
+	}
+	return int(attempts), nil
+}
+
+// ReplaceIntentUnsignedTx replaces the intent's transaction with unsignedTx,
+// built for the same nonce, and drops replacedSignedTx so the new one is signed
+// on the next attempt. It returns ErrListingFeeStateConflict if the fee is no
+// longer pending or the intent was already replaced.
+func (p *PostgresBackend) ReplaceIntentUnsignedTx(ctx context.Context, policyID uuid.UUID, unsignedTx, replacedSignedTx []byte) error {
+	n, err := p.queries.ReplaceListingFeeIntentUnsignedTx(ctx, sqlcgen.ReplaceListingFeeIntentUnsignedTxParams{
+		PolicyID:         policyID,
+		UnsignedTx:       unsignedTx,
+		ReplacedSignedTx: replacedSignedTx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replace intent unsigned tx: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: intent was replaced or fee is no longer pending", ErrListingFeeStateConflict)
+	}
+	return nil
+}
+
+// DeleteListingFeeIntent discards an intent whose nonce can no longer carry a
+// payment, e.g. because its transaction reverted. Its nonce reservation goes
+// with it, so the nonce can be handed out again.
+func (p *PostgresBackend) DeleteListingFeeIntent(ctx context.Context, policyID uuid.UUID) error {
+	return p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		err := q.DeleteListingFeeIntent(ctx, policyID)
+		if err != nil {
+			return fmt.Errorf("failed to delete listing fee intent: %w", err)
+		}
+		err = q.DeleteNonceReservation(ctx, policyID)
+		if err != nil {
+			return fmt.Errorf("failed to release nonce reservation: %w", err)
+		}
+		return nil
+	})
+}
+
+// IntentNonce is the nonce one live fee's intent occupies.
+type IntentNonce struct {
+	PolicyID    uuid.UUID
+	FromAddress string
+	Nonce       uint64
+	// Signed is set once a transaction for the nonce was signed, so it may be
+	// on the network.
+	Signed bool
+}
+
+// GetLiveIntentNonces returns the intents of pending and submitted fees,
+// ordered by address and nonce.
+func (p *PostgresBackend) GetLiveIntentNonces(ctx context.Context) ([]IntentNonce, error) {
+	rows, err := p.queries.GetLiveIntentNonces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get live intent nonces: %w", err)
+	}
+	nonces := make([]IntentNonce, 0, len(rows))
+	for _, row := range rows {
+		nonces = append(nonces, IntentNonce{
+			PolicyID:    row.PolicyID,
+			FromAddress: row.FromAddress,
+			Nonce:       uint64(row.Nonce),
+			Signed:      row.Signed,
+		})
+	}
+	return nonces, nil
+}
+
+// DeleteUnsignedListingFeeIntent discards an intent nothing was signed for,
+// releasing its nonce so the fee is rebuilt at the lowest free one. It returns
+// false if the intent was signed or its fee is being executed.
+func (p *PostgresBackend) DeleteUnsignedListingFeeIntent(ctx context.Context, policyID uuid.UUID) (bool, error) {
+	var deleted bool
+	err := p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		n, err := q.DeleteUnsignedListingFeeIntent(ctx, policyID)
+		if err != nil {
+			return fmt.Errorf("failed to delete unsigned listing fee intent: %w", err)
+		}
+		if n == 0 {
+			return nil
+		}
+		err = q.DeleteNonceReservation(ctx, policyID)
+		if err != nil {
+			return fmt.Errorf("failed to release nonce reservation: %w", err)
+		}
+		deleted = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return deleted, nil
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -29,21 +30,49 @@ type ListingFee struct {
 	FailureReason  *string
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
+	// ExpiresAt is when a still-pending fee stops being payable. Nil means never.
+	ExpiresAt *time.Time
+	// FailureDetails is structured diagnostics for a failed fee, as JSON.
+	FailureDetails json.RawMessage
+	// BatchPolicyID is the fee whose transfer pays for this one as well, if
+	// any. Such a fee follows that batch lead's status and transaction.
+	BatchPolicyID *uuid.UUID
 }
 
-func (p *PostgresBackend) CreateListingFee(ctx context.Context, fee ListingFee) error {
-	err := p.queries.CreateListingFee(ctx, sqlcgen.CreateListingFeeParams{
-		PolicyID:       fee.PolicyID,
-		PublicKey:      fee.PublicKey,
-		TargetPluginID: fee.TargetPluginID,
-		Amount:         fee.Amount.String(),
-		Destination:    fee.Destination,
-		Status:         fee.Status,
+// CreateListingFee stores a fee that expires ttl after its policy was
+// created, or never if ttl is zero; fee.ExpiresAt is ignored. It reports
+// whether a fee was created; false means the policy already had one.
+func (p *PostgresBackend) CreateListingFee(ctx context.Context, fee ListingFee, ttl time.Duration) (bool, error) {
+	created := false
+	err := p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		n, err := q.CreateListingFee(ctx, sqlcgen.CreateListingFeeParams{
+			PolicyID:       fee.PolicyID,
+			PublicKey:      fee.PublicKey,
+			TargetPluginID: fee.TargetPluginID,
+			Amount:         fee.Amount.String(),
+			Destination:    fee.Destination,
+			Status:         fee.Status,
+			TtlSeconds:     ttl.Seconds(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create listing fee: %w", err)
+		}
+		if n == 0 {
+			return nil
+		}
+
+		err = q.InsertListingFeeEvent(ctx, sqlcgen.InsertListingFeeEventParams{
+			PolicyID:  fee.PolicyID,
+			NewStatus: fee.Status,
+			Actor:     ActorWorker,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record listing fee event: %w", err)
+		}
+		created = true
+		return nil
 	})
-	if err != nil {
-		return fmt.Errorf("failed to create listing fee: %w", err)
-	}
-	return nil
+	return created, err
 }
 
 func (p *PostgresBackend) GetListingFeeByPolicyID(ctx context.Context, policyID uuid.UUID) (*ListingFee, error) {
@@ -93,6 +122,22 @@ func (p *PostgresBackend) GetPendingListingFees(ctx context.Context) ([]ListingF
 	return toListingFees(rows), nil
 }
 
+type PendingStats struct {
+	Count     int64
+	OldestAge time.Duration
+}
+
+func (p *PostgresBackend) GetPendingListingFeeStats(ctx context.Context) (PendingStats, error) {
+	row, err := p.queries.GetPendingListingFeeStats(ctx)
+	if err != nil {
+		return PendingStats{}, fmt.Errorf("failed to query pending listing fee stats: %w", err)
+	}
+	return PendingStats{
+		Count:     row.PendingCount,
+		OldestAge: time.Duration(row.OldestAgeSeconds * float64(time.Second)),
+	}, nil
+}
+
 func (p *PostgresBackend) GetSubmittedListingFees(ctx context.Context) ([]ListingFee, error) {
 	rows, err := p.queries.GetSubmittedListingFees(ctx)
 	if err != nil {
@@ -101,44 +146,152 @@ func (p *PostgresBackend) GetSubmittedListingFees(ctx context.Context) ([]Listin
 	return toListingFees(rows), nil
 }
 
-func (p *PostgresBackend) MarkAsSubmitted(ctx context.Context, policyID uuid.UUID, txHash string) error {
-	err := p.queries.MarkAsSubmitted(ctx, sqlcgen.MarkAsSubmittedParams{
-		PolicyID: policyID,
-		TxHash:   &txHash,
+// MarkAsSubmitted is idempotent: repeating it with the hash already on record
+// succeeds, while a fee that has moved on with a different hash (or none)
+// returns ErrListingFeeStateConflict. The endpoint that accepted the
+// transaction, if known, is recorded on the fee's intent for auditing.
+func (p *PostgresBackend) MarkAsSubmitted(ctx context.Context, policyID uuid.UUID, txHash, endpoint string) error {
+	return p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		if endpoint != "" {
+			err := q.SetListingFeeIntentBroadcastEndpoint(ctx, sqlcgen.SetListingFeeIntentBroadcastEndpointParams{
+				PolicyID:          policyID,
+				BroadcastEndpoint: &endpoint,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to record broadcast endpoint: %w", err)
+			}
+		}
+
+		changed, err := applyStatusChange(ctx, q, statusChange{
+			policyID:  policyID,
+			newStatus: "submitted",
+			actor:     ActorWorker,
+			txHash:    &txHash,
+		}, func() (int64, error) {
+			return q.MarkAsSubmitted(ctx, sqlcgen.MarkAsSubmittedParams{
+				PolicyID: policyID,
+				TxHash:   &txHash,
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to mark listing fee as submitted: %w", err)
+		}
+		if changed {
+			return nil
+		}
+
+		row, err := q.GetListingFeeByPolicyID(ctx, policyID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("listing fee not found for policy %s", policyID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get listing fee: %w", err)
+		}
+		if row.TxHash != nil && *row.TxHash == txHash && (row.Status == "submitted" || row.Status == "paid") {
+			return nil
+		}
+		return fmt.Errorf("%w: status %s", ErrListingFeeStateConflict, row.Status)
 	})
-	if err != nil {
-		return fmt.Errorf("failed to mark listing fee as submitted: %w", err)
-	}
-	return nil
 }
 
 func (p *PostgresBackend) MarkAsPaid(ctx context.Context, policyID uuid.UUID, blockNum int64, confirmations int) error {
-	err := p.queries.MarkAsPaid(ctx, sqlcgen.MarkAsPaidParams{
-		PolicyID:      policyID,
-		BlockNumber:   &blockNum,
-		Confirmations: int32(confirmations),
+	return p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		_, err := applyStatusChange(ctx, q, statusChange{
+			policyID:  policyID,
+			newStatus: "paid",
+			actor:     ActorWorker,
+		}, func() (int64, error) {
+			return q.MarkAsPaid(ctx, sqlcgen.MarkAsPaidParams{
+				PolicyID:      policyID,
+				BlockNumber:   &blockNum,
+				Confirmations: int32(confirmations),
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to mark listing fee as paid: %w", err)
+		}
+		return nil
+	})
+}
+
+// MarkAsFailed records reason and, when known, structured details of the
+// failure. details may be nil.
+func (p *PostgresBackend) MarkAsFailed(ctx context.Context, policyID uuid.UUID, reason string, details json.RawMessage) error {
+	return p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		changed, err := applyStatusChange(ctx, q, statusChange{
+			policyID:  policyID,
+			newStatus: "failed",
+			actor:     ActorWorker,
+			reason:    &reason,
+		}, func() (int64, error) {
+			return q.MarkAsFailed(ctx, sqlcgen.MarkAsFailedParams{
+				PolicyID:       policyID,
+				FailureReason:  &reason,
+				FailureDetails: details,
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to mark listing fee as failed: %w", err)
+		}
+		if !changed {
+			return ErrListingFeeStateConflict
+		}
+		return nil
+	})
+}
+
+func (p *PostgresBackend) DeactivatePolicy(ctx context.Context, policyID uuid.UUID, reason string) error {
+	err := p.queries.DeactivatePolicy(ctx, sqlcgen.DeactivatePolicyParams{
+		ID:                 policyID,
+		DeactivationReason: &reason,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to mark listing fee as paid: %w", err)
+		return fmt.Errorf("failed to deactivate policy: %w", err)
 	}
 	return nil
 }
 
-func (p *PostgresBackend) MarkAsFailed(ctx context.Context, policyID uuid.UUID, reason string) error {
-	err := p.queries.MarkAsFailed(ctx, sqlcgen.MarkAsFailedParams{
-		PolicyID:      policyID,
-		FailureReason: &reason,
+// CancelListingFee cancels a pending fee on behalf of its payer and
+// deactivates the policy so the verifier stops treating it as installed.
+func (p *PostgresBackend) CancelListingFee(ctx context.Context, policyID uuid.UUID, reason string) error {
+	return p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		return cancelPendingFee(ctx, q, policyID, ActorDeveloper, reason, true)
+	})
+}
+
+// CancelOrphanedFee cancels a pending fee whose policy is already inactive or
+// deleted.
+func (p *PostgresBackend) CancelOrphanedFee(ctx context.Context, policyID uuid.UUID) error {
+	return p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		return cancelPendingFee(ctx, q, policyID, ActorWorker, "policy inactive or deleted", false)
+	})
+}
+
+// cancelPendingFee returns ErrListingFeeStateConflict when the fee is not
+// pending or a payment for it may already have been broadcast.
+func cancelPendingFee(ctx context.Context, q *sqlcgen.Queries, policyID uuid.UUID, actor, reason string, deactivate bool) error {
+	changed, err := applyStatusChange(ctx, q, statusChange{
+		policyID:  policyID,
+		newStatus: "cancelled",
+		actor:     actor,
+		reason:    &reason,
+	}, func() (int64, error) {
+		return q.CancelPendingListingFee(ctx, policyID)
 	})
 	if err != nil {
-		return fmt.Errorf("failed to mark listing fee as failed: %w", err)
+		return fmt.Errorf("failed to cancel listing fee: %w", err)
+	}
+	if !changed {
+		return ErrListingFeeStateConflict
+	}
+	if !deactivate {
+		return nil
 	}
-	return nil
-}
 
-func (p *PostgresBackend) DeactivatePolicy(ctx context.Context, policyID uuid.UUID, reason string) error {
-	err := p.queries.DeactivatePolicy(ctx, sqlcgen.DeactivatePolicyParams{
+	deactivationReason := "cancelled"
+	err = q.DeactivatePolicy(ctx, sqlcgen.DeactivatePolicyParams{
 		ID:                 policyID,
-		DeactivationReason: &reason,
+		DeactivationReason: &deactivationReason,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to deactivate policy: %w", err)
@@ -146,6 +299,65 @@ func (p *PostgresBackend) DeactivatePolicy(ctx context.Context, policyID uuid.UU
 	return nil
 }
 
+// ExpireListingFee moves an overdue pending fee to expired and deactivates its
+// policy. It returns ErrListingFeeStateConflict if the fee is not pending, not
+// yet due, or a payment for it may already have been broadcast.
+func (p *PostgresBackend) ExpireListingFee(ctx context.Context, policyID uuid.UUID) error {
+	return p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		reason := "payment window elapsed"
+		changed, err := applyStatusChange(ctx, q, statusChange{
+			policyID:  policyID,
+			newStatus: "expired",
+			actor:     ActorWorker,
+			reason:    &reason,
+		}, func() (int64, error) {
+			return q.ExpirePendingListingFee(ctx, policyID)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to expire listing fee: %w", err)
+		}
+		if !changed {
+			return ErrListingFeeStateConflict
+		}
+
+		deactivationReason := "expiry"
+		err = q.DeactivatePolicy(ctx, sqlcgen.DeactivatePolicyParams{
+			ID:                 policyID,
+			DeactivationReason: &deactivationReason,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to deactivate policy: %w", err)
+		}
+		return nil
+	})
+}
+
+// BackfillListingFeeExpiry sets the expiry of pending fees created without one
+// to ttl after their policy was created, and returns how many were set.
+func (p *PostgresBackend) BackfillListingFeeExpiry(ctx context.Context, ttl time.Duration) (int64, error) {
+	n, err := p.queries.BackfillListingFeeExpiry(ctx, ttl.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to backfill listing fee expiry: %w", err)
+	}
+	return n, nil
+}
+
+func (p *PostgresBackend) GetExpiredPendingPolicyIDs(ctx context.Context) ([]uuid.UUID, error) {
+	ids, err := p.queries.GetExpiredPendingPolicyIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expired pending fees: %w", err)
+	}
+	return ids, nil
+}
+
+func (p *PostgresBackend) GetOrphanedPendingPolicyIDs(ctx context.Context) ([]uuid.UUID, error) {
+	ids, err := p.queries.GetOrphanedPendingPolicyIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orphaned pending fees: %w", err)
+	}
+	return ids, nil
+}
+
 func (p *PostgresBackend) GetPaidActivePolicyIDs(ctx context.Context) ([]uuid.UUID, error) {
 	ids, err := p.queries.GetPaidActivePolicyIDs(ctx)
 	if err != nil {
@@ -154,6 +366,14 @@ func (p *PostgresBackend) GetPaidActivePolicyIDs(ctx context.Context) ([]uuid.UU
 	return ids, nil
 }
 
+func (p *PostgresBackend) HasListingFee(ctx context.Context, policyID uuid.UUID) (bool, error) {
+	exists, err := p.queries.HasListingFee(ctx, policyID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check listing fee: %w", err)
+	}
+	return exists, nil
+}
+
 func (p *PostgresBackend) HasActiveListingFee(ctx context.Context, publicKey, targetPluginID string) (bool, error) {
 	exists, err := p.queries.HasActiveListingFee(ctx, sqlcgen.HasActiveListingFeeParams{
 		PublicKey:      publicKey,
@@ -173,6 +393,19 @@ func (p *PostgresBackend) IsListingFeePaidForPlugin(ctx context.Context, pluginI
 	return paid, nil
 }
 
+// IsListingFeePaidForVaultPlugin is IsListingFeePaidForPlugin limited to fees
+// paid by the vault with publicKey.
+func (p *PostgresBackend) IsListingFeePaidForVaultPlugin(ctx context.Context, publicKey, pluginID string) (bool, error) {
+	paid, err := p.queries.IsListingFeePaidForVaultPlugin(ctx, sqlcgen.IsListingFeePaidForVaultPluginParams{
+		PublicKey:      publicKey,
+		TargetPluginID: pluginID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to check listing fee paid: %w", err)
+	}
+	return paid, nil
+}
+
 func (p *PostgresBackend) GetUnprocessedPolicyIDs(ctx context.Context) ([]uuid.UUID, error) {
 	ids, err := p.queries.GetUnprocessedPolicyIDs(ctx)
 	if err != nil {
@@ -181,20 +414,52 @@ func (p *PostgresBackend) GetUnprocessedPolicyIDs(ctx context.Context) ([]uuid.U
 	return ids, nil
 }
 
-func (p *PostgresBackend) SyncSubmittedFees(ctx context.Context) (paid int64, failed int64, err error) {
-	paid, err = p.queries.SyncPaidFees(ctx)
-	if err != nil {
-		return 0, 0, fmt.Errorf("failed to sync paid fees: %w", err)
-	}
-
-	failed, err = p.queries.SyncFailedFees(ctx)
+// SyncSubmittedFee settles a submitted fee from its tx_indexer record, reporting
+// whether it became paid or failed. Both are false while still unconfirmed.
+func (p *PostgresBackend) SyncSubmittedFee(ctx context.Context, policyID uuid.UUID) (paid bool, failed bool, err error) {
+	err = p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		n, err := q.SyncPaidFee(ctx, policyID)
+		if err != nil {
+			return fmt.Errorf("failed to sync paid fee: %w", err)
+		}
+		paid = n > 0
+		if !paid {
+			n, err = q.SyncFailedFee(ctx, policyID)
+			if err != nil {
+				return fmt.Errorf("failed to sync failed fee: %w", err)
+			}
+			failed = n > 0
+		}
+		if !paid && !failed {
+			return nil
+		}
+		return followBatchLead(ctx, q, policyID, ActorSync)
+	})
 	if err != nil {
-		return paid, 0, fmt.Errorf("failed to sync failed fees: %w", err)
+		return false, false, err
 	}
-
 	return paid, failed, nil
 }
 
+// RequeueLostFee returns a submitted fee whose transaction tx_indexer lost to
+// pending, so the worker reconciles its intent against the chain. It reports
+// whether the fee was requeued.
+func (p *PostgresBackend) RequeueLostFee(ctx context.Context, policyID uuid.UUID) (bool, error) {
+	requeued := false
+	err := p.withTx(ctx, func(q *sqlcgen.Queries) error {
+		n, err := q.RequeueLostFee(ctx, policyID)
+		if err != nil {
+			return fmt.Errorf("failed to requeue lost fee: %w", err)
+		}
+		if n == 0 {
+			return nil
+		}
+		requeued = true
+		return followBatchLead(ctx, q, policyID, ActorSync)
+	})
+	return requeued, err
+}
+
 func (p *PostgresBackend) UpdateConfirmations(ctx context.Context, policyID uuid.UUID, confirmations int) error {
 	err := p.queries.UpdateConfirmations(ctx, sqlcgen.UpdateConfirmationsParams{
 		PolicyID:      policyID,
@@ -225,6 +490,9 @@ func toListingFee(row sqlcgen.ListingFee) *ListingFee {
 		FailureReason:  row.FailureReason,
 		CreatedAt:      row.CreatedAt,
 		UpdatedAt:      row.UpdatedAt,
+		ExpiresAt:      row.ExpiresAt,
+		FailureDetails: row.FailureDetails,
+		BatchPolicyID:  row.BatchPolicyID,
 	}
 }
 
@@ -235,3 +503,55 @@ func toListingFees(rows []sqlcgen.ListingFee) []ListingFee {
 	}
 	return fees
 }
+
+// GetPendingPolicyIDsByPublicKey returns the pending fees paid from the vault
+// with publicKey.
+func (p *PostgresBackend) GetPendingPolicyIDsByPublicKey(ctx context.Context, publicKey string) ([]uuid.UUID, error) {
+	policyIDs, err := p.queries.GetPendingPolicyIDsByPublicKey(ctx, publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending listing fees: %w", err)
+	}
+	return policyIDs, nil
+}
+
+// ClaimListingFee leases a pending fee to owner. It returns nil if the fee is
+// no longer pending or another worker holds a live lease on it.
+func (p *PostgresBackend) ClaimListingFee(ctx context.Context, policyID uuid.UUID, owner string, lease time.Duration) (*ListingFee, error) {
+	row, err := p.queries.ClaimListingFee(ctx, sqlcgen.ClaimListingFeeParams{
+		LeaseOwner:   owner,
+		LeaseSeconds: lease.Seconds(),
+		PolicyID:     policyID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim listing fee: %w", err)
+	}
+	return toListingFee(row), nil
+}
+
+// RenewListingFeeLease extends owner's lease on a fee by lease. It reports
+// false if owner no longer holds the lease.
+func (p *PostgresBackend) RenewListingFeeLease(ctx context.Context, policyID uuid.UUID, owner string, lease time.Duration) (bool, error) {
+	n, err := p.queries.RenewListingFeeLease(ctx, sqlcgen.RenewListingFeeLeaseParams{
+		LeaseSeconds: lease.Seconds(),
+		PolicyID:     policyID,
+		LeaseOwner:   owner,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to renew listing fee lease: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (p *PostgresBackend) ReleaseListingFeeLease(ctx context.Context, policyID uuid.UUID, owner string) error {
+	err := p.queries.ReleaseListingFeeLease(ctx, sqlcgen.ReleaseListingFeeLeaseParams{
+		PolicyID: policyID,
+		Owner:    owner,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release listing fee lease: %w", err)
+	}
+	return nil
+}
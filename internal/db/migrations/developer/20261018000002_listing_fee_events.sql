@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE listing_fee_events (
+    id BIGSERIAL PRIMARY KEY,
+    policy_id UUID NOT NULL,
+    old_status TEXT,
+    new_status TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    tx_hash TEXT,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_listing_fee_events_policy_id ON listing_fee_events(policy_id, id);
+
+-- Backfill existing fees with their creation and, if it moved on, current status.
+-- Intermediate transitions were never recorded, so old_status is left unknown.
+INSERT INTO listing_fee_events (policy_id, old_status, new_status, actor, created_at)
+SELECT policy_id, NULL, 'pending', 'worker', created_at FROM listing_fees;
+
+INSERT INTO listing_fee_events (policy_id, old_status, new_status, actor, tx_hash, reason, created_at)
+SELECT policy_id, NULL, status, 'migration', tx_hash, failure_reason, updated_at
+FROM listing_fees
+WHERE status <> 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS listing_fee_events;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A payment intent is written before a fee's transaction is signed so that a
+-- crash between broadcast and MarkAsSubmitted can be reconciled by nonce and
+-- hash instead of signing a second payment.
+CREATE TABLE listing_fee_intents (
+    policy_id UUID PRIMARY KEY REFERENCES listing_fees(policy_id),
+    from_address TEXT NOT NULL,
+    nonce BIGINT NOT NULL,
+    unsigned_tx BYTEA NOT NULL,
+    tx_hashes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS listing_fee_intents;
+-- +goose StatementEnd
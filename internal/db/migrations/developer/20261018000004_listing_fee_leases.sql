@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Leases let several worker replicas share pending fees: a fee is only
+-- executed by the worker holding an unexpired lease on it.
+CREATE TABLE listing_fee_leases (
+    policy_id UUID PRIMARY KEY REFERENCES listing_fees(policy_id),
+    owner TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_listing_fees_pending_created_at ON listing_fees(created_at) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_listing_fees_pending_created_at;
+DROP TABLE IF EXISTS listing_fee_leases;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Wake the worker as soon as a policy is committed rather than on its next
+-- polling sweep. The payload is the policy ID.
+CREATE OR REPLACE FUNCTION notify_developer_policy_created() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('developer_policy_created', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER developer_policy_created
+    AFTER INSERT ON plugin_policies
+    FOR EACH ROW EXECUTE FUNCTION notify_developer_policy_created();
+
+-- Policies whose configuration can never produce a listing fee. Recording
+-- them stops the fallback sweep from retrying and lets the API explain why.
+CREATE TABLE policy_ingestion_errors (
+    policy_id UUID PRIMARY KEY,
+    error TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS policy_ingestion_errors;
+DROP TRIGGER IF EXISTS developer_policy_created ON plugin_policies;
+DROP FUNCTION IF EXISTS notify_developer_policy_created();
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE listing_fees ADD COLUMN expires_at TIMESTAMP;
+-- Pending fees created before expiry existed are given a payment window by
+-- the worker, from the configured PENDING_TTL.
+CREATE INDEX idx_listing_fees_pending_expires_at
+    ON listing_fees(expires_at) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_listing_fees_pending_expires_at;
+ALTER TABLE listing_fees DROP COLUMN IF EXISTS expires_at;
+-- +goose StatementEnd
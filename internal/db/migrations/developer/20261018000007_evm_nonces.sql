@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE evm_nonces (
+    address TEXT PRIMARY KEY,
+    next_nonce BIGINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE evm_nonce_reservations (
+    policy_id UUID PRIMARY KEY REFERENCES listing_fees(policy_id) ON DELETE CASCADE,
+    address TEXT NOT NULL,
+    nonce BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_evm_nonce_reservations_address_nonce ON evm_nonce_reservations(address, nonce);
+-- Intents created before the nonce manager keep their nonces.
+INSERT INTO evm_nonce_reservations (policy_id, address, nonce)
+SELECT policy_id, from_address, nonce FROM listing_fee_intents;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS evm_nonce_reservations;
+DROP TABLE IF EXISTS evm_nonces;
+-- +goose StatementEnd
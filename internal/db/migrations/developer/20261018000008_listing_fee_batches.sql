@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A pending fee can be paid by the transfer of another pending fee from the
+-- same vault, its batch lead. batch_policy_id names the lead; the covered fee
+-- follows the lead's status and shares its transaction hash, so the hash is
+-- only unique among fees outside a batch.
+ALTER TABLE listing_fees ADD COLUMN batch_policy_id UUID REFERENCES listing_fees(policy_id);
+CREATE INDEX idx_listing_fees_batch_policy_id ON listing_fees(batch_policy_id) WHERE batch_policy_id IS NOT NULL;
+ALTER TABLE listing_fees DROP CONSTRAINT IF EXISTS listing_fees_tx_hash_key;
+CREATE UNIQUE INDEX idx_listing_fees_tx_hash ON listing_fees(tx_hash) WHERE batch_policy_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_listing_fees_tx_hash;
+UPDATE listing_fees SET tx_hash = NULL WHERE batch_policy_id IS NOT NULL;
+ALTER TABLE listing_fees ADD CONSTRAINT listing_fees_tx_hash_key UNIQUE (tx_hash);
+DROP INDEX IF EXISTS idx_listing_fees_batch_policy_id;
+ALTER TABLE listing_fees DROP COLUMN IF EXISTS batch_policy_id;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The signed transaction is kept so a failed broadcast can be retried with the
+-- same bytes instead of another keysign round.
+ALTER TABLE listing_fee_intents ADD COLUMN signed_tx BYTEA;
+ALTER TABLE listing_fee_intents ADD COLUMN broadcast_attempts INT NOT NULL DEFAULT 0;
+-- Every earlier signature was broadcast once.
+UPDATE listing_fee_intents SET broadcast_attempts = cardinality(tx_hashes);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE listing_fee_intents DROP COLUMN IF EXISTS broadcast_attempts;
+ALTER TABLE listing_fee_intents DROP COLUMN IF EXISTS signed_tx;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Structured diagnostics for failures, e.g. which keysign parties joined,
+-- alongside the human-readable failure_reason.
+ALTER TABLE listing_fees ADD COLUMN failure_details JSONB;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE listing_fees DROP COLUMN IF EXISTS failure_details;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every recipe engine evaluation of a fee's payment, so reviewers can see
+-- which rule matched or which constraint denied it.
+CREATE TABLE listing_fee_evaluations (
+    id BIGSERIAL PRIMARY KEY,
+    policy_id UUID NOT NULL REFERENCES listing_fees(policy_id) ON DELETE CASCADE,
+    allowed BOOLEAN NOT NULL,
+    report JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_listing_fee_evaluations_policy_id ON listing_fee_evaluations(policy_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS listing_fee_evaluations;
+-- +goose StatementEnd
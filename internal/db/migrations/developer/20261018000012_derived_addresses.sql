@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Vault EVM addresses derived from backups, keyed by the vault's public key so
+-- the backup is not downloaded and decrypted on every execution. The address
+-- is stored encrypted with the vault encryption secret.
+CREATE TABLE derived_addresses (
+    public_key TEXT PRIMARY KEY,
+    encrypted_address BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS derived_addresses;
+-- +goose StatementEnd
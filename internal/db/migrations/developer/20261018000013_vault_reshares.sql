@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Vaults with a reshare in progress. Fee executions for the public key are
+-- paused until the row is removed or goes stale.
+CREATE TABLE vault_reshares (
+    public_key TEXT PRIMARY KEY,
+    plugin_id TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS vault_reshares;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Outstanding EIP-712 challenges for developer authentication. A challenge is
+-- deleted when it is answered, so each signature is accepted once.
+CREATE TABLE developer_auth_challenges (
+    nonce TEXT PRIMARY KEY,
+    public_key TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS developer_auth_challenges;
+-- +goose StatementEnd
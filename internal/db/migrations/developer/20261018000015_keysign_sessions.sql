@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every keysign session started for a fee, with the tx_indexer record it
+-- signs and the correlation ID of the request or task behind it, so logs of
+-- the verifier, the relay and the tx indexer can be joined to the fee.
+CREATE TABLE keysign_sessions (
+    session_id TEXT PRIMARY KEY,
+    policy_id UUID NOT NULL REFERENCES listing_fees(policy_id) ON DELETE CASCADE,
+    tx_indexer_id UUID NOT NULL,
+    correlation_id TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_keysign_sessions_policy_id ON keysign_sessions(policy_id);
+CREATE INDEX idx_keysign_sessions_correlation_id ON keysign_sessions(correlation_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS keysign_sessions;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The endpoint that accepted the intent's signed transaction, public RPC or
+-- private relay, kept for auditing where a payment was sent.
+ALTER TABLE listing_fee_intents ADD COLUMN broadcast_endpoint TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE listing_fee_intents DROP COLUMN IF EXISTS broadcast_endpoint;
+-- +goose StatementEnd
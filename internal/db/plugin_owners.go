@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// VerifierPlugins reads plugin registrations from the verifier's database,
+// where developers register their plugins and the vaults that own them. Its
+// tables are not part of this service's schema, hence the hand-written query.
+type VerifierPlugins struct {
+	pool *pgxpool.Pool
+}
+
+func NewVerifierPlugins(pool *pgxpool.Pool) *VerifierPlugins {
+	return &VerifierPlugins{pool: pool}
+}
+
+const getPluginOwnership = `
+SELECT EXISTS(SELECT 1 FROM plugins p WHERE p.id::text = $1) AS registered,
+       EXISTS(
+           SELECT 1 FROM plugin_owners po
+           WHERE po.plugin_id::text = $1 AND po.active = true
+             AND lower(po.public_key) = lower($2)
+       ) AS owned`
+
+// GetPluginOwnership reports whether pluginID is registered with the verifier
+// and whether publicKey is one of its active owners.
+func (v *VerifierPlugins) GetPluginOwnership(ctx context.Context, pluginID, publicKey string) (bool, bool, error) {
+	var registered, owned bool
+	err := v.pool.QueryRow(ctx, getPluginOwnership, pluginID, publicKey).Scan(&registered, &owned)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to get plugin ownership: %w", err)
+	}
+	return registered, owned, nil
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/vultisig/app-developer/internal/db/sqlcgen"
+)
+
+type PolicyIngestionError struct {
+	PolicyID  uuid.UUID
+	Error     string
+	CreatedAt time.Time
+}
+
+// ListenPolicyCreated calls fn with the ID of every policy inserted into
+// plugin_policies. It holds a dedicated connection and returns when ctx is
+// cancelled or the connection fails; callers are expected to call it again.
+func (p *PostgresBackend) ListenPolicyCreated(ctx context.Context, fn func(policyID uuid.UUID)) error {
+	poolConn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	// The connection is LISTENing, so it must not go back to the pool.
+	conn := poolConn.Hijack()
+	defer func() {
+		_ = conn.Close(context.Background())
+	}()
+
+	err = sqlcgen.New(conn).ListenPolicyCreated(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to listen for policy notifications: %w", err)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to wait for policy notification: %w", err)
+		}
+
+		policyID, err := uuid.Parse(notification.Payload)
+		if err != nil {
+			continue
+		}
+		fn(policyID)
+	}
+}
+
+func (p *PostgresBackend) RecordPolicyIngestionError(ctx context.Context, policyID uuid.UUID, reason string) error {
+	err := p.queries.RecordPolicyIngestionError(ctx, sqlcgen.RecordPolicyIngestionErrorParams{
+		PolicyID: policyID,
+		Error:    reason,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record policy ingestion error: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresBackend) GetPolicyIngestionError(ctx context.Context, policyID uuid.UUID) (*PolicyIngestionError, error) {
+	row, err := p.queries.GetPolicyIngestionError(ctx, policyID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy ingestion error: %w", err)
+	}
+	return &PolicyIngestionError{
+		PolicyID:  row.PolicyID,
+		Error:     row.Error,
+		CreatedAt: row.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,77 @@
+-- name: InsertAdminAuditRecord :exec
+INSERT INTO admin_audit_log (operator, action, policy_id, reason, details)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetAdminAuditRecordsByPolicyID :many
+SELECT id, operator, action, policy_id, reason, details, created_at
+FROM admin_audit_log
+WHERE policy_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListListingFeesByStatus :many
+SELECT id, policy_id, public_key, target_plugin_id, amount, destination,
+       tx_hash, block_number, confirmations, status,
+       submitted_at, paid_at, failure_reason,
+       created_at, updated_at, expires_at, failure_details, batch_policy_id
+FROM listing_fees
+WHERE status = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: RetryFailedListingFee :execrows
+UPDATE listing_fees
+SET status = 'pending', tx_hash = NULL, block_number = NULL, submitted_at = NULL,
+    failure_reason = NULL, failure_details = NULL, confirmations = 0, updated_at = CURRENT_TIMESTAMP
+WHERE policy_id = $1 AND status = 'failed';
+
+-- name: MarkAsPaidManually :execrows
+-- A pending fee with an intent or a live lease may be signing or broadcasting
+-- its own payment, so it is refused rather than risk paying twice. A fee in a
+-- batch is settled through its batch lead.
+UPDATE listing_fees lf
+SET status = 'paid', tx_hash = $2, block_number = $3, failure_reason = NULL, failure_details = NULL,
+    paid_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE lf.policy_id = $1 AND lf.status IN ('pending', 'submitted', 'failed')
+  AND lf.batch_policy_id IS NULL
+  AND (lf.status <> 'pending' OR (
+      NOT EXISTS (SELECT 1 FROM listing_fee_intents i WHERE i.policy_id = lf.policy_id)
+      AND NOT EXISTS (
+          SELECT 1 FROM listing_fee_leases l
+          WHERE l.policy_id = lf.policy_id AND l.expires_at >= CURRENT_TIMESTAMP
+      )
+  ));
+
+-- name: IsTxHashClaimedByOtherFee :one
+-- The fees a batch lead's transfer covers share its hash, so they don't count.
+SELECT (EXISTS(
+    SELECT 1 FROM listing_fees lf
+    WHERE lf.tx_hash = sqlc.arg(tx_hash)::text AND lf.policy_id <> sqlc.arg(policy_id)::uuid
+      AND lf.batch_policy_id IS DISTINCT FROM sqlc.arg(policy_id)::uuid
+) OR EXISTS(
+    SELECT 1 FROM listing_fee_intents i
+    WHERE sqlc.arg(tx_hash)::text = ANY(i.tx_hashes) AND i.policy_id <> sqlc.arg(policy_id)::uuid
+))::boolean AS claimed;
+
+-- name: CancelPendingListingFee :execrows
+-- A fee whose intent has a signed hash on record may already be paying, so it
+-- is left to reconciliation instead. So is a fee in a batch whose lead is
+-- building or paying the transfer that covers it; otherwise it leaves the batch.
+UPDATE listing_fees lf
+SET status = 'cancelled', batch_policy_id = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE lf.policy_id = $1 AND lf.status = 'pending'
+  AND NOT EXISTS (
+      SELECT 1 FROM listing_fee_intents i
+      WHERE i.policy_id = lf.policy_id AND cardinality(i.tx_hashes) > 0
+  )
+  AND NOT EXISTS (SELECT 1 FROM listing_fee_intents i WHERE i.policy_id = lf.batch_policy_id)
+  AND NOT EXISTS (
+      SELECT 1 FROM listing_fee_leases l
+      WHERE l.policy_id = lf.batch_policy_id AND l.expires_at >= CURRENT_TIMESTAMP
+  );
+
+-- name: GetTxIndexerRecordsByPolicyID :many
+SELECT id, tx_hash, status::text AS status, status_onchain::text AS status_onchain,
+       lost, broadcasted_at, created_at, updated_at
+FROM tx_indexer
+WHERE policy_id = $1
+ORDER BY created_at DESC;
@@ -0,0 +1,10 @@
+-- name: GetDerivedAddress :one
+SELECT encrypted_address
+FROM derived_addresses
+WHERE public_key = $1;
+
+-- name: UpsertDerivedAddress :exec
+INSERT INTO derived_addresses (public_key, encrypted_address)
+VALUES ($1, $2)
+ON CONFLICT (public_key) DO UPDATE
+SET encrypted_address = EXCLUDED.encrypted_address, created_at = CURRENT_TIMESTAMP;
@@ -0,0 +1,18 @@
+-- name: CreateDeveloperAuthChallenge :exec
+INSERT INTO developer_auth_challenges (nonce, public_key, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: ConsumeDeveloperAuthChallenge :one
+DELETE FROM developer_auth_challenges
+WHERE nonce = $1
+RETURNING public_key, expires_at;
+
+-- name: DeleteExpiredDeveloperAuthChallenges :execrows
+DELETE FROM developer_auth_challenges
+WHERE expires_at < $1;
+
+-- name: IsKnownDeveloperPublicKey :one
+-- A vault is known once it installed a policy of this plugin, whether or not
+-- its listing fee was created yet.
+SELECT (EXISTS(SELECT 1 FROM listing_fees lf WHERE lf.public_key = sqlc.arg(public_key)::text)
+    OR EXISTS(SELECT 1 FROM plugin_policies pp WHERE pp.public_key = sqlc.arg(public_key)::text AND NOT pp.deleted))::boolean AS known;
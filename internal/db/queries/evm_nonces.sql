@@ -0,0 +1,53 @@
+-- name: EnsureEvmNonce :exec
+INSERT INTO evm_nonces (address, next_nonce)
+VALUES ($1, $2)
+ON CONFLICT (address) DO NOTHING;
+
+-- name: GetEvmNonceForUpdate :one
+-- Serializes nonce assignment per address.
+SELECT next_nonce
+FROM evm_nonces
+WHERE address = $1
+FOR UPDATE;
+
+-- name: SetEvmNextNonce :exec
+UPDATE evm_nonces
+SET next_nonce = $2, updated_at = CURRENT_TIMESTAMP
+WHERE address = $1;
+
+-- name: GetNonceReservation :one
+SELECT policy_id, address, nonce, created_at
+FROM evm_nonce_reservations
+WHERE policy_id = $1;
+
+-- name: GetHeldNonces :many
+-- Nonces at or above the chain's pending nonce still reserved by other fees
+-- that may pay: pending or submitted ones, and any fee with a signed payment
+-- until it is confirmed or failed, since that transaction can still be mined
+-- at its nonce. Reservations of settled fees are covered by the chain's own
+-- count.
+SELECT r.nonce
+FROM evm_nonce_reservations r
+JOIN listing_fees lf ON lf.policy_id = r.policy_id
+WHERE r.address = @address
+  AND r.nonce >= @min_nonce
+  AND r.policy_id <> @policy_id
+  AND (
+      lf.status IN ('pending', 'submitted')
+      OR (lf.status NOT IN ('paid', 'failed') AND EXISTS (
+          SELECT 1
+          FROM listing_fee_intents i
+          WHERE i.policy_id = r.policy_id AND cardinality(i.tx_hashes) > 0
+      ))
+  )
+ORDER BY r.nonce;
+
+-- name: UpsertNonceReservation :exec
+INSERT INTO evm_nonce_reservations (policy_id, address, nonce)
+VALUES ($1, $2, $3)
+ON CONFLICT (policy_id) DO UPDATE
+SET address = EXCLUDED.address, nonce = EXCLUDED.nonce, created_at = CURRENT_TIMESTAMP;
+
+-- name: DeleteNonceReservation :exec
+DELETE FROM evm_nonce_reservations
+WHERE policy_id = $1;
@@ -0,0 +1,4 @@
+-- name: InsertKeysignSession :exec
+INSERT INTO keysign_sessions (session_id, policy_id, tx_indexer_id, correlation_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (session_id) DO NOTHING;
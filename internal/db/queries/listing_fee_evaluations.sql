@@ -0,0 +1,9 @@
+-- name: InsertListingFeeEvaluation :exec
+INSERT INTO listing_fee_evaluations (policy_id, allowed, report)
+VALUES ($1, $2, $3);
+
+-- name: GetListingFeeEvaluationsByPolicyID :many
+SELECT id, policy_id, allowed, report, created_at
+FROM listing_fee_evaluations
+WHERE policy_id = $1
+ORDER BY id DESC;
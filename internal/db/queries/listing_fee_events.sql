@@ -0,0 +1,15 @@
+-- name: InsertListingFeeEvent :exec
+INSERT INTO listing_fee_events (policy_id, old_status, new_status, actor, tx_hash, reason)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetListingFeeEventsByPolicyID :many
+SELECT id, policy_id, old_status, new_status, actor, tx_hash, reason, created_at
+FROM listing_fee_events
+WHERE policy_id = $1
+ORDER BY id;
+
+-- name: GetListingFeeStatusForUpdate :one
+SELECT status
+FROM listing_fees
+WHERE policy_id = $1
+FOR UPDATE;
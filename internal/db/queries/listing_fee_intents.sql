@@ -0,0 +1,90 @@
+-- name: CreateListingFeeIntent :execrows
+INSERT INTO listing_fee_intents (policy_id, from_address, nonce, unsigned_tx)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (policy_id) DO NOTHING;
+
+-- name: GetListingFeeIntent :one
+SELECT policy_id, from_address, nonce, unsigned_tx, tx_hashes, created_at, updated_at, signed_tx, broadcast_attempts, broadcast_endpoint
+FROM listing_fee_intents
+WHERE policy_id = $1;
+
+-- name: RecordListingFeeIntentSignedTx :execrows
+-- Only a pending fee takes new hashes. The share lock orders this against a
+-- concurrent cancellation, so a cancelled fee is never broadcast.
+UPDATE listing_fee_intents i
+SET tx_hashes = CASE
+        WHEN sqlc.arg(tx_hash)::text = ANY(i.tx_hashes) THEN i.tx_hashes
+        ELSE array_append(i.tx_hashes, sqlc.arg(tx_hash)::text)
+    END,
+    signed_tx = sqlc.arg(signed_tx)::bytea,
+    updated_at = CURRENT_TIMESTAMP
+WHERE i.policy_id = $1
+  AND EXISTS (
+      SELECT 1 FROM listing_fees lf
+      WHERE lf.policy_id = i.policy_id AND lf.status = 'pending'
+      FOR SHARE
+  );
+
+-- name: IncrementListingFeeIntentBroadcastAttempts :one
+UPDATE listing_fee_intents i
+SET broadcast_attempts = i.broadcast_attempts + 1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE i.policy_id = $1
+  AND i.signed_tx IS NOT NULL
+  AND EXISTS (
+      SELECT 1 FROM listing_fees lf
+      WHERE lf.policy_id = i.policy_id AND lf.status = 'pending'
+      FOR SHARE
+  )
+RETURNING i.broadcast_attempts;
+
+-- name: ReplaceListingFeeIntentUnsignedTx :execrows
+-- Swaps in a repriced transaction for the same nonce. The earlier hashes are
+-- kept, since any of them may still be mined.
+UPDATE listing_fee_intents i
+SET unsigned_tx = sqlc.arg(unsigned_tx)::bytea,
+    signed_tx = NULL,
+    broadcast_attempts = 0,
+    updated_at = CURRENT_TIMESTAMP
+WHERE i.policy_id = $1
+  AND i.signed_tx = sqlc.arg(replaced_signed_tx)::bytea
+  AND EXISTS (
+      SELECT 1 FROM listing_fees lf
+      WHERE lf.policy_id = i.policy_id AND lf.status = 'pending'
+      FOR SHARE
+  );
+
+-- name: SetListingFeeIntentBroadcastEndpoint :exec
+UPDATE listing_fee_intents
+SET broadcast_endpoint = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE policy_id = $1;
+
+-- name: ResetListingFeeIntentBroadcastAttempts :exec
+UPDATE listing_fee_intents
+SET broadcast_attempts = 0,
+    updated_at = CURRENT_TIMESTAMP
+WHERE policy_id = $1;
+
+-- name: DeleteListingFeeIntent :exec
+DELETE FROM listing_fee_intents
+WHERE policy_id = $1;
+
+-- name: GetLiveIntentNonces :many
+-- Intents of fees that may still pay, with whether each was signed, for
+-- finding nonces no transaction will ever fill.
+SELECT i.policy_id, i.from_address, i.nonce, (cardinality(i.tx_hashes) > 0)::boolean AS signed
+FROM listing_fee_intents i
+JOIN listing_fees lf ON lf.policy_id = i.policy_id
+WHERE lf.status IN ('pending', 'submitted')
+ORDER BY i.from_address, i.nonce;
+
+-- name: DeleteUnsignedListingFeeIntent :execrows
+-- Only an intent nothing was ever signed for, of a fee no worker is executing,
+-- can be dropped without risking a transaction at its old nonce.
+DELETE FROM listing_fee_intents i
+WHERE i.policy_id = $1 AND i.signed_tx IS NULL AND cardinality(i.tx_hashes) = 0
+  AND NOT EXISTS (
+      SELECT 1 FROM listing_fee_leases l
+      WHERE l.policy_id = i.policy_id AND l.expires_at >= CURRENT_TIMESTAMP
+  );
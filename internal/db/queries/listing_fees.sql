@@ -1,13 +1,21 @@
--- name: CreateListingFee :exec
-INSERT INTO listing_fees (policy_id, public_key, target_plugin_id, amount, destination, status)
-VALUES ($1, $2, $3, $4, $5, $6)
+-- name: CreateListingFee :execrows
+-- The fee's amount was quoted when the policy was created, so its payment
+-- window runs from then. A ttl_seconds of zero means the fee never expires.
+INSERT INTO listing_fees (policy_id, public_key, target_plugin_id, amount, destination, status, expires_at)
+VALUES (
+    @policy_id, @public_key, @target_plugin_id, @amount, @destination, @status,
+    CASE WHEN @ttl_seconds::float8 > 0 THEN
+        COALESCE((SELECT pp.created_at::timestamp FROM plugin_policies pp WHERE pp.id = @policy_id), LOCALTIMESTAMP)
+            + make_interval(secs => @ttl_seconds::float8)
+    END
+)
 ON CONFLICT (policy_id) DO NOTHING;
 
 -- name: GetListingFeeByPolicyID :one
 SELECT id, policy_id, public_key, target_plugin_id, amount, destination,
        tx_hash, block_number, confirmations, status,
        submitted_at, paid_at, failure_reason,
-       created_at, updated_at
+       created_at, updated_at, expires_at, failure_details, batch_policy_id
 FROM listing_fees
 WHERE policy_id = $1;
 
@@ -15,7 +23,7 @@ WHERE policy_id = $1;
 SELECT id, policy_id, public_key, target_plugin_id, amount, destination,
        tx_hash, block_number, confirmations, status,
        submitted_at, paid_at, failure_reason,
-       created_at, updated_at
+       created_at, updated_at, expires_at, failure_details, batch_policy_id
 FROM listing_fees
 WHERE public_key = $1 AND target_plugin_id = $2
 ORDER BY created_at DESC
@@ -25,7 +33,7 @@ LIMIT 1;
 SELECT id, policy_id, public_key, target_plugin_id, amount, destination,
        tx_hash, block_number, confirmations, status,
        submitted_at, paid_at, failure_reason,
-       created_at, updated_at
+       created_at, updated_at, expires_at, failure_details, batch_policy_id
 FROM listing_fees
 WHERE public_key = $1 AND target_plugin_id = $2 AND status = 'pending'
 LIMIT 1;
@@ -34,32 +42,71 @@ LIMIT 1;
 SELECT id, policy_id, public_key, target_plugin_id, amount, destination,
        tx_hash, block_number, confirmations, status,
        submitted_at, paid_at, failure_reason,
-       created_at, updated_at
+       created_at, updated_at, expires_at, failure_details, batch_policy_id
 FROM listing_fees
 WHERE status = 'pending';
 
+-- name: ClaimListingFee :one
+-- Leases a pending fee to one worker. A fee in a batch is paid by its lead and
+-- is never claimed itself. SKIP LOCKED keeps a claim from blocking
+-- on a fee another transaction is changing, and the conflict guard refuses to
+-- steal a lease that is still live; either way no row is returned.
+WITH leased AS (
+    INSERT INTO listing_fee_leases (policy_id, owner, expires_at)
+    SELECT lf.policy_id, @lease_owner::text, CURRENT_TIMESTAMP + make_interval(secs => @lease_seconds::float8)
+    FROM listing_fees lf
+    WHERE lf.policy_id = @policy_id AND lf.status = 'pending' AND lf.batch_policy_id IS NULL
+    FOR UPDATE OF lf SKIP LOCKED
+    ON CONFLICT (policy_id) DO UPDATE
+    SET owner = EXCLUDED.owner, expires_at = EXCLUDED.expires_at
+    WHERE listing_fee_leases.expires_at < CURRENT_TIMESTAMP
+    RETURNING policy_id
+)
+SELECT lf.id, lf.policy_id, lf.public_key, lf.target_plugin_id, lf.amount, lf.destination,
+       lf.tx_hash, lf.block_number, lf.confirmations, lf.status,
+       lf.submitted_at, lf.paid_at, lf.failure_reason,
+       lf.created_at, lf.updated_at, lf.expires_at, lf.failure_details, lf.batch_policy_id
+FROM listing_fees lf
+JOIN leased ON leased.policy_id = lf.policy_id;
+
+-- name: RenewListingFeeLease :execrows
+UPDATE listing_fee_leases
+SET expires_at = CURRENT_TIMESTAMP + make_interval(secs => @lease_seconds::float8)
+WHERE policy_id = @policy_id AND owner = @lease_owner::text;
+
+-- name: ReleaseListingFeeLease :exec
+DELETE FROM listing_fee_leases
+WHERE policy_id = $1 AND owner = $2;
+
 -- name: GetSubmittedListingFees :many
+-- Fees in a batch follow their lead, so only the lead is synced.
 SELECT id, policy_id, public_key, target_plugin_id, amount, destination,
        tx_hash, block_number, confirmations, status,
        submitted_at, paid_at, failure_reason,
-       created_at, updated_at
+       created_at, updated_at, expires_at, failure_details, batch_policy_id
 FROM listing_fees
-WHERE status = 'submitted';
+WHERE status = 'submitted' AND batch_policy_id IS NULL;
 
--- name: MarkAsSubmitted :exec
+-- name: MarkAsSubmitted :execrows
 UPDATE listing_fees
 SET status = 'submitted', tx_hash = $2, submitted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 WHERE policy_id = $1 AND status = 'pending';
 
--- name: MarkAsPaid :exec
+-- name: MarkAsPaid :execrows
 UPDATE listing_fees
 SET status = 'paid', block_number = $2, confirmations = $3, paid_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 WHERE policy_id = $1 AND status = 'submitted';
 
--- name: MarkAsFailed :exec
-UPDATE listing_fees
-SET status = 'failed', failure_reason = $2, updated_at = CURRENT_TIMESTAMP
-WHERE policy_id = $1 AND status IN ('pending', 'submitted');
+-- name: MarkAsFailed :execrows
+-- A fee whose intent has a signed hash on record may still be mined, so it is
+-- left to reconciliation, which discards the intent once its nonce is spent.
+UPDATE listing_fees lf
+SET status = 'failed', failure_reason = $2, failure_details = $3, updated_at = CURRENT_TIMESTAMP
+WHERE lf.policy_id = $1 AND lf.status IN ('pending', 'submitted')
+  AND NOT EXISTS (
+      SELECT 1 FROM listing_fee_intents i
+      WHERE i.policy_id = lf.policy_id AND cardinality(i.tx_hashes) > 0
+  );
 
 -- name: DeactivatePolicy :exec
 UPDATE plugin_policies
@@ -86,30 +133,108 @@ SELECT pp.id
 FROM plugin_policies pp
 LEFT JOIN listing_fees lf ON lf.policy_id = pp.id
 WHERE pp.active = true
-  AND lf.id IS NULL;
+  AND pp.deleted = false
+  AND lf.id IS NULL
+  AND NOT EXISTS (SELECT 1 FROM policy_ingestion_errors e WHERE e.policy_id = pp.id);
 
--- name: SyncPaidFees :execrows
-UPDATE listing_fees lf
-SET status = 'paid', paid_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
-FROM tx_indexer ti
-WHERE ti.policy_id = lf.policy_id
-  AND lf.status = 'submitted'
-  AND ti.status_onchain = 'SUCCESS';
+-- name: GetOrphanedPendingPolicyIDs :many
+-- Pending fees whose policy was deactivated or deleted in the verifier.
+SELECT lf.policy_id
+FROM listing_fees lf
+LEFT JOIN plugin_policies pp ON pp.id = lf.policy_id
+WHERE lf.status = 'pending'
+  AND (pp.id IS NULL OR pp.active = false OR pp.deleted = true);
 
--- name: SyncFailedFees :execrows
+-- name: BackfillListingFeeExpiry :execrows
+-- Gives pending fees created without a payment window the one CreateListingFee
+-- would have set.
 UPDATE listing_fees lf
-SET status = 'failed',
-    failure_reason = CASE WHEN ti.lost THEN 'transaction lost' ELSE 'transaction failed on-chain' END,
+SET expires_at = COALESCE((SELECT pp.created_at::timestamp FROM plugin_policies pp WHERE pp.id = lf.policy_id), lf.created_at)
+        + make_interval(secs => @ttl_seconds::float8),
     updated_at = CURRENT_TIMESTAMP
-FROM tx_indexer ti
-WHERE ti.policy_id = lf.policy_id
-  AND lf.status = 'submitted'
-  AND (ti.status_onchain = 'FAIL' OR ti.lost = true);
+WHERE lf.status = 'pending' AND lf.expires_at IS NULL;
+
+-- name: GetExpiredPendingPolicyIDs :many
+SELECT policy_id
+FROM listing_fees
+WHERE status = 'pending'
+  AND expires_at <= CURRENT_TIMESTAMP;
+
+-- name: ExpirePendingListingFee :execrows
+-- Like cancellation, expiry leaves fees with a broadcast payment, or covered by
+-- a batch lead's transfer in the making, to reconciliation.
+UPDATE listing_fees lf
+SET status = 'expired', batch_policy_id = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE lf.policy_id = $1 AND lf.status = 'pending'
+  AND lf.expires_at <= CURRENT_TIMESTAMP
+  AND NOT EXISTS (
+      SELECT 1 FROM listing_fee_intents i
+      WHERE i.policy_id = lf.policy_id AND cardinality(i.tx_hashes) > 0
+  )
+  AND NOT EXISTS (SELECT 1 FROM listing_fee_intents i WHERE i.policy_id = lf.batch_policy_id)
+  AND NOT EXISTS (
+      SELECT 1 FROM listing_fee_leases l
+      WHERE l.policy_id = lf.batch_policy_id AND l.expires_at >= CURRENT_TIMESTAMP
+  );
+
+-- name: TryAdvisoryLock :one
+SELECT pg_try_advisory_lock(hashtext(@name::text))::boolean AS acquired;
+
+-- name: SyncPaidFee :execrows
+WITH updated AS (
+    UPDATE listing_fees lf
+    SET status = 'paid', paid_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+    FROM tx_indexer ti
+    WHERE lf.policy_id = $1
+      AND ti.policy_id = lf.policy_id
+      AND ti.tx_hash = lf.tx_hash
+      AND lf.status = 'submitted'
+      AND ti.status_onchain = 'SUCCESS'
+    RETURNING lf.policy_id, lf.tx_hash
+)
+INSERT INTO listing_fee_events (policy_id, old_status, new_status, actor, tx_hash)
+SELECT policy_id, 'submitted', 'paid', 'sync', tx_hash
+FROM updated;
+
+-- name: SyncFailedFee :execrows
+WITH updated AS (
+    UPDATE listing_fees lf
+    SET status = 'failed', failure_reason = 'transaction failed on-chain', updated_at = CURRENT_TIMESTAMP
+    FROM tx_indexer ti
+    WHERE lf.policy_id = $1
+      AND ti.policy_id = lf.policy_id
+      AND ti.tx_hash = lf.tx_hash
+      AND lf.status = 'submitted'
+      AND ti.status_onchain = 'FAIL'
+    RETURNING lf.policy_id, lf.tx_hash, lf.failure_reason
+)
+INSERT INTO listing_fee_events (policy_id, old_status, new_status, actor, tx_hash, reason)
+SELECT policy_id, 'submitted', 'failed', 'sync', tx_hash, failure_reason
+FROM updated;
+
+-- name: RequeueLostFee :execrows
+-- A transaction tx_indexer lost track of may still be mined, so its fee goes
+-- back to pending, where the intent is reconciled by hash and nonce.
+WITH updated AS (
+    UPDATE listing_fees lf
+    SET status = 'pending', updated_at = CURRENT_TIMESTAMP
+    FROM tx_indexer ti
+    WHERE lf.policy_id = $1
+      AND ti.policy_id = lf.policy_id
+      AND ti.tx_hash = lf.tx_hash
+      AND lf.status = 'submitted'
+      AND ti.lost = true
+      AND EXISTS (SELECT 1 FROM listing_fee_intents i WHERE i.policy_id = lf.policy_id)
+    RETURNING lf.policy_id, lf.tx_hash
+)
+INSERT INTO listing_fee_events (policy_id, old_status, new_status, actor, tx_hash, reason)
+SELECT policy_id, 'submitted', 'pending', 'sync', tx_hash, 'transaction lost, reconciling'
+FROM updated;
 
 -- name: UpdateConfirmations :exec
 UPDATE listing_fees
 SET confirmations = $2, updated_at = CURRENT_TIMESTAMP
-WHERE policy_id = $1;
+WHERE policy_id = $1 OR batch_policy_id = $1;
 
 -- name: IsListingFeePaidForPlugin :one
 SELECT EXISTS(
@@ -117,3 +242,99 @@ SELECT EXISTS(
     WHERE target_plugin_id = $1
       AND status = 'paid'
 );
+
+-- name: IsListingFeePaidForVaultPlugin :one
+SELECT EXISTS(
+    SELECT 1 FROM listing_fees
+    WHERE public_key = $1
+      AND target_plugin_id = $2
+      AND status = 'paid'
+);
+
+-- name: GetPendingListingFeeStats :one
+SELECT COUNT(*)::bigint AS pending_count,
+       COALESCE(EXTRACT(EPOCH FROM LOCALTIMESTAMP - MIN(created_at)), 0)::float8 AS oldest_age_seconds
+FROM listing_fees
+WHERE status = 'pending';
+
+-- name: GetPendingPolicyIDsByPublicKey :many
+SELECT policy_id
+FROM listing_fees
+WHERE public_key = $1
+  AND status = 'pending';
+
+-- name: HasListingFee :one
+SELECT EXISTS(SELECT 1 FROM listing_fees WHERE policy_id = $1);
+
+-- name: JoinListingFeeBatch :many
+-- Adds up to @max_fees more of the vault's pending fees to the batch led by
+-- @lead_policy_id. Only fees nothing else pays for qualify: no intent or live
+-- lease of their own, not in a batch and not leading one.
+UPDATE listing_fees lf
+SET batch_policy_id = @lead_policy_id::uuid, updated_at = CURRENT_TIMESTAMP
+WHERE lf.policy_id IN (
+    SELECT c.policy_id FROM listing_fees c
+    WHERE c.public_key = @public_key AND c.status = 'pending'
+      AND c.policy_id <> @lead_policy_id::uuid AND c.batch_policy_id IS NULL
+      AND NOT EXISTS (SELECT 1 FROM listing_fee_intents i WHERE i.policy_id = c.policy_id)
+      AND NOT EXISTS (
+          SELECT 1 FROM listing_fee_leases l
+          WHERE l.policy_id = c.policy_id AND l.expires_at >= CURRENT_TIMESTAMP
+      )
+      AND NOT EXISTS (SELECT 1 FROM listing_fees m WHERE m.batch_policy_id = c.policy_id)
+    ORDER BY c.created_at
+    LIMIT @max_fees
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING lf.policy_id;
+
+-- name: GetListingFeeBatch :many
+-- The fees a batch lead's transfer pays for besides its own.
+SELECT id, policy_id, public_key, target_plugin_id, amount, destination,
+       tx_hash, block_number, confirmations, status,
+       submitted_at, paid_at, failure_reason,
+       created_at, updated_at, expires_at, failure_details, batch_policy_id
+FROM listing_fees
+WHERE batch_policy_id = $1
+ORDER BY created_at;
+
+-- name: FollowListingFeeBatchLead :execrows
+-- Moves the fees in a batch to the status and transaction of their lead while
+-- it is pending, submitted or paid.
+WITH updated AS (
+    UPDATE listing_fees m
+    SET status = lead.status, tx_hash = lead.tx_hash, block_number = lead.block_number,
+        confirmations = lead.confirmations, submitted_at = lead.submitted_at,
+        paid_at = lead.paid_at, updated_at = CURRENT_TIMESTAMP
+    FROM listing_fees lead, listing_fees old
+    WHERE lead.policy_id = @lead_policy_id::uuid
+      AND m.batch_policy_id = lead.policy_id
+      AND old.id = m.id
+      AND lead.status IN ('pending', 'submitted', 'paid')
+      AND m.status IN ('pending', 'submitted')
+      AND m.status <> lead.status
+    RETURNING m.policy_id, old.status AS old_status, m.status AS new_status, m.tx_hash, lead.policy_id AS lead_policy_id
+)
+INSERT INTO listing_fee_events (policy_id, old_status, new_status, actor, tx_hash, reason)
+SELECT policy_id, old_status, new_status, @actor::text, tx_hash, 'following batch lead ' || lead_policy_id::text
+FROM updated;
+
+-- name: ReleaseListingFeeBatch :execrows
+-- Returns the fees in a batch to pending on their own once their lead failed,
+-- was cancelled or expired, since its transfer paid for none of them.
+WITH updated AS (
+    UPDATE listing_fees m
+    SET status = 'pending', batch_policy_id = NULL, tx_hash = NULL, submitted_at = NULL,
+        updated_at = CURRENT_TIMESTAMP
+    FROM listing_fees lead, listing_fees old
+    WHERE lead.policy_id = @lead_policy_id::uuid
+      AND m.batch_policy_id = lead.policy_id
+      AND old.id = m.id
+      AND lead.status IN ('failed', 'cancelled', 'expired')
+      AND m.status IN ('pending', 'submitted')
+    RETURNING m.policy_id, old.status AS old_status, lead.policy_id AS lead_policy_id, lead.status AS lead_status
+)
+INSERT INTO listing_fee_events (policy_id, old_status, new_status, actor, reason)
+SELECT policy_id, old_status, 'pending', @actor::text, 'batch lead ' || lead_policy_id::text || ' ' || lead_status
+FROM updated
+WHERE old_status <> 'pending';
@@ -0,0 +1,12 @@
+-- name: ListenPolicyCreated :exec
+LISTEN developer_policy_created;
+
+-- name: RecordPolicyIngestionError :exec
+INSERT INTO policy_ingestion_errors (policy_id, error)
+VALUES ($1, $2)
+ON CONFLICT (policy_id) DO UPDATE SET error = EXCLUDED.error;
+
+-- name: GetPolicyIngestionError :one
+SELECT policy_id, error, created_at
+FROM policy_ingestion_errors
+WHERE policy_id = $1;
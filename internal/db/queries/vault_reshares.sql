@@ -0,0 +1,18 @@
+-- name: StartVaultReshare :exec
+INSERT INTO vault_reshares (public_key, plugin_id, session_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (public_key) DO UPDATE
+SET plugin_id = EXCLUDED.plugin_id, session_id = EXCLUDED.session_id, started_at = CURRENT_TIMESTAMP;
+
+-- name: FinishVaultReshare :exec
+DELETE FROM vault_reshares
+WHERE public_key = $1 AND session_id = $2;
+
+-- name: IsVaultResharing :one
+-- A reshare that never finished, e.g. because its worker died, stops pausing
+-- fees once it is older than @timeout_seconds.
+SELECT EXISTS(
+    SELECT 1 FROM vault_reshares
+    WHERE public_key = @public_key
+      AND started_at > CURRENT_TIMESTAMP - make_interval(secs => @timeout_seconds::float8)
+);
@@ -5,7 +5,7 @@ CREATE TABLE listing_fees (
     target_plugin_id TEXT NOT NULL,
     amount NUMERIC(78,0) NOT NULL,
     destination TEXT NOT NULL,
-    tx_hash TEXT UNIQUE,
+    tx_hash TEXT,
     block_number BIGINT,
     confirmations INTEGER NOT NULL DEFAULT 0,
     status TEXT NOT NULL DEFAULT 'pending',
@@ -13,18 +13,124 @@ CREATE TABLE listing_fees (
     paid_at TIMESTAMP,
     failure_reason TEXT,
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP,
+    failure_details JSONB,
+    batch_policy_id UUID
 );
 
 CREATE TABLE plugin_policies (
     id UUID PRIMARY KEY,
+    public_key TEXT NOT NULL,
     active BOOLEAN NOT NULL DEFAULT true,
-    deactivation_reason TEXT
+    deactivation_reason TEXT,
+    deleted BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
 );
 
 CREATE TABLE tx_indexer (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     policy_id UUID NOT NULL,
+    tx_hash TEXT,
+    status TEXT NOT NULL DEFAULT '',
     status_onchain TEXT NOT NULL DEFAULT '',
-    lost BOOLEAN NOT NULL DEFAULT false
+    lost BOOLEAN NOT NULL DEFAULT false,
+    broadcasted_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE admin_audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    operator TEXT NOT NULL,
+    action TEXT NOT NULL,
+    policy_id UUID NOT NULL,
+    reason TEXT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE listing_fee_events (
+    id BIGSERIAL PRIMARY KEY,
+    policy_id UUID NOT NULL,
+    old_status TEXT,
+    new_status TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    tx_hash TEXT,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE listing_fee_intents (
+    policy_id UUID PRIMARY KEY REFERENCES listing_fees(policy_id),
+    from_address TEXT NOT NULL,
+    nonce BIGINT NOT NULL,
+    unsigned_tx BYTEA NOT NULL,
+    tx_hashes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    signed_tx BYTEA,
+    broadcast_attempts INT NOT NULL DEFAULT 0,
+    broadcast_endpoint TEXT
+);
+
+CREATE TABLE listing_fee_leases (
+    policy_id UUID PRIMARY KEY REFERENCES listing_fees(policy_id),
+    owner TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE policy_ingestion_errors (
+    policy_id UUID PRIMARY KEY,
+    error TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE evm_nonces (
+    address TEXT PRIMARY KEY,
+    next_nonce BIGINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE evm_nonce_reservations (
+    policy_id UUID PRIMARY KEY REFERENCES listing_fees(policy_id) ON DELETE CASCADE,
+    address TEXT NOT NULL,
+    nonce BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE listing_fee_evaluations (
+    id BIGSERIAL PRIMARY KEY,
+    policy_id UUID NOT NULL REFERENCES listing_fees(policy_id) ON DELETE CASCADE,
+    allowed BOOLEAN NOT NULL,
+    report JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE derived_addresses (
+    public_key TEXT PRIMARY KEY,
+    encrypted_address BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE vault_reshares (
+    public_key TEXT PRIMARY KEY,
+    plugin_id TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE developer_auth_challenges (
+    nonce TEXT PRIMARY KEY,
+    public_key TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE keysign_sessions (
+    session_id TEXT PRIMARY KEY,
+    policy_id UUID NOT NULL REFERENCES listing_fees(policy_id) ON DELETE CASCADE,
+    tx_indexer_id UUID NOT NULL,
+    correlation_id TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
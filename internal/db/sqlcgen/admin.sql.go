@@ -0,0 +1,273 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: admin.sql
+
+package sqlcgen
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const cancelPendingListingFee = `-- name: CancelPendingListingFee :execrows
+UPDATE listing_fees lf
+SET status = 'cancelled', batch_policy_id = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE lf.policy_id = $1 AND lf.status = 'pending'
+  AND NOT EXISTS (
+      SELECT 1 FROM listing_fee_intents i
+      WHERE i.policy_id = lf.policy_id AND cardinality(i.tx_hashes) > 0
+  )
+  AND NOT EXISTS (SELECT 1 FROM listing_fee_intents i WHERE i.policy_id = lf.batch_policy_id)
+  AND NOT EXISTS (
+      SELECT 1 FROM listing_fee_leases l
+      WHERE l.policy_id = lf.batch_policy_id AND l.expires_at >= CURRENT_TIMESTAMP
+  )
+`
+
+// A fee whose intent has a signed hash on record may already be paying, so it
+// is left to reconciliation instead. So is a fee in a batch whose lead is
+// building or paying the transfer that covers it; otherwise it leaves the batch.
+func (q *Queries) CancelPendingListingFee(ctx context.Context, policyID uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, cancelPendingListingFee, policyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getAdminAuditRecordsByPolicyID = `-- name: GetAdminAuditRecordsByPolicyID :many
+SELECT id, operator, action, policy_id, reason, details, created_at
+FROM admin_audit_log
+WHERE policy_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetAdminAuditRecordsByPolicyID(ctx context.Context, policyID uuid.UUID) ([]AdminAuditLog, error) {
+	rows, err := q.db.Query(ctx, getAdminAuditRecordsByPolicyID, policyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AdminAuditLog
+	for rows.Next() {
+		var i AdminAuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Operator,
+			&i.Action,
+			&i.PolicyID,
+			&i.Reason,
+			&i.Details,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTxIndexerRecordsByPolicyID = `-- name: GetTxIndexerRecordsByPolicyID :many
+SELECT id, tx_hash, status::text AS status, status_onchain::text AS status_onchain,
+       lost, broadcasted_at, created_at, updated_at
+FROM tx_indexer
+WHERE policy_id = $1
+ORDER BY created_at DESC
+`
+
+type GetTxIndexerRecordsByPolicyIDRow struct {
+	ID            uuid.UUID
+	TxHash        *string
+	Status        string
+	StatusOnchain string
+	Lost          bool
+	BroadcastedAt *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (q *Queries) GetTxIndexerRecordsByPolicyID(ctx context.Context, policyID uuid.UUID) ([]GetTxIndexerRecordsByPolicyIDRow, error) {
+	rows, err := q.db.Query(ctx, getTxIndexerRecordsByPolicyID, policyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTxIndexerRecordsByPolicyIDRow
+	for rows.Next() {
+		var i GetTxIndexerRecordsByPolicyIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TxHash,
+			&i.Status,
+			&i.StatusOnchain,
+			&i.Lost,
+			&i.BroadcastedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertAdminAuditRecord = `-- name: InsertAdminAuditRecord :exec
+INSERT INTO admin_audit_log (operator, action, policy_id, reason, details)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type InsertAdminAuditRecordParams struct {
+	Operator string
+	Action   string
+	PolicyID uuid.UUID
+	Reason   string
+	Details  []byte
+}
+
+func (q *Queries) InsertAdminAuditRecord(ctx context.Context, arg InsertAdminAuditRecordParams) error {
+	_, err := q.db.Exec(ctx, insertAdminAuditRecord,
+		arg.Operator,
+		arg.Action,
+		arg.PolicyID,
+		arg.Reason,
+		arg.Details,
+	)
+	return err
+}
+
+const isTxHashClaimedByOtherFee = `-- name: IsTxHashClaimedByOtherFee :one
+SELECT (EXISTS(
+    SELECT 1 FROM listing_fees lf
+    WHERE lf.tx_hash = $1::text AND lf.policy_id <> $2::uuid
+      AND lf.batch_policy_id IS DISTINCT FROM $2::uuid
+) OR EXISTS(
+    SELECT 1 FROM listing_fee_intents i
+    WHERE $1::text = ANY(i.tx_hashes) AND i.policy_id <> $2::uuid
+))::boolean AS claimed
+`
+
+type IsTxHashClaimedByOtherFeeParams struct {
+	TxHash   string
+	PolicyID uuid.UUID
+}
+
+// The fees a batch lead's transfer covers share its hash, so they don't count.
+func (q *Queries) IsTxHashClaimedByOtherFee(ctx context.Context, arg IsTxHashClaimedByOtherFeeParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isTxHashClaimedByOtherFee, arg.TxHash, arg.PolicyID)
+	var claimed bool
+	err := row.Scan(&claimed)
+	return claimed, err
+}
+
+const listListingFeesByStatus = `-- name: ListListingFeesByStatus :many
+SELECT id, policy_id, public_key, target_plugin_id, amount, destination,
+       tx_hash, block_number, confirmations, status,
+       submitted_at, paid_at, failure_reason,
+       created_at, updated_at, expires_at, failure_details, batch_policy_id
+FROM listing_fees
+WHERE status = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListListingFeesByStatusParams struct {
+	Status string
+	Limit  int32
+	Offset int32
+}
+
+func (q *Queries) ListListingFeesByStatus(ctx context.Context, arg ListListingFeesByStatusParams) ([]ListingFee, error) {
+	rows, err := q.db.Query(ctx, listListingFeesByStatus, arg.Status, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListingFee
+	for rows.Next() {
+		var i ListingFee
+		if err := rows.Scan(
+			&i.ID,
+			&i.PolicyID,
+			&i.PublicKey,
+			&i.TargetPluginID,
+			&i.Amount,
+			&i.Destination,
+			&i.TxHash,
+			&i.BlockNumber,
+			&i.Confirmations,
+			&i.Status,
+			&i.SubmittedAt,
+			&i.PaidAt,
+			&i.FailureReason,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ExpiresAt,
+			&i.FailureDetails,
+			&i.BatchPolicyID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markAsPaidManually = `-- name: MarkAsPaidManually :execrows
+UPDATE listing_fees lf
+SET status = 'paid', tx_hash = $2, block_number = $3, failure_reason = NULL, failure_details = NULL,
+    paid_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE lf.policy_id = $1 AND lf.status IN ('pending', 'submitted', 'failed')
+  AND lf.batch_policy_id IS NULL
+  AND (lf.status <> 'pending' OR (
+      NOT EXISTS (SELECT 1 FROM listing_fee_intents i WHERE i.policy_id = lf.policy_id)
+      AND NOT EXISTS (
+          SELECT 1 FROM listing_fee_leases l
+          WHERE l.policy_id = lf.policy_id AND l.expires_at >= CURRENT_TIMESTAMP
+      )
+  ))
+`
+
+type MarkAsPaidManuallyParams struct {
+	PolicyID    uuid.UUID
+	TxHash      *string
+	BlockNumber *int64
+}
+
+// A pending fee with an intent or a live lease may be signing or broadcasting
+// its own payment, so it is refused rather than risk paying twice. A fee in a
+// batch is settled through its batch lead.
+func (q *Queries) MarkAsPaidManually(ctx context.Context, arg MarkAsPaidManuallyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markAsPaidManually, arg.PolicyID, arg.TxHash, arg.BlockNumber)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const retryFailedListingFee = `-- name: RetryFailedListingFee :execrows
+UPDATE listing_fees
+SET status = 'pending', tx_hash = NULL, block_number = NULL, submitted_at = NULL,
+    failure_reason = NULL, failure_details = NULL, confirmations = 0, updated_at = CURRENT_TIMESTAMP
+WHERE policy_id = $1 AND status = 'failed'
+`
+
+func (q *Queries) RetryFailedListingFee(ctx context.Context, policyID uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, retryFailedListingFee, policyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,40 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: derived_addresses.sql
+
+package sqlcgen
+
+import (
+	"context"
+)
+
+const getDerivedAddress = `-- name: GetDerivedAddress :one
+SELECT encrypted_address
+FROM derived_addresses
+WHERE public_key = $1
+`
+
+func (q *Queries) GetDerivedAddress(ctx context.Context, publicKey string) ([]byte, error) {
+	row := q.db.QueryRow(ctx, getDerivedAddress, publicKey)
+	var encrypted_address []byte
+	err := row.Scan(&encrypted_address)
+	return encrypted_address, err
+}
+
+const upsertDerivedAddress = `-- name: UpsertDerivedAddress :exec
+INSERT INTO derived_addresses (public_key, encrypted_address)
+VALUES ($1, $2)
+ON CONFLICT (public_key) DO UPDATE
+SET encrypted_address = EXCLUDED.encrypted_address, created_at = CURRENT_TIMESTAMP
+`
+
+type UpsertDerivedAddressParams struct {
+	PublicKey        string
+	EncryptedAddress []byte
+}
+
+func (q *Queries) UpsertDerivedAddress(ctx context.Context, arg UpsertDerivedAddressParams) error {
+	_, err := q.db.Exec(ctx, upsertDerivedAddress, arg.PublicKey, arg.EncryptedAddress)
+	return err
+}
@@ -0,0 +1,72 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: developer_auth.sql
+
+package sqlcgen
+
+import (
+	"context"
+	"time"
+)
+
+const consumeDeveloperAuthChallenge = `-- name: ConsumeDeveloperAuthChallenge :one
+DELETE FROM developer_auth_challenges
+WHERE nonce = $1
+RETURNING public_key, expires_at
+`
+
+type ConsumeDeveloperAuthChallengeRow struct {
+	PublicKey string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) ConsumeDeveloperAuthChallenge(ctx context.Context, nonce string) (ConsumeDeveloperAuthChallengeRow, error) {
+	row := q.db.QueryRow(ctx, consumeDeveloperAuthChallenge, nonce)
+	var i ConsumeDeveloperAuthChallengeRow
+	err := row.Scan(&i.PublicKey, &i.ExpiresAt)
+	return i, err
+}
+
+const createDeveloperAuthChallenge = `-- name: CreateDeveloperAuthChallenge :exec
+INSERT INTO developer_auth_challenges (nonce, public_key, expires_at)
+VALUES ($1, $2, $3)
+`
+
+type CreateDeveloperAuthChallengeParams struct {
+	Nonce     string
+	PublicKey string
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreateDeveloperAuthChallenge(ctx context.Context, arg CreateDeveloperAuthChallengeParams) error {
+	_, err := q.db.Exec(ctx, createDeveloperAuthChallenge, arg.Nonce, arg.PublicKey, arg.ExpiresAt)
+	return err
+}
+
+const deleteExpiredDeveloperAuthChallenges = `-- name: DeleteExpiredDeveloperAuthChallenges :execrows
+DELETE FROM developer_auth_challenges
+WHERE expires_at < $1
+`
+
+func (q *Queries) DeleteExpiredDeveloperAuthChallenges(ctx context.Context, expiresAt time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredDeveloperAuthChallenges, expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const isKnownDeveloperPublicKey = `-- name: IsKnownDeveloperPublicKey :one
+SELECT (EXISTS(SELECT 1 FROM listing_fees lf WHERE lf.public_key = $1::text)
+    OR EXISTS(SELECT 1 FROM plugin_policies pp WHERE pp.public_key = $1::text AND NOT pp.deleted))::boolean AS known
+`
+
+// A vault is known once it installed a policy of this plugin, whether or not
+// its listing fee was created yet.
+func (q *Queries) IsKnownDeveloperPublicKey(ctx context.Context, publicKey string) (bool, error) {
+	row := q.db.QueryRow(ctx, isKnownDeveloperPublicKey, publicKey)
+	var known bool
+	err := row.Scan(&known)
+	return known, err
+}
@@ -0,0 +1,154 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: evm_nonces.sql
+
+package sqlcgen
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const deleteNonceReservation = `-- name: DeleteNonceReservation :exec
+DELETE FROM evm_nonce_reservations
+WHERE policy_id = $1
+`
+
+func (q *Queries) DeleteNonceReservation(ctx context.Context, policyID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteNonceReservation, policyID)
+	return err
+}
+
+const ensureEvmNonce = `-- name: EnsureEvmNonce :exec
+INSERT INTO evm_nonces (address, next_nonce)
+VALUES ($1, $2)
+ON CONFLICT (address) DO NOTHING
+`
+
+type EnsureEvmNonceParams struct {
+	Address   string
+	NextNonce int64
+}
+
+func (q *Queries) EnsureEvmNonce(ctx context.Context, arg EnsureEvmNonceParams) error {
+	_, err := q.db.Exec(ctx, ensureEvmNonce, arg.Address, arg.NextNonce)
+	return err
+}
+
+const getEvmNonceForUpdate = `-- name: GetEvmNonceForUpdate :one
+SELECT next_nonce
+FROM evm_nonces
+WHERE address = $1
+FOR UPDATE
+`
+
+// Serializes nonce assignment per address.
+func (q *Queries) GetEvmNonceForUpdate(ctx context.Context, address string) (int64, error) {
+	row := q.db.QueryRow(ctx, getEvmNonceForUpdate, address)
+	var next_nonce int64
+	err := row.Scan(&next_nonce)
+	return next_nonce, err
+}
+
+const getHeldNonces = `-- name: GetHeldNonces :many
+SELECT r.nonce
+FROM evm_nonce_reservations r
+JOIN listing_fees lf ON lf.policy_id = r.policy_id
+WHERE r.address = $1
+  AND r.nonce >= $2
+  AND r.policy_id <> $3
+  AND (
+      lf.status IN ('pending', 'submitted')
+      OR (lf.status NOT IN ('paid', 'failed') AND EXISTS (
+          SELECT 1
+          FROM listing_fee_intents i
+          WHERE i.policy_id = r.policy_id AND cardinality(i.tx_hashes) > 0
+      ))
+  )
+ORDER BY r.nonce
+`
+
+type GetHeldNoncesParams struct {
+	Address  string
+	MinNonce int64
+	PolicyID uuid.UUID
+}
+
+// Nonces at or above the chain's pending nonce still reserved by other fees
+// that may pay: pending or submitted ones, and any fee with a signed payment
+// until it is confirmed or failed, since that transaction can still be mined
+// at its nonce. Reservations of settled fees are covered by the chain's own
+// count.
+func (q *Queries) GetHeldNonces(ctx context.Context, arg GetHeldNoncesParams) ([]int64, error) {
+	rows, err := q.db.Query(ctx, getHeldNonces, arg.Address, arg.MinNonce, arg.PolicyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var nonce int64
+		if err := rows.Scan(&nonce); err != nil {
+			return nil, err
+		}
+		items = append(items, nonce)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNonceReservation = `-- name: GetNonceReservation :one
+SELECT policy_id, address, nonce, created_at
+FROM evm_nonce_reservations
+WHERE policy_id = $1
+`
+
+func (q *Queries) GetNonceReservation(ctx context.Context, policyID uuid.UUID) (EvmNonceReservation, error) {
+	row := q.db.QueryRow(ctx, getNonceReservation, policyID)
+	var i EvmNonceReservation
+	err := row.Scan(
+		&i.PolicyID,
+		&i.Address,
+		&i.Nonce,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const setEvmNextNonce = `-- name: SetEvmNextNonce :exec
+UPDATE evm_nonces
+SET next_nonce = $2, updated_at = CURRENT_TIMESTAMP
+WHERE address = $1
+`
+
+type SetEvmNextNonceParams struct {
+	Address   string
+	NextNonce int64
+}
+
+func (q *Queries) SetEvmNextNonce(ctx context.Context, arg SetEvmNextNonceParams) error {
+	_, err := q.db.Exec(ctx, setEvmNextNonce, arg.Address, arg.NextNonce)
+	return err
+}
+
+const upsertNonceReservation = `-- name: UpsertNonceReservation :exec
+INSERT INTO evm_nonce_reservations (policy_id, address, nonce)
+VALUES ($1, $2, $3)
+ON CONFLICT (policy_id) DO UPDATE
+SET address = EXCLUDED.address, nonce = EXCLUDED.nonce, created_at = CURRENT_TIMESTAMP
+`
+
+type UpsertNonceReservationParams struct {
+	PolicyID uuid.UUID
+	Address  string
+	Nonce    int64
+}
+
+func (q *Queries) UpsertNonceReservation(ctx context.Context, arg UpsertNonceReservationParams) error {
+	_, err := q.db.Exec(ctx, upsertNonceReservation, arg.PolicyID, arg.Address, arg.Nonce)
+	return err
+}
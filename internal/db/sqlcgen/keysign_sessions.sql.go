@@ -0,0 +1,35 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: keysign_sessions.sql
+
+package sqlcgen
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const insertKeysignSession = `-- name: InsertKeysignSession :exec
+INSERT INTO keysign_sessions (session_id, policy_id, tx_indexer_id, correlation_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (session_id) DO NOTHING
+`
+
+type InsertKeysignSessionParams struct {
+	SessionID     string
+	PolicyID      uuid.UUID
+	TxIndexerID   uuid.UUID
+	CorrelationID string
+}
+
+func (q *Queries) InsertKeysignSession(ctx context.Context, arg InsertKeysignSessionParams) error {
+	_, err := q.db.Exec(ctx, insertKeysignSession,
+		arg.SessionID,
+		arg.PolicyID,
+		arg.TxIndexerID,
+		arg.CorrelationID,
+	)
+	return err
+}
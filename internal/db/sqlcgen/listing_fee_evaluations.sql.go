@@ -0,0 +1,61 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: listing_fee_evaluations.sql
+
+package sqlcgen
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const getListingFeeEvaluationsByPolicyID = `-- name: GetListingFeeEvaluationsByPolicyID :many
+SELECT id, policy_id, allowed, report, created_at
+FROM listing_fee_evaluations
+WHERE policy_id = $1
+ORDER BY id DESC
+`
+
+func (q *Queries) GetListingFeeEvaluationsByPolicyID(ctx context.Context, policyID uuid.UUID) ([]ListingFeeEvaluation, error) {
+	rows, err := q.db.Query(ctx, getListingFeeEvaluationsByPolicyID, policyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListingFeeEvaluation
+	for rows.Next() {
+		var i ListingFeeEvaluation
+		if err := rows.Scan(
+			&i.ID,
+			&i.PolicyID,
+			&i.Allowed,
+			&i.Report,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertListingFeeEvaluation = `-- name: InsertListingFeeEvaluation :exec
+INSERT INTO listing_fee_evaluations (policy_id, allowed, report)
+VALUES ($1, $2, $3)
+`
+
+type InsertListingFeeEvaluationParams struct {
+	PolicyID uuid.UUID
+	Allowed  bool
+	Report   []byte
+}
+
+func (q *Queries) InsertListingFeeEvaluation(ctx context.Context, arg InsertListingFeeEvaluationParams) error {
+	_, err := q.db.Exec(ctx, insertListingFeeEvaluation, arg.PolicyID, arg.Allowed, arg.Report)
+	return err
+}
@@ -0,0 +1,88 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: listing_fee_events.sql
+
+package sqlcgen
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const getListingFeeEventsByPolicyID = `-- name: GetListingFeeEventsByPolicyID :many
+SELECT id, policy_id, old_status, new_status, actor, tx_hash, reason, created_at
+FROM listing_fee_events
+WHERE policy_id = $1
+ORDER BY id
+`
+
+func (q *Queries) GetListingFeeEventsByPolicyID(ctx context.Context, policyID uuid.UUID) ([]ListingFeeEvent, error) {
+	rows, err := q.db.Query(ctx, getListingFeeEventsByPolicyID, policyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListingFeeEvent
+	for rows.Next() {
+		var i ListingFeeEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.PolicyID,
+			&i.OldStatus,
+			&i.NewStatus,
+			&i.Actor,
+			&i.TxHash,
+			&i.Reason,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListingFeeStatusForUpdate = `-- name: GetListingFeeStatusForUpdate :one
+SELECT status
+FROM listing_fees
+WHERE policy_id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetListingFeeStatusForUpdate(ctx context.Context, policyID uuid.UUID) (string, error) {
+	row := q.db.QueryRow(ctx, getListingFeeStatusForUpdate, policyID)
+	var status string
+	err := row.Scan(&status)
+	return status, err
+}
+
+const insertListingFeeEvent = `-- name: InsertListingFeeEvent :exec
+INSERT INTO listing_fee_events (policy_id, old_status, new_status, actor, tx_hash, reason)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type InsertListingFeeEventParams struct {
+	PolicyID  uuid.UUID
+	OldStatus *string
+	NewStatus string
+	Actor     string
+	TxHash    *string
+	Reason    *string
+}
+
+func (q *Queries) InsertListingFeeEvent(ctx context.Context, arg InsertListingFeeEventParams) error {
+	_, err := q.db.Exec(ctx, insertListingFeeEvent,
+		arg.PolicyID,
+		arg.OldStatus,
+		arg.NewStatus,
+		arg.Actor,
+		arg.TxHash,
+		arg.Reason,
+	)
+	return err
+}
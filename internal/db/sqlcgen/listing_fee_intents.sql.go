@@ -0,0 +1,246 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: listing_fee_intents.sql
+
+package sqlcgen
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const createListingFeeIntent = `-- name: CreateListingFeeIntent :execrows
+INSERT INTO listing_fee_intents (policy_id, from_address, nonce, unsigned_tx)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (policy_id) DO NOTHING
+`
+
+type CreateListingFeeIntentParams struct {
+	PolicyID    uuid.UUID
+	FromAddress string
+	Nonce       int64
+	UnsignedTx  []byte
+}
+
+func (q *Queries) CreateListingFeeIntent(ctx context.Context, arg CreateListingFeeIntentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createListingFeeIntent,
+		arg.PolicyID,
+		arg.FromAddress,
+		arg.Nonce,
+		arg.UnsignedTx,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteListingFeeIntent = `-- name: DeleteListingFeeIntent :exec
+DELETE FROM listing_fee_intents
+WHERE policy_id = $1
+`
+
+func (q *Queries) DeleteListingFeeIntent(ctx context.Context, policyID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteListingFeeIntent, policyID)
+	return err
+}
+
+const deleteUnsignedListingFeeIntent = `-- name: DeleteUnsignedListingFeeIntent :execrows
+DELETE FROM listing_fee_intents i
+WHERE i.policy_id = $1 AND i.signed_tx IS NULL AND cardinality(i.tx_hashes) = 0
+  AND NOT EXISTS (
+      SELECT 1 FROM listing_fee_leases l
+      WHERE l.policy_id = i.policy_id AND l.expires_at >= CURRENT_TIMESTAMP
+  )
+`
+
+// Only an intent nothing was ever signed for, of a fee no worker is executing,
+// can be dropped without risking a transaction at its old nonce.
+func (q *Queries) DeleteUnsignedListingFeeIntent(ctx context.Context, policyID uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteUnsignedListingFeeIntent, policyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getListingFeeIntent = `-- name: GetListingFeeIntent :one
+SELECT policy_id, from_address, nonce, unsigned_tx, tx_hashes, created_at, updated_at, signed_tx, broadcast_attempts, broadcast_endpoint
+FROM listing_fee_intents
+WHERE policy_id = $1
+`
+
+func (q *Queries) GetListingFeeIntent(ctx context.Context, policyID uuid.UUID) (ListingFeeIntent, error) {
+	row := q.db.QueryRow(ctx, getListingFeeIntent, policyID)
+	var i ListingFeeIntent
+	err := row.Scan(
+		&i.PolicyID,
+		&i.FromAddress,
+		&i.Nonce,
+		&i.UnsignedTx,
+		&i.TxHashes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SignedTx,
+		&i.BroadcastAttempts,
+		&i.BroadcastEndpoint,
+	)
+	return i, err
+}
+
+const getLiveIntentNonces = `-- name: GetLiveIntentNonces :many
+SELECT i.policy_id, i.from_address, i.nonce, (cardinality(i.tx_hashes) > 0)::boolean AS signed
+FROM listing_fee_intents i
+JOIN listing_fees lf ON lf.policy_id = i.policy_id
+WHERE lf.status IN ('pending', 'submitted')
+ORDER BY i.from_address, i.nonce
+`
+
+type GetLiveIntentNoncesRow struct {
+	PolicyID    uuid.UUID
+	FromAddress string
+	Nonce       int64
+	Signed      bool
+}
+
+// Intents of fees that may still pay, with whether each was signed, for
+// finding nonces no transaction will ever fill.
+func (q *Queries) GetLiveIntentNonces(ctx context.Context) ([]GetLiveIntentNoncesRow, error) {
+	rows, err := q.db.Query(ctx, getLiveIntentNonces)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLiveIntentNoncesRow
+	for rows.Next() {
+		var i GetLiveIntentNoncesRow
+		if err := rows.Scan(
+			&i.PolicyID,
+			&i.FromAddress,
+			&i.Nonce,
+			&i.Signed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const incrementListingFeeIntentBroadcastAttempts = `-- name: IncrementListingFeeIntentBroadcastAttempts :one
+UPDATE listing_fee_intents i
+SET broadcast_attempts = i.broadcast_attempts + 1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE i.policy_id = $1
+  AND i.signed_tx IS NOT NULL
+  AND EXISTS (
+      SELECT 1 FROM listing_fees lf
+      WHERE lf.policy_id = i.policy_id AND lf.status = 'pending'
+      FOR SHARE
+  )
+RETURNING i.broadcast_attempts
+`
+
+func (q *Queries) IncrementListingFeeIntentBroadcastAttempts(ctx context.Context, policyID uuid.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, incrementListingFeeIntentBroadcastAttempts, policyID)
+	var broadcast_attempts int32
+	err := row.Scan(&broadcast_attempts)
+	return broadcast_attempts, err
+}
+
+const recordListingFeeIntentSignedTx = `-- name: RecordListingFeeIntentSignedTx :execrows
+UPDATE listing_fee_intents i
+SET tx_hashes = CASE
+        WHEN $2::text = ANY(i.tx_hashes) THEN i.tx_hashes
+        ELSE array_append(i.tx_hashes, $2::text)
+    END,
+    signed_tx = $3::bytea,
+    updated_at = CURRENT_TIMESTAMP
+WHERE i.policy_id = $1
+  AND EXISTS (
+      SELECT 1 FROM listing_fees lf
+      WHERE lf.policy_id = i.policy_id AND lf.status = 'pending'
+      FOR SHARE
+  )
+`
+
+type RecordListingFeeIntentSignedTxParams struct {
+	PolicyID uuid.UUID
+	TxHash   string
+	SignedTx []byte
+}
+
+// Only a pending fee takes new hashes. The share lock orders this against a
+// concurrent cancellation, so a cancelled fee is never broadcast.
+func (q *Queries) RecordListingFeeIntentSignedTx(ctx context.Context, arg RecordListingFeeIntentSignedTxParams) (int64, error) {
+	result, err := q.db.Exec(ctx, recordListingFeeIntentSignedTx, arg.PolicyID, arg.TxHash, arg.SignedTx)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const replaceListingFeeIntentUnsignedTx = `-- name: ReplaceListingFeeIntentUnsignedTx :execrows
+UPDATE listing_fee_intents i
+SET unsigned_tx = $2::bytea,
+    signed_tx = NULL,
+    broadcast_attempts = 0,
+    updated_at = CURRENT_TIMESTAMP
+WHERE i.policy_id = $1
+  AND i.signed_tx = $3::bytea
+  AND EXISTS (
+      SELECT 1 FROM listing_fees lf
+      WHERE lf.policy_id = i.policy_id AND lf.status = 'pending'
+      FOR SHARE
+  )
+`
+
+type ReplaceListingFeeIntentUnsignedTxParams struct {
+	PolicyID         uuid.UUID
+	UnsignedTx       []byte
+	ReplacedSignedTx []byte
+}
+
+// Swaps in a repriced transaction for the same nonce. The earlier hashes are
+// kept, since any of them may still be mined.
+func (q *Queries) ReplaceListingFeeIntentUnsignedTx(ctx context.Context, arg ReplaceListingFeeIntentUnsignedTxParams) (int64, error) {
+	result, err := q.db.Exec(ctx, replaceListingFeeIntentUnsignedTx, arg.PolicyID, arg.UnsignedTx, arg.ReplacedSignedTx)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const resetListingFeeIntentBroadcastAttempts = `-- name: ResetListingFeeIntentBroadcastAttempts :exec
+UPDATE listing_fee_intents
+SET broadcast_attempts = 0,
+    updated_at = CURRENT_TIMESTAMP
+WHERE policy_id = $1
+`
+
+func (q *Queries) ResetListingFeeIntentBroadcastAttempts(ctx context.Context, policyID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, resetListingFeeIntentBroadcastAttempts, policyID)
+	return err
+}
+
+const setListingFeeIntentBroadcastEndpoint = `-- name: SetListingFeeIntentBroadcastEndpoint :exec
+UPDATE listing_fee_intents
+SET broadcast_endpoint = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE policy_id = $1
+`
+
+type SetListingFeeIntentBroadcastEndpointParams struct {
+	PolicyID          uuid.UUID
+	BroadcastEndpoint *string
+}
+
+func (q *Queries) SetListingFeeIntentBroadcastEndpoint(ctx context.Context, arg SetListingFeeIntentBroadcastEndpointParams) error {
+	_, err := q.db.Exec(ctx, setListingFeeIntentBroadcastEndpoint, arg.PolicyID, arg.BroadcastEndpoint)
+	return err
+}
@@ -11,9 +11,89 @@ import (
 	"github.com/google/uuid"
 )
 
-const createListingFee = `-- name: CreateListingFee :exec
-INSERT INTO listing_fees (policy_id, public_key, target_plugin_id, amount, destination, status)
-VALUES ($1, $2, $3, $4, $5, $6)
+const backfillListingFeeExpiry = `-- name: BackfillListingFeeExpiry :execrows
+UPDATE listing_fees lf
+SET expires_at = COALESCE((SELECT pp.created_at::timestamp FROM plugin_policies pp WHERE pp.id = lf.policy_id), lf.created_at)
+        + make_interval(secs => $1::float8),
+    updated_at = CURRENT_TIMESTAMP
+WHERE lf.status = 'pending' AND lf.expires_at IS NULL
+`
+
+// Gives pending fees created without a payment window the one CreateListingFee
+// would have set.
+func (q *Queries) BackfillListingFeeExpiry(ctx context.Context, ttlSeconds float64) (int64, error) {
+	result, err := q.db.Exec(ctx, backfillListingFeeExpiry, ttlSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const claimListingFee = `-- name: ClaimListingFee :one
+WITH leased AS (
+    INSERT INTO listing_fee_leases (policy_id, owner, expires_at)
+    SELECT lf.policy_id, $1::text, CURRENT_TIMESTAMP + make_interval(secs => $2::float8)
+    FROM listing_fees lf
+    WHERE lf.policy_id = $3 AND lf.status = 'pending' AND lf.batch_policy_id IS NULL
+    FOR UPDATE OF lf SKIP LOCKED
+    ON CONFLICT (policy_id) DO UPDATE
+    SET owner = EXCLUDED.owner, expires_at = EXCLUDED.expires_at
+    WHERE listing_fee_leases.expires_at < CURRENT_TIMESTAMP
+    RETURNING policy_id
+)
+SELECT lf.id, lf.policy_id, lf.public_key, lf.target_plugin_id, lf.amount, lf.destination,
+       lf.tx_hash, lf.block_number, lf.confirmations, lf.status,
+       lf.submitted_at, lf.paid_at, lf.failure_reason,
+       lf.created_at, lf.updated_at, lf.expires_at, lf.failure_details, lf.batch_policy_id
+FROM listing_fees lf
+JOIN leased ON leased.policy_id = lf.policy_id
+`
+
+type ClaimListingFeeParams struct {
+	LeaseOwner   string
+	LeaseSeconds float64
+	PolicyID     uuid.UUID
+}
+
+// Leases a pending fee to one worker. A fee in a batch is paid by its lead and
+// is never claimed itself. SKIP LOCKED keeps a claim from blocking
+// on a fee another transaction is changing, and the conflict guard refuses to
+// steal a lease that is still live; either way no row is returned.
+func (q *Queries) ClaimListingFee(ctx context.Context, arg ClaimListingFeeParams) (ListingFee, error) {
+	row := q.db.QueryRow(ctx, claimListingFee, arg.LeaseOwner, arg.LeaseSeconds, arg.PolicyID)
+	var i ListingFee
+	err := row.Scan(
+		&i.ID,
+		&i.PolicyID,
+		&i.PublicKey,
+		&i.TargetPluginID,
+		&i.Amount,
+		&i.Destination,
+		&i.TxHash,
+		&i.BlockNumber,
+		&i.Confirmations,
+		&i.Status,
+		&i.SubmittedAt,
+		&i.PaidAt,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExpiresAt,
+		&i.FailureDetails,
+		&i.BatchPolicyID,
+	)
+	return i, err
+}
+
+const createListingFee = `-- name: CreateListingFee :execrows
+INSERT INTO listing_fees (policy_id, public_key, target_plugin_id, amount, destination, status, expires_at)
+VALUES (
+    $1, $2, $3, $4, $5, $6,
+    CASE WHEN $7::float8 > 0 THEN
+        COALESCE((SELECT pp.created_at::timestamp FROM plugin_policies pp WHERE pp.id = $1), LOCALTIMESTAMP)
+            + make_interval(secs => $7::float8)
+    END
+)
 ON CONFLICT (policy_id) DO NOTHING
 `
 
@@ -24,18 +104,25 @@ type CreateListingFeeParams struct {
 	Amount         string
 	Destination    string
 	Status         string
+	TtlSeconds     float64
 }
 
-func (q *Queries) CreateListingFee(ctx context.Context, arg CreateListingFeeParams) error {
-	_, err := q.db.Exec(ctx, createListingFee,
+// The fee's amount was quoted when the policy was created, so its payment
+// window runs from then. A ttl_seconds of zero means the fee never expires.
+func (q *Queries) CreateListingFee(ctx context.Context, arg CreateListingFeeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createListingFee,
 		arg.PolicyID,
 		arg.PublicKey,
 		arg.TargetPluginID,
 		arg.Amount,
 		arg.Destination,
 		arg.Status,
+		arg.TtlSeconds,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const deactivatePolicy = `-- name: DeactivatePolicy :exec
@@ -54,11 +141,149 @@ func (q *Queries) DeactivatePolicy(ctx context.Context, arg DeactivatePolicyPara
 	return err
 }
 
+const expirePendingListingFee = `-- name: ExpirePendingListingFee :execrows
+UPDATE listing_fees lf
+SET status = 'expired', batch_policy_id = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE lf.policy_id = $1 AND lf.status = 'pending'
+  AND lf.expires_at <= CURRENT_TIMESTAMP
+  AND NOT EXISTS (
+      SELECT 1 FROM listing_fee_intents i
+      WHERE i.policy_id = lf.policy_id AND cardinality(i.tx_hashes) > 0
+  )
+  AND NOT EXISTS (SELECT 1 FROM listing_fee_intents i WHERE i.policy_id = lf.batch_policy_id)
+  AND NOT EXISTS (
+      SELECT 1 FROM listing_fee_leases l
+      WHERE l.policy_id = lf.batch_policy_id AND l.expires_at >= CURRENT_TIMESTAMP
+  )
+`
+
+// Like cancellation, expiry leaves fees with a broadcast payment, or covered by
+// a batch lead's transfer in the making, to reconciliation.
+func (q *Queries) ExpirePendingListingFee(ctx context.Context, policyID uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, expirePendingListingFee, policyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const followListingFeeBatchLead = `-- name: FollowListingFeeBatchLead :execrows
+WITH updated AS (
+    UPDATE listing_fees m
+    SET status = lead.status, tx_hash = lead.tx_hash, block_number = lead.block_number,
+        confirmations = lead.confirmations, submitted_at = lead.submitted_at,
+        paid_at = lead.paid_at, updated_at = CURRENT_TIMESTAMP
+    FROM listing_fees lead, listing_fees old
+    WHERE lead.policy_id = $2::uuid
+      AND m.batch_policy_id = lead.policy_id
+      AND old.id = m.id
+      AND lead.status IN ('pending', 'submitted', 'paid')
+      AND m.status IN ('pending', 'submitted')
+      AND m.status <> lead.status
+    RETURNING m.policy_id, old.status AS old_status, m.status AS new_status, m.tx_hash, lead.policy_id AS lead_policy_id
+)
+INSERT INTO listing_fee_events (policy_id, old_status, new_status, actor, tx_hash, reason)
+SELECT policy_id, old_status, new_status, $1::text, tx_hash, 'following batch lead ' || lead_policy_id::text
+FROM updated
+`
+
+type FollowListingFeeBatchLeadParams struct {
+	Actor        string
+	LeadPolicyID uuid.UUID
+}
+
+// Moves the fees in a batch to the status and transaction of their lead while
+// it is pending, submitted or paid.
+func (q *Queries) FollowListingFeeBatchLead(ctx context.Context, arg FollowListingFeeBatchLeadParams) (int64, error) {
+	result, err := q.db.Exec(ctx, followListingFeeBatchLead, arg.Actor, arg.LeadPolicyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getExpiredPendingPolicyIDs = `-- name: GetExpiredPendingPolicyIDs :many
+SELECT policy_id
+FROM listing_fees
+WHERE status = 'pending'
+  AND expires_at <= CURRENT_TIMESTAMP
+`
+
+func (q *Queries) GetExpiredPendingPolicyIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, getExpiredPendingPolicyIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var policy_id uuid.UUID
+		if err := rows.Scan(&policy_id); err != nil {
+			return nil, err
+		}
+		items = append(items, policy_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getListingFeeBatch = `-- name: GetListingFeeBatch :many
+SELECT id, policy_id, public_key, target_plugin_id, amount, destination,
+       tx_hash, block_number, confirmations, status,
+       submitted_at, paid_at, failure_reason,
+       created_at, updated_at, expires_at, failure_details, batch_policy_id
+FROM listing_fees
+WHERE batch_policy_id = $1
+ORDER BY created_at
+`
+
+// The fees a batch lead's transfer pays for besides its own.
+func (q *Queries) GetListingFeeBatch(ctx context.Context, batchPolicyID *uuid.UUID) ([]ListingFee, error) {
+	rows, err := q.db.Query(ctx, getListingFeeBatch, batchPolicyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListingFee
+	for rows.Next() {
+		var i ListingFee
+		if err := rows.Scan(
+			&i.ID,
+			&i.PolicyID,
+			&i.PublicKey,
+			&i.TargetPluginID,
+			&i.Amount,
+			&i.Destination,
+			&i.TxHash,
+			&i.BlockNumber,
+			&i.Confirmations,
+			&i.Status,
+			&i.SubmittedAt,
+			&i.PaidAt,
+			&i.FailureReason,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ExpiresAt,
+			&i.FailureDetails,
+			&i.BatchPolicyID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getListingFeeByPolicyID = `-- name: GetListingFeeByPolicyID :one
 SELECT id, policy_id, public_key, target_plugin_id, amount, destination,
        tx_hash, block_number, confirmations, status,
        submitted_at, paid_at, failure_reason,
-       created_at, updated_at
+       created_at, updated_at, expires_at, failure_details, batch_policy_id
 FROM listing_fees
 WHERE policy_id = $1
 `
@@ -82,6 +307,9 @@ func (q *Queries) GetListingFeeByPolicyID(ctx context.Context, policyID uuid.UUI
 		&i.FailureReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ExpiresAt,
+		&i.FailureDetails,
+		&i.BatchPolicyID,
 	)
 	return i, err
 }
@@ -90,7 +318,7 @@ const getListingFeeByScope = `-- name: GetListingFeeByScope :one
 SELECT id, policy_id, public_key, target_plugin_id, amount, destination,
        tx_hash, block_number, confirmations, status,
        submitted_at, paid_at, failure_reason,
-       created_at, updated_at
+       created_at, updated_at, expires_at, failure_details, batch_policy_id
 FROM listing_fees
 WHERE public_key = $1 AND target_plugin_id = $2
 ORDER BY created_at DESC
@@ -121,10 +349,42 @@ func (q *Queries) GetListingFeeByScope(ctx context.Context, arg GetListingFeeByS
 		&i.FailureReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ExpiresAt,
+		&i.FailureDetails,
+		&i.BatchPolicyID,
 	)
 	return i, err
 }
 
+const getOrphanedPendingPolicyIDs = `-- name: GetOrphanedPendingPolicyIDs :many
+SELECT lf.policy_id
+FROM listing_fees lf
+LEFT JOIN plugin_policies pp ON pp.id = lf.policy_id
+WHERE lf.status = 'pending'
+  AND (pp.id IS NULL OR pp.active = false OR pp.deleted = true)
+`
+
+// Pending fees whose policy was deactivated or deleted in the verifier.
+func (q *Queries) GetOrphanedPendingPolicyIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, getOrphanedPendingPolicyIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var policy_id uuid.UUID
+		if err := rows.Scan(&policy_id); err != nil {
+			return nil, err
+		}
+		items = append(items, policy_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPaidActivePolicyIDs = `-- name: GetPaidActivePolicyIDs :many
 SELECT lf.policy_id
 FROM listing_fees lf
@@ -157,7 +417,7 @@ const getPendingListingFeeByScope = `-- name: GetPendingListingFeeByScope :one
 SELECT id, policy_id, public_key, target_plugin_id, amount, destination,
        tx_hash, block_number, confirmations, status,
        submitted_at, paid_at, failure_reason,
-       created_at, updated_at
+       created_at, updated_at, expires_at, failure_details, batch_policy_id
 FROM listing_fees
 WHERE public_key = $1 AND target_plugin_id = $2 AND status = 'pending'
 LIMIT 1
@@ -187,15 +447,37 @@ func (q *Queries) GetPendingListingFeeByScope(ctx context.Context, arg GetPendin
 		&i.FailureReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ExpiresAt,
+		&i.FailureDetails,
+		&i.BatchPolicyID,
 	)
 	return i, err
 }
 
+const getPendingListingFeeStats = `-- name: GetPendingListingFeeStats :one
+SELECT COUNT(*)::bigint AS pending_count,
+       COALESCE(EXTRACT(EPOCH FROM LOCALTIMESTAMP - MIN(created_at)), 0)::float8 AS oldest_age_seconds
+FROM listing_fees
+WHERE status = 'pending'
+`
+
+type GetPendingListingFeeStatsRow struct {
+	PendingCount     int64
+	OldestAgeSeconds float64
+}
+
+func (q *Queries) GetPendingListingFeeStats(ctx context.Context) (GetPendingListingFeeStatsRow, error) {
+	row := q.db.QueryRow(ctx, getPendingListingFeeStats)
+	var i GetPendingListingFeeStatsRow
+	err := row.Scan(&i.PendingCount, &i.OldestAgeSeconds)
+	return i, err
+}
+
 const getPendingListingFees = `-- name: GetPendingListingFees :many
 SELECT id, policy_id, public_key, target_plugin_id, amount, destination,
        tx_hash, block_number, confirmations, status,
        submitted_at, paid_at, failure_reason,
-       created_at, updated_at
+       created_at, updated_at, expires_at, failure_details, batch_policy_id
 FROM listing_fees
 WHERE status = 'pending'
 `
@@ -225,6 +507,9 @@ func (q *Queries) GetPendingListingFees(ctx context.Context) ([]ListingFee, erro
 			&i.FailureReason,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ExpiresAt,
+			&i.FailureDetails,
+			&i.BatchPolicyID,
 		); err != nil {
 			return nil, err
 		}
@@ -236,15 +521,43 @@ func (q *Queries) GetPendingListingFees(ctx context.Context) ([]ListingFee, erro
 	return items, nil
 }
 
+const getPendingPolicyIDsByPublicKey = `-- name: GetPendingPolicyIDsByPublicKey :many
+SELECT policy_id
+FROM listing_fees
+WHERE public_key = $1
+  AND status = 'pending'
+`
+
+func (q *Queries) GetPendingPolicyIDsByPublicKey(ctx context.Context, publicKey string) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, getPendingPolicyIDsByPublicKey, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var policy_id uuid.UUID
+		if err := rows.Scan(&policy_id); err != nil {
+			return nil, err
+		}
+		items = append(items, policy_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getSubmittedListingFees = `-- name: GetSubmittedListingFees :many
 SELECT id, policy_id, public_key, target_plugin_id, amount, destination,
        tx_hash, block_number, confirmations, status,
        submitted_at, paid_at, failure_reason,
-       created_at, updated_at
+       created_at, updated_at, expires_at, failure_details, batch_policy_id
 FROM listing_fees
-WHERE status = 'submitted'
+WHERE status = 'submitted' AND batch_policy_id IS NULL
 `
 
+// Fees in a batch follow their lead, so only the lead is synced.
 func (q *Queries) GetSubmittedListingFees(ctx context.Context) ([]ListingFee, error) {
 	rows, err := q.db.Query(ctx, getSubmittedListingFees)
 	if err != nil {
@@ -270,6 +583,9 @@ func (q *Queries) GetSubmittedListingFees(ctx context.Context) ([]ListingFee, er
 			&i.FailureReason,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ExpiresAt,
+			&i.FailureDetails,
+			&i.BatchPolicyID,
 		); err != nil {
 			return nil, err
 		}
@@ -286,7 +602,9 @@ SELECT pp.id
 FROM plugin_policies pp
 LEFT JOIN listing_fees lf ON lf.policy_id = pp.id
 WHERE pp.active = true
+  AND pp.deleted = false
   AND lf.id IS NULL
+  AND NOT EXISTS (SELECT 1 FROM policy_ingestion_errors e WHERE e.policy_id = pp.id)
 `
 
 func (q *Queries) GetUnprocessedPolicyIDs(ctx context.Context) ([]uuid.UUID, error) {
@@ -330,6 +648,17 @@ func (q *Queries) HasActiveListingFee(ctx context.Context, arg HasActiveListingF
 	return exists, err
 }
 
+const hasListingFee = `-- name: HasListingFee :one
+SELECT EXISTS(SELECT 1 FROM listing_fees WHERE policy_id = $1)
+`
+
+func (q *Queries) HasListingFee(ctx context.Context, policyID uuid.UUID) (bool, error) {
+	row := q.db.QueryRow(ctx, hasListingFee, policyID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const isListingFeePaidForPlugin = `-- name: IsListingFeePaidForPlugin :one
 SELECT EXISTS(
     SELECT 1 FROM listing_fees
@@ -345,23 +674,103 @@ func (q *Queries) IsListingFeePaidForPlugin(ctx context.Context, targetPluginID
 	return exists, err
 }
 
-const markAsFailed = `-- name: MarkAsFailed :exec
-UPDATE listing_fees
-SET status = 'failed', failure_reason = $2, updated_at = CURRENT_TIMESTAMP
-WHERE policy_id = $1 AND status IN ('pending', 'submitted')
+const isListingFeePaidForVaultPlugin = `-- name: IsListingFeePaidForVaultPlugin :one
+SELECT EXISTS(
+    SELECT 1 FROM listing_fees
+    WHERE public_key = $1
+      AND target_plugin_id = $2
+      AND status = 'paid'
+)
+`
+
+type IsListingFeePaidForVaultPluginParams struct {
+	PublicKey      string
+	TargetPluginID string
+}
+
+func (q *Queries) IsListingFeePaidForVaultPlugin(ctx context.Context, arg IsListingFeePaidForVaultPluginParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isListingFeePaidForVaultPlugin, arg.PublicKey, arg.TargetPluginID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const joinListingFeeBatch = `-- name: JoinListingFeeBatch :many
+UPDATE listing_fees lf
+SET batch_policy_id = $1::uuid, updated_at = CURRENT_TIMESTAMP
+WHERE lf.policy_id IN (
+    SELECT c.policy_id FROM listing_fees c
+    WHERE c.public_key = $2 AND c.status = 'pending'
+      AND c.policy_id <> $1::uuid AND c.batch_policy_id IS NULL
+      AND NOT EXISTS (SELECT 1 FROM listing_fee_intents i WHERE i.policy_id = c.policy_id)
+      AND NOT EXISTS (
+          SELECT 1 FROM listing_fee_leases l
+          WHERE l.policy_id = c.policy_id AND l.expires_at >= CURRENT_TIMESTAMP
+      )
+      AND NOT EXISTS (SELECT 1 FROM listing_fees m WHERE m.batch_policy_id = c.policy_id)
+    ORDER BY c.created_at
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING lf.policy_id
+`
+
+type JoinListingFeeBatchParams struct {
+	LeadPolicyID uuid.UUID
+	PublicKey    string
+	MaxFees      int32
+}
+
+// Adds up to @max_fees more of the vault's pending fees to the batch led by
+// @lead_policy_id. Only fees nothing else pays for qualify: no intent or live
+// lease of their own, not in a batch and not leading one.
+func (q *Queries) JoinListingFeeBatch(ctx context.Context, arg JoinListingFeeBatchParams) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, joinListingFeeBatch, arg.LeadPolicyID, arg.PublicKey, arg.MaxFees)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var policy_id uuid.UUID
+		if err := rows.Scan(&policy_id); err != nil {
+			return nil, err
+		}
+		items = append(items, policy_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markAsFailed = `-- name: MarkAsFailed :execrows
+UPDATE listing_fees lf
+SET status = 'failed', failure_reason = $2, failure_details = $3, updated_at = CURRENT_TIMESTAMP
+WHERE lf.policy_id = $1 AND lf.status IN ('pending', 'submitted')
+  AND NOT EXISTS (
+      SELECT 1 FROM listing_fee_intents i
+      WHERE i.policy_id = lf.policy_id AND cardinality(i.tx_hashes) > 0
+  )
 `
 
 type MarkAsFailedParams struct {
-	PolicyID      uuid.UUID
-	FailureReason *string
+	PolicyID       uuid.UUID
+	FailureReason  *string
+	FailureDetails []byte
 }
 
-func (q *Queries) MarkAsFailed(ctx context.Context, arg MarkAsFailedParams) error {
-	_, err := q.db.Exec(ctx, markAsFailed, arg.PolicyID, arg.FailureReason)
-	return err
+// A fee whose intent has a signed hash on record may still be mined, so it is
+// left to reconciliation, which discards the intent once its nonce is spent.
+func (q *Queries) MarkAsFailed(ctx context.Context, arg MarkAsFailedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markAsFailed, arg.PolicyID, arg.FailureReason, arg.FailureDetails)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const markAsPaid = `-- name: MarkAsPaid :exec
+const markAsPaid = `-- name: MarkAsPaid :execrows
 UPDATE listing_fees
 SET status = 'paid', block_number = $2, confirmations = $3, paid_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 WHERE policy_id = $1 AND status = 'submitted'
@@ -373,12 +782,15 @@ type MarkAsPaidParams struct {
 	Confirmations int32
 }
 
-func (q *Queries) MarkAsPaid(ctx context.Context, arg MarkAsPaidParams) error {
-	_, err := q.db.Exec(ctx, markAsPaid, arg.PolicyID, arg.BlockNumber, arg.Confirmations)
-	return err
+func (q *Queries) MarkAsPaid(ctx context.Context, arg MarkAsPaidParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markAsPaid, arg.PolicyID, arg.BlockNumber, arg.Confirmations)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const markAsSubmitted = `-- name: MarkAsSubmitted :exec
+const markAsSubmitted = `-- name: MarkAsSubmitted :execrows
 UPDATE listing_fees
 SET status = 'submitted', tx_hash = $2, submitted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
 WHERE policy_id = $1 AND status = 'pending'
@@ -389,51 +801,176 @@ type MarkAsSubmittedParams struct {
 	TxHash   *string
 }
 
-func (q *Queries) MarkAsSubmitted(ctx context.Context, arg MarkAsSubmittedParams) error {
-	_, err := q.db.Exec(ctx, markAsSubmitted, arg.PolicyID, arg.TxHash)
+func (q *Queries) MarkAsSubmitted(ctx context.Context, arg MarkAsSubmittedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markAsSubmitted, arg.PolicyID, arg.TxHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const releaseListingFeeBatch = `-- name: ReleaseListingFeeBatch :execrows
+WITH updated AS (
+    UPDATE listing_fees m
+    SET status = 'pending', batch_policy_id = NULL, tx_hash = NULL, submitted_at = NULL,
+        updated_at = CURRENT_TIMESTAMP
+    FROM listing_fees lead, listing_fees old
+    WHERE lead.policy_id = $2::uuid
+      AND m.batch_policy_id = lead.policy_id
+      AND old.id = m.id
+      AND lead.status IN ('failed', 'cancelled', 'expired')
+      AND m.status IN ('pending', 'submitted')
+    RETURNING m.policy_id, old.status AS old_status, lead.policy_id AS lead_policy_id, lead.status AS lead_status
+)
+INSERT INTO listing_fee_events (policy_id, old_status, new_status, actor, reason)
+SELECT policy_id, old_status, 'pending', $1::text, 'batch lead ' || lead_policy_id::text || ' ' || lead_status
+FROM updated
+WHERE old_status <> 'pending'
+`
+
+type ReleaseListingFeeBatchParams struct {
+	Actor        string
+	LeadPolicyID uuid.UUID
+}
+
+// Returns the fees in a batch to pending on their own once their lead failed,
+// was cancelled or expired, since its transfer paid for none of them.
+func (q *Queries) ReleaseListingFeeBatch(ctx context.Context, arg ReleaseListingFeeBatchParams) (int64, error) {
+	result, err := q.db.Exec(ctx, releaseListingFeeBatch, arg.Actor, arg.LeadPolicyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const releaseListingFeeLease = `-- name: ReleaseListingFeeLease :exec
+DELETE FROM listing_fee_leases
+WHERE policy_id = $1 AND owner = $2
+`
+
+type ReleaseListingFeeLeaseParams struct {
+	PolicyID uuid.UUID
+	Owner    string
+}
+
+func (q *Queries) ReleaseListingFeeLease(ctx context.Context, arg ReleaseListingFeeLeaseParams) error {
+	_, err := q.db.Exec(ctx, releaseListingFeeLease, arg.PolicyID, arg.Owner)
 	return err
 }
 
-const syncFailedFees = `-- name: SyncFailedFees :execrows
-UPDATE listing_fees lf
-SET status = 'failed',
-    failure_reason = CASE WHEN ti.lost THEN 'transaction lost' ELSE 'transaction failed on-chain' END,
-    updated_at = CURRENT_TIMESTAMP
-FROM tx_indexer ti
-WHERE ti.policy_id = lf.policy_id
-  AND lf.status = 'submitted'
-  AND (ti.status_onchain = 'FAIL' OR ti.lost = true)
+const renewListingFeeLease = `-- name: RenewListingFeeLease :execrows
+UPDATE listing_fee_leases
+SET expires_at = CURRENT_TIMESTAMP + make_interval(secs => $1::float8)
+WHERE policy_id = $2 AND owner = $3::text
 `
 
-func (q *Queries) SyncFailedFees(ctx context.Context) (int64, error) {
-	result, err := q.db.Exec(ctx, syncFailedFees)
+type RenewListingFeeLeaseParams struct {
+	LeaseSeconds float64
+	PolicyID     uuid.UUID
+	LeaseOwner   string
+}
+
+func (q *Queries) RenewListingFeeLease(ctx context.Context, arg RenewListingFeeLeaseParams) (int64, error) {
+	result, err := q.db.Exec(ctx, renewListingFeeLease, arg.LeaseSeconds, arg.PolicyID, arg.LeaseOwner)
 	if err != nil {
 		return 0, err
 	}
 	return result.RowsAffected(), nil
 }
 
-const syncPaidFees = `-- name: SyncPaidFees :execrows
-UPDATE listing_fees lf
-SET status = 'paid', paid_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
-FROM tx_indexer ti
-WHERE ti.policy_id = lf.policy_id
-  AND lf.status = 'submitted'
-  AND ti.status_onchain = 'SUCCESS'
+const requeueLostFee = `-- name: RequeueLostFee :execrows
+WITH updated AS (
+    UPDATE listing_fees lf
+    SET status = 'pending', updated_at = CURRENT_TIMESTAMP
+    FROM tx_indexer ti
+    WHERE lf.policy_id = $1
+      AND ti.policy_id = lf.policy_id
+      AND ti.tx_hash = lf.tx_hash
+      AND lf.status = 'submitted'
+      AND ti.lost = true
+      AND EXISTS (SELECT 1 FROM listing_fee_intents i WHERE i.policy_id = lf.policy_id)
+    RETURNING lf.policy_id, lf.tx_hash
+)
+INSERT INTO listing_fee_events (policy_id, old_status, new_status, actor, tx_hash, reason)
+SELECT policy_id, 'submitted', 'pending', 'sync', tx_hash, 'transaction lost, reconciling'
+FROM updated
+`
+
+// A transaction tx_indexer lost track of may still be mined, so its fee goes
+// back to pending, where the intent is reconciled by hash and nonce.
+func (q *Queries) RequeueLostFee(ctx context.Context, policyID uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, requeueLostFee, policyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const syncFailedFee = `-- name: SyncFailedFee :execrows
+WITH updated AS (
+    UPDATE listing_fees lf
+    SET status = 'failed', failure_reason = 'transaction failed on-chain', updated_at = CURRENT_TIMESTAMP
+    FROM tx_indexer ti
+    WHERE lf.policy_id = $1
+      AND ti.policy_id = lf.policy_id
+      AND ti.tx_hash = lf.tx_hash
+      AND lf.status = 'submitted'
+      AND ti.status_onchain = 'FAIL'
+    RETURNING lf.policy_id, lf.tx_hash, lf.failure_reason
+)
+INSERT INTO listing_fee_events (policy_id, old_status, new_status, actor, tx_hash, reason)
+SELECT policy_id, 'submitted', 'failed', 'sync', tx_hash, failure_reason
+FROM updated
+`
+
+func (q *Queries) SyncFailedFee(ctx context.Context, policyID uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, syncFailedFee, policyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const syncPaidFee = `-- name: SyncPaidFee :execrows
+WITH updated AS (
+    UPDATE listing_fees lf
+    SET status = 'paid', paid_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+    FROM tx_indexer ti
+    WHERE lf.policy_id = $1
+      AND ti.policy_id = lf.policy_id
+      AND ti.tx_hash = lf.tx_hash
+      AND lf.status = 'submitted'
+      AND ti.status_onchain = 'SUCCESS'
+    RETURNING lf.policy_id, lf.tx_hash
+)
+INSERT INTO listing_fee_events (policy_id, old_status, new_status, actor, tx_hash)
+SELECT policy_id, 'submitted', 'paid', 'sync', tx_hash
+FROM updated
 `
 
-func (q *Queries) SyncPaidFees(ctx context.Context) (int64, error) {
-	result, err := q.db.Exec(ctx, syncPaidFees)
+func (q *Queries) SyncPaidFee(ctx context.Context, policyID uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, syncPaidFee, policyID)
 	if err != nil {
 		return 0, err
 	}
 	return result.RowsAffected(), nil
 }
 
+const tryAdvisoryLock = `-- name: TryAdvisoryLock :one
+SELECT pg_try_advisory_lock(hashtext($1::text))::boolean AS acquired
+`
+
+func (q *Queries) TryAdvisoryLock(ctx context.Context, name string) (bool, error) {
+	row := q.db.QueryRow(ctx, tryAdvisoryLock, name)
+	var acquired bool
+	err := row.Scan(&acquired)
+	return acquired, err
+}
+
 const updateConfirmations = `-- name: UpdateConfirmations :exec
 UPDATE listing_fees
 SET confirmations = $2, updated_at = CURRENT_TIMESTAMP
-WHERE policy_id = $1
+WHERE policy_id = $1 OR batch_policy_id = $1
 `
 
 type UpdateConfirmationsParams struct {
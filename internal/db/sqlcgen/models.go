@@ -8,8 +8,53 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AdminAuditLog struct {
+	ID        uuid.UUID
+	Operator  string
+	Action    string
+	PolicyID  uuid.UUID
+	Reason    string
+	Details   []byte
+	CreatedAt time.Time
+}
+
+type DerivedAddress struct {
+	PublicKey        string
+	EncryptedAddress []byte
+	CreatedAt        time.Time
+}
+
+type DeveloperAuthChallenge struct {
+	Nonce     string
+	PublicKey string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type EvmNonce struct {
+	Address   string
+	NextNonce int64
+	UpdatedAt time.Time
+}
+
+type EvmNonceReservation struct {
+	PolicyID  uuid.UUID
+	Address   string
+	Nonce     int64
+	CreatedAt time.Time
+}
+
+type KeysignSession struct {
+	SessionID     string
+	PolicyID      uuid.UUID
+	TxIndexerID   uuid.UUID
+	CorrelationID string
+	CreatedAt     time.Time
+}
+
 type ListingFee struct {
 	ID             uuid.UUID
 	PolicyID       uuid.UUID
@@ -26,17 +71,79 @@ type ListingFee struct {
 	FailureReason  *string
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
+	ExpiresAt      *time.Time
+	FailureDetails []byte
+	BatchPolicyID  *uuid.UUID
+}
+
+type ListingFeeEvaluation struct {
+	ID        int64
+	PolicyID  uuid.UUID
+	Allowed   bool
+	Report    []byte
+	CreatedAt time.Time
+}
+
+type ListingFeeEvent struct {
+	ID        int64
+	PolicyID  uuid.UUID
+	OldStatus *string
+	NewStatus string
+	Actor     string
+	TxHash    *string
+	Reason    *string
+	CreatedAt time.Time
+}
+
+type ListingFeeIntent struct {
+	PolicyID          uuid.UUID
+	FromAddress       string
+	Nonce             int64
+	UnsignedTx        []byte
+	TxHashes          []string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	SignedTx          []byte
+	BroadcastAttempts int32
+	BroadcastEndpoint *string
+}
+
+type ListingFeeLease struct {
+	PolicyID  uuid.UUID
+	Owner     string
+	ExpiresAt time.Time
 }
 
 type PluginPolicy struct {
 	ID                 uuid.UUID
+	PublicKey          string
 	Active             bool
 	DeactivationReason *string
+	Deleted            bool
+	CreatedAt          pgtype.Timestamptz
+}
+
+type PolicyIngestionError struct {
+	PolicyID  uuid.UUID
+	Error     string
+	CreatedAt time.Time
 }
 
 type TxIndexer struct {
 	ID            uuid.UUID
 	PolicyID      uuid.UUID
+	TxHash        *string
+	Status        string
 	StatusOnchain string
 	Lost          bool
+	BroadcastedAt *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type VaultReshare struct {
+	PublicKey string
+	PluginID  string
+	SessionID string
+	StartedAt time.Time
 }
@@ -0,0 +1,50 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: policy_ingestion.sql
+
+package sqlcgen
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const getPolicyIngestionError = `-- name: GetPolicyIngestionError :one
+SELECT policy_id, error, created_at
+FROM policy_ingestion_errors
+WHERE policy_id = $1
+`
+
+func (q *Queries) GetPolicyIngestionError(ctx context.Context, policyID uuid.UUID) (PolicyIngestionError, error) {
+	row := q.db.QueryRow(ctx, getPolicyIngestionError, policyID)
+	var i PolicyIngestionError
+	err := row.Scan(&i.PolicyID, &i.Error, &i.CreatedAt)
+	return i, err
+}
+
+const listenPolicyCreated = `-- name: ListenPolicyCreated :exec
+LISTEN developer_policy_created
+`
+
+func (q *Queries) ListenPolicyCreated(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, listenPolicyCreated)
+	return err
+}
+
+const recordPolicyIngestionError = `-- name: RecordPolicyIngestionError :exec
+INSERT INTO policy_ingestion_errors (policy_id, error)
+VALUES ($1, $2)
+ON CONFLICT (policy_id) DO UPDATE SET error = EXCLUDED.error
+`
+
+type RecordPolicyIngestionErrorParams struct {
+	PolicyID uuid.UUID
+	Error    string
+}
+
+func (q *Queries) RecordPolicyIngestionError(ctx context.Context, arg RecordPolicyIngestionErrorParams) error {
+	_, err := q.db.Exec(ctx, recordPolicyIngestionError, arg.PolicyID, arg.Error)
+	return err
+}
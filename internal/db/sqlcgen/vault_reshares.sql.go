@@ -0,0 +1,65 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: vault_reshares.sql
+
+package sqlcgen
+
+import (
+	"context"
+)
+
+const finishVaultReshare = `-- name: FinishVaultReshare :exec
+DELETE FROM vault_reshares
+WHERE public_key = $1 AND session_id = $2
+`
+
+type FinishVaultReshareParams struct {
+	PublicKey string
+	SessionID string
+}
+
+func (q *Queries) FinishVaultReshare(ctx context.Context, arg FinishVaultReshareParams) error {
+	_, err := q.db.Exec(ctx, finishVaultReshare, arg.PublicKey, arg.SessionID)
+	return err
+}
+
+const isVaultResharing = `-- name: IsVaultResharing :one
+SELECT EXISTS(
+    SELECT 1 FROM vault_reshares
+    WHERE public_key = $1
+      AND started_at > CURRENT_TIMESTAMP - make_interval(secs => $2::float8)
+)
+`
+
+type IsVaultResharingParams struct {
+	PublicKey      string
+	TimeoutSeconds float64
+}
+
+// A reshare that never finished, e.g. because its worker died, stops pausing
+// fees once it is older than @timeout_seconds.
+func (q *Queries) IsVaultResharing(ctx context.Context, arg IsVaultResharingParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isVaultResharing, arg.PublicKey, arg.TimeoutSeconds)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const startVaultReshare = `-- name: StartVaultReshare :exec
+INSERT INTO vault_reshares (public_key, plugin_id, session_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (public_key) DO UPDATE
+SET plugin_id = EXCLUDED.plugin_id, session_id = EXCLUDED.session_id, started_at = CURRENT_TIMESTAMP
+`
+
+type StartVaultReshareParams struct {
+	PublicKey string
+	PluginID  string
+	SessionID string
+}
+
+func (q *Queries) StartVaultReshare(ctx context.Context, arg StartVaultReshareParams) error {
+	_, err := q.db.Exec(ctx, startVaultReshare, arg.PublicKey, arg.PluginID, arg.SessionID)
+	return err
+}
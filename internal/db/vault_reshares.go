@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vultisig/app-developer/internal/db/sqlcgen"
+)
+
+// StartVaultReshare pauses fee executions for publicKey while sessionID runs.
+func (p *PostgresBackend) StartVaultReshare(ctx context.Context, publicKey, pluginID, sessionID string) error {
+	err := p.queries.StartVaultReshare(ctx, sqlcgen.StartVaultReshareParams{
+		PublicKey: publicKey,
+		PluginID:  pluginID,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start vault reshare: %w", err)
+	}
+	return nil
+}
+
+// FinishVaultReshare lifts the pause, unless a newer session replaced it.
+func (p *PostgresBackend) FinishVaultReshare(ctx context.Context, publicKey, sessionID string) error {
+	err := p.queries.FinishVaultReshare(ctx, sqlcgen.FinishVaultReshareParams{
+		PublicKey: publicKey,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to finish vault reshare: %w", err)
+	}
+	return nil
+}
+
+// IsVaultResharing reports whether a reshare of publicKey started within
+// timeout and has not finished.
+func (p *PostgresBackend) IsVaultResharing(ctx context.Context, publicKey string, timeout time.Duration) (bool, error) {
+	resharing, err := p.queries.IsVaultResharing(ctx, sqlcgen.IsVaultResharingParams{
+		PublicKey:      publicKey,
+		TimeoutSeconds: timeout.Seconds(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to check vault reshare: %w", err)
+	}
+	return resharing, nil
+}
@@ -0,0 +1,189 @@
+package evm
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+	vaultType "github.com/vultisig/commondata/go/vultisig/vault/v1"
+	"github.com/vultisig/mobile-tss-lib/tss"
+	"github.com/vultisig/verifier/vault"
+	"github.com/vultisig/vultisig-go/address"
+	vcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// DerivedAddressStore caches derived addresses in encrypted form. It is
+// satisfied by *db.PostgresBackend.
+type DerivedAddressStore interface {
+	// GetDerivedAddress returns nil when nothing is cached for publicKey.
+	GetDerivedAddress(ctx context.Context, publicKey string) ([]byte, error)
+	SaveDerivedAddress(ctx context.Context, publicKey string, encrypted []byte) error
+}
+
+// VaultAddressDeriver resolves the Ethereum address of the vault a plugin was
+// installed into, from the encrypted backup held in vault storage. The address
+// depends only on the vault, so it is cached per public key and the backup is
+// fetched once.
+type VaultAddressDeriver struct {
+	vaultStorage vault.Storage
+	vaultSecret  string
+	cacheSecret  string
+	store        DerivedAddressStore
+	logger       *logrus.Entry
+}
+
+func NewVaultAddressDeriver(
+	vaultStorage vault.Storage,
+	vaultSecret string,
+	cacheSecret string,
+	store DerivedAddressStore,
+	logger *logrus.Logger,
+) *VaultAddressDeriver {
+	return &VaultAddressDeriver{
+		vaultStorage: vaultStorage,
+		vaultSecret:  vaultSecret,
+		cacheSecret:  cacheSecret,
+		store:        store,
+		logger:       logger.WithField("pkg", "evm.VaultAddressDeriver"),
+	}
+}
+
+// DeriveAddress returns the cached address for publicKey, deriving and caching
+// it on a miss. A cache that cannot be read or written only costs a derivation.
+func (d *VaultAddressDeriver) DeriveAddress(ctx context.Context, publicKey string, pluginID string) (ecommon.Address, error) {
+	logger := d.logger.WithContext(ctx).WithField("public_key", publicKey)
+
+	cached, err := d.cachedAddress(ctx, publicKey)
+	if err != nil {
+		logger.WithError(err).Warn("failed to read cached address, deriving from vault backup")
+	}
+	if cached != nil {
+		return *cached, nil
+	}
+
+	addr, err := d.deriveFromBackup(publicKey, pluginID)
+	if err != nil {
+		return ecommon.Address{}, err
+	}
+
+	encrypted, err := d.encrypt(publicKey, addr)
+	if err != nil {
+		logger.WithError(err).Warn("failed to encrypt derived address")
+		return addr, nil
+	}
+	err = d.store.SaveDerivedAddress(ctx, publicKey, encrypted)
+	if err != nil {
+		logger.WithError(err).Warn("failed to cache derived address")
+	}
+	return addr, nil
+}
+
+func (d *VaultAddressDeriver) cachedAddress(ctx context.Context, publicKey string) (*ecommon.Address, error) {
+	encrypted, err := d.store.GetDerivedAddress(ctx, publicKey)
+	if err != nil || encrypted == nil {
+		return nil, err
+	}
+	addr, err := d.decrypt(publicKey, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	return &addr, nil
+}
+
+func (d *VaultAddressDeriver) deriveFromBackup(publicKey string, pluginID string) (ecommon.Address, error) {
+	vlt, err := loadVaultBackup(d.vaultStorage, d.vaultSecret, publicKey, pluginID)
+	if err != nil {
+		return ecommon.Address{}, err
+	}
+
+	childPub, err := tss.GetDerivedPubKey(publicKey, vlt.GetHexChainCode(), vcommon.Ethereum.GetDerivePath(), false)
+	if err != nil {
+		return ecommon.Address{}, fmt.Errorf("failed to get derived pubkey: %w", err)
+	}
+
+	addr, err := address.GetEVMAddress(childPub)
+	if err != nil {
+		return ecommon.Address{}, fmt.Errorf("failed to get address: %w", err)
+	}
+
+	return ecommon.HexToAddress(addr), nil
+}
+
+// CheckBackup fetches and decrypts the backup of the vault with publicKey from
+// vault storage, bypassing the address cache, and reports why it is unusable,
+// if it is.
+func (d *VaultAddressDeriver) CheckBackup(publicKey, pluginID string) error {
+	_, err := loadVaultBackup(d.vaultStorage, d.vaultSecret, publicKey, pluginID)
+	return err
+}
+
+// loadVaultBackup fetches and decrypts the backup of the vault with publicKey
+// that pluginID was installed into.
+func loadVaultBackup(vaultStorage vault.Storage, vaultSecret, publicKey, pluginID string) (*vaultType.Vault, error) {
+	vaultContent, err := vaultStorage.GetVault(vcommon.GetVaultBackupFilename(publicKey, pluginID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vault content: %w", err)
+	}
+
+	vlt, err := vcommon.DecryptVaultFromBackup(vaultSecret, vaultContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt vault: %w", err)
+	}
+	if vlt.GetPublicKeyEcdsa() != publicKey {
+		return nil, fmt.Errorf("vault backup has public key %s, expected %s", vlt.GetPublicKeyEcdsa(), publicKey)
+	}
+	return vlt, nil
+}
+
+// encrypt seals addr with AES-GCM under a key derived from the cache secret.
+// The public key is bound as additional data, so a row copied to another key
+// does not decrypt.
+func (d *VaultAddressDeriver) encrypt(publicKey string, addr ecommon.Address) ([]byte, error) {
+	gcm, err := d.cipher()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, addr.Bytes(), []byte(publicKey)), nil
+}
+
+func (d *VaultAddressDeriver) decrypt(publicKey string, encrypted []byte) (ecommon.Address, error) {
+	gcm, err := d.cipher()
+	if err != nil {
+		return ecommon.Address{}, err
+	}
+	if len(encrypted) < gcm.NonceSize() {
+		return ecommon.Address{}, fmt.Errorf("cached address is too short")
+	}
+	nonce, sealed := encrypted[:gcm.NonceSize()], encrypted[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, sealed, []byte(publicKey))
+	if err != nil {
+		return ecommon.Address{}, fmt.Errorf("failed to decrypt cached address: %w", err)
+	}
+	if len(plain) != ecommon.AddressLength {
+		return ecommon.Address{}, fmt.Errorf("cached address has length %d", len(plain))
+	}
+	return ecommon.BytesToAddress(plain), nil
+}
+
+func (d *VaultAddressDeriver) cipher() (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(d.cacheSecret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
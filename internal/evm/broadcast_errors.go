@@ -0,0 +1,40 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+const (
+	BroadcastErrNonce             = "nonce"
+	BroadcastErrUnderpriced       = "underpriced"
+	BroadcastErrInsufficientFunds = "insufficient_funds"
+	BroadcastErrAlreadyKnown      = "already_known"
+	BroadcastErrTimeout           = "timeout"
+	BroadcastErrOther             = "other"
+)
+
+// ClassifyBroadcastError maps an RPC error to a coarse class. Nodes return
+// these as JSON-RPC messages, so the match is on the message text.
+func ClassifyBroadcastError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return BroadcastErrTimeout
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "nonce too low"), strings.Contains(msg, "nonce too high"):
+		return BroadcastErrNonce
+	case strings.Contains(msg, "underpriced"), strings.Contains(msg, "fee cap"):
+		return BroadcastErrUnderpriced
+	case strings.Contains(msg, "insufficient funds"):
+		return BroadcastErrInsufficientFunds
+	case strings.Contains(msg, "already known"):
+		return BroadcastErrAlreadyKnown
+	case strings.Contains(msg, "timeout"), strings.Contains(msg, "deadline exceeded"):
+		return BroadcastErrTimeout
+	default:
+		return BroadcastErrOther
+	}
+}
@@ -0,0 +1,232 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/app-developer/internal/metrics"
+)
+
+const (
+	// broadcastTimeout bounds a single endpoint's eth_sendRawTransaction.
+	broadcastTimeout = 15 * time.Second
+	// endpointCooldownBase is how long an endpoint is tried last after one
+	// failure; it doubles with each consecutive failure up to the max.
+	endpointCooldownBase = 10 * time.Second
+	endpointCooldownMax  = 5 * time.Minute
+)
+
+// TxSender is satisfied by *ethclient.Client.
+type TxSender interface {
+	SendTransaction(ctx context.Context, tx *etypes.Transaction) error
+}
+
+type BroadcastEndpoint struct {
+	// Name identifies the endpoint in logs and metrics. It must not carry
+	// credentials, which RPC URLs often do.
+	Name   string
+	Client TxSender
+}
+
+type endpointHealth struct {
+	BroadcastEndpoint
+	order         int
+	failures      int
+	cooldownUntil time.Time
+}
+
+// Broadcaster sends signed transactions through several RPC endpoints. Healthy
+// endpoints are tried first, in configured order; one that fails is tried last
+// until its cooldown passes. With fanOut set the transaction is sent to every
+// endpoint at once, e.g. to a private relay and a public node together.
+type Broadcaster struct {
+	mu        sync.Mutex
+	endpoints []*endpointHealth
+	fanOut    bool
+	metrics   metrics.ListingFeeMetrics
+	logger    *logrus.Entry
+}
+
+func NewBroadcaster(
+	endpoints []BroadcastEndpoint,
+	fanOut bool,
+	feeMetrics metrics.ListingFeeMetrics,
+	logger *logrus.Logger,
+) *Broadcaster {
+	health := make([]*endpointHealth, len(endpoints))
+	for i, ep := range endpoints {
+		health[i] = &endpointHealth{BroadcastEndpoint: ep, order: i}
+	}
+	return &Broadcaster{
+		endpoints: health,
+		fanOut:    fanOut,
+		metrics:   feeMetrics,
+		logger:    logger.WithField("pkg", "evm.Broadcaster"),
+	}
+}
+
+// DialBroadcaster connects to each RPC URL and names the endpoints by host.
+func DialBroadcaster(
+	rpcURLs []string,
+	fanOut bool,
+	feeMetrics metrics.ListingFeeMetrics,
+	logger *logrus.Logger,
+) (*Broadcaster, error) {
+	if len(rpcURLs) == 0 {
+		return nil, errors.New("at least one broadcast RPC URL is required")
+	}
+
+	seen := make(map[string]int)
+	endpoints := make([]BroadcastEndpoint, 0, len(rpcURLs))
+	for i, rawURL := range rpcURLs {
+		client, err := ethclient.Dial(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial broadcast RPC %d: %w", i, err)
+		}
+
+		name := fmt.Sprintf("rpc-%d", i)
+		u, err := url.Parse(rawURL)
+		if err == nil && u.Host != "" {
+			name = u.Host
+		}
+		seen[name]++
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s#%d", name, seen[name])
+		}
+
+		endpoints = append(endpoints, BroadcastEndpoint{Name: name, Client: client})
+	}
+	return NewBroadcaster(endpoints, fanOut, feeMetrics, logger), nil
+}
+
+// Broadcast sends tx and returns the name of the endpoint that accepted it.
+// An endpoint that already knows the transaction counts as accepting it.
+func (b *Broadcaster) Broadcast(ctx context.Context, tx *etypes.Transaction) (string, error) {
+	ranked := b.ranked()
+	if b.fanOut {
+		return b.broadcastAll(ctx, ranked, tx)
+	}
+
+	var errs []error
+	for _, ep := range ranked {
+		err := b.send(ctx, ep, tx)
+		if err == nil {
+			return ep.Name, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", ep.Name, err))
+		if isTxRejection(err) || ctx.Err() != nil {
+			// Other nodes would reject the same transaction for the same reason.
+			break
+		}
+	}
+	return "", errors.Join(errs...)
+}
+
+func (b *Broadcaster) broadcastAll(ctx context.Context, ranked []*endpointHealth, tx *etypes.Transaction) (string, error) {
+	errs := make([]error, len(ranked))
+	var wg sync.WaitGroup
+	for i, ep := range ranked {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = b.send(ctx, ep, tx)
+		}()
+	}
+	wg.Wait()
+
+	var failed []error
+	for i, ep := range ranked {
+		if errs[i] == nil {
+			return ep.Name, nil
+		}
+		failed = append(failed, fmt.Errorf("%s: %w", ep.Name, errs[i]))
+	}
+	return "", errors.Join(failed...)
+}
+
+func (b *Broadcaster) send(ctx context.Context, ep *endpointHealth, tx *etypes.Transaction) error {
+	sendCtx, cancel := context.WithTimeout(ctx, broadcastTimeout)
+	defer cancel()
+
+	err := ep.Client.SendTransaction(sendCtx, tx)
+	if err != nil && ClassifyBroadcastError(err) == BroadcastErrAlreadyKnown {
+		err = nil
+	}
+
+	logger := b.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"endpoint": ep.Name,
+		"tx_hash":  tx.Hash().Hex(),
+	})
+	if err != nil {
+		b.metrics.RecordBroadcast(ep.Name, metrics.ResultError)
+		// A rejected transaction says nothing about the endpoint's health.
+		if !isTxRejection(err) && ctx.Err() == nil {
+			b.recordFailure(ep)
+		}
+		logger.WithError(err).Warn("broadcast endpoint failed")
+		return err
+	}
+
+	b.metrics.RecordBroadcast(ep.Name, metrics.ResultSuccess)
+	b.recordSuccess(ep)
+	logger.Debug("broadcast endpoint accepted transaction")
+	return nil
+}
+
+// isTxRejection reports errors caused by the transaction rather than the node.
+func isTxRejection(err error) bool {
+	switch ClassifyBroadcastError(err) {
+	case BroadcastErrNonce, BroadcastErrUnderpriced, BroadcastErrInsufficientFunds:
+		return true
+	default:
+		return false
+	}
+}
+
+// ranked orders endpoints out of cooldown first, then by fewest consecutive
+// failures, then by configured order.
+func (b *Broadcaster) ranked() []*endpointHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	ranked := make([]*endpointHealth, len(b.endpoints))
+	copy(ranked, b.endpoints)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		iCool, jCool := now.Before(ranked[i].cooldownUntil), now.Before(ranked[j].cooldownUntil)
+		if iCool != jCool {
+			return !iCool
+		}
+		if ranked[i].failures != ranked[j].failures {
+			return ranked[i].failures < ranked[j].failures
+		}
+		return ranked[i].order < ranked[j].order
+	})
+	return ranked
+}
+
+func (b *Broadcaster) recordFailure(ep *endpointHealth) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ep.failures++
+	cooldown := endpointCooldownBase << min(ep.failures-1, 10)
+	ep.cooldownUntil = time.Now().Add(min(cooldown, endpointCooldownMax))
+}
+
+func (b *Broadcaster) recordSuccess(ep *endpointHealth) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ep.failures = 0
+	ep.cooldownUntil = time.Time{}
+}
@@ -0,0 +1,210 @@
+package evm
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	rethereum "github.com/vultisig/recipes/chain/evm/ethereum"
+	"github.com/vultisig/recipes/engine"
+	"github.com/vultisig/recipes/metarule"
+	rtypes "github.com/vultisig/recipes/types"
+	"github.com/vultisig/verifier/types"
+	rcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// erc20TransferSelector is the selector of transfer(address,uint256).
+var erc20TransferSelector = []byte{0xa9, 0x05, 0x9c, 0xbb}
+
+// EvaluationReport is the recipe engine's verdict on a payment together with
+// the parameters decoded from it and the constraints it was held to, so a
+// denial can be traced to the rule that should have allowed it.
+type EvaluationReport struct {
+	Allowed bool `json:"allowed"`
+	// MatchedRule and MatchedResource identify the rule the engine matched.
+	MatchedRule     string `json:"matched_rule,omitempty"`
+	MatchedResource string `json:"matched_resource,omitempty"`
+	// Error is the engine's reason for denying the payment.
+	Error string `json:"error,omitempty"`
+	// Parameters holds asset, from_address, amount and to_address as decoded
+	// from the transaction.
+	Parameters map[string]string `json:"parameters"`
+	// Checks are the matched rule's constraints or, on a denial, those of the
+	// rules for the same resource and target.
+	Checks []ConstraintCheck `json:"checks"`
+}
+
+// Constraint check statuses. The engine only reports which rule it matched,
+// so constraints of any other rule are not evaluated on their own.
+const (
+	ConstraintPassed       = "passed"
+	ConstraintNotEvaluated = "not_evaluated"
+)
+
+type ConstraintCheck struct {
+	RuleID    string `json:"rule_id"`
+	Resource  string `json:"resource"`
+	Parameter string `json:"parameter"`
+	Type      string `json:"type"`
+	Expected  string `json:"expected,omitempty"`
+	Actual    string `json:"actual,omitempty"`
+	Status    string `json:"status"`
+}
+
+// EvaluatePolicy runs unsignedTx from from through the policy's recipe. A
+// denial is reported in the result; errors mean the evaluation itself could not
+// run.
+func EvaluatePolicy(
+	policy types.PluginPolicy,
+	chain rcommon.Chain,
+	from ecommon.Address,
+	unsignedTx []byte,
+) (*EvaluationReport, error) {
+	recipe, err := policy.GetRecipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack recipe: %w", err)
+	}
+
+	transfer, err := decodeTransfer(chain, from, unsignedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	eng, err := engine.NewEngine()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create engine: %w", err)
+	}
+
+	report := &EvaluationReport{
+		Parameters: transfer.params,
+	}
+	rule, err := eng.Evaluate(recipe, chain, unsignedTx)
+	if err != nil {
+		report.Error = err.Error()
+		report.Checks, err = candidateChecks(recipe, transfer)
+		if err != nil {
+			return nil, err
+		}
+		return report, nil
+	}
+	report.Allowed = true
+	report.MatchedRule = rule.GetId()
+	report.MatchedResource = rule.GetResource()
+	report.Checks = constraintChecks(rule, transfer.params, ConstraintPassed)
+	return report, nil
+}
+
+// decodedTransfer is a payment transaction as the engine sees it: the
+// resource its rule must have, the address that rule must target and the
+// decoded parameters.
+type decodedTransfer struct {
+	resource string
+	target   ecommon.Address
+	params   map[string]string
+}
+
+// decodeTransfer names the transaction's fields the way the send rule's
+// parameters do. A native transfer has an empty asset.
+func decodeTransfer(chain rcommon.Chain, from ecommon.Address, unsignedTx []byte) (*decodedTransfer, error) {
+	txData, err := rethereum.DecodeUnsignedPayload(unsignedTx)
+	if err != nil {
+		return nil, fmt.Errorf("ethereum.DecodeUnsignedPayload: %w", err)
+	}
+	tx := etypes.NewTx(txData)
+
+	transfer := &decodedTransfer{
+		params: map[string]string{
+			"from_address": from.Hex(),
+		},
+	}
+	data := tx.Data()
+	switch {
+	case len(data) == 68 && bytes.Equal(data[:4], erc20TransferSelector) && tx.To() != nil:
+		transfer.resource = fmt.Sprintf("%s.erc20.transfer", strings.ToLower(chain.String()))
+		transfer.target = *tx.To()
+		transfer.params["asset"] = tx.To().Hex()
+		transfer.params["to_address"] = ecommon.BytesToAddress(data[4:36]).Hex()
+		transfer.params["amount"] = new(big.Int).SetBytes(data[36:68]).String()
+	case len(data) == 0 && tx.To() != nil:
+		symbol, err := chain.NativeSymbol()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get native symbol: %w", err)
+		}
+		transfer.resource = fmt.Sprintf("%s.%s.transfer", strings.ToLower(chain.String()), strings.ToLower(symbol))
+		transfer.target = *tx.To()
+		transfer.params["asset"] = ""
+		transfer.params["to_address"] = tx.To().Hex()
+		transfer.params["amount"] = tx.Value().String()
+	}
+	return transfer, nil
+}
+
+// candidateChecks lists the constraints of the rules the engine could have
+// matched the denied transfer against: those for its resource and, where the
+// rule names one, its target address.
+func candidateChecks(recipe *rtypes.Policy, transfer *decodedTransfer) ([]ConstraintCheck, error) {
+	if transfer.resource == "" {
+		return nil, nil
+	}
+
+	var checks []ConstraintCheck
+	for _, ruleRaw := range recipe.GetRules() {
+		rules, err := metarule.NewMetaRule().TryFormat(ruleRaw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to format rule %s: %w", ruleRaw.GetId(), err)
+		}
+		for _, rule := range rules {
+			if rule.GetResource() != transfer.resource {
+				continue
+			}
+			target := rule.GetTarget().GetAddress()
+			if target != "" && ecommon.HexToAddress(target) != transfer.target {
+				continue
+			}
+			checks = append(checks, constraintChecks(rule, transfer.params, ConstraintNotEvaluated)...)
+		}
+	}
+	return checks, nil
+}
+
+// ruleParameters maps the parameter names of the rules the engine evaluates
+// to the send rule's names used in the report's parameters.
+var ruleParameters = map[string]string{
+	"recipient": "to_address",
+}
+
+func constraintChecks(rule *rtypes.Rule, params map[string]string, status string) []ConstraintCheck {
+	var checks []ConstraintCheck
+	for _, pc := range rule.GetParameterConstraints() {
+		name := pc.GetParameterName()
+		if mapped, ok := ruleParameters[name]; ok {
+			name = mapped
+		}
+		constraint := pc.GetConstraint()
+		check := ConstraintCheck{
+			RuleID:    rule.GetId(),
+			Resource:  rule.GetResource(),
+			Parameter: pc.GetParameterName(),
+			Type:      constraint.GetType().String(),
+			Actual:    params[name],
+			Status:    status,
+		}
+		switch constraint.GetType() {
+		case rtypes.ConstraintType_CONSTRAINT_TYPE_FIXED:
+			check.Expected = constraint.GetFixedValue()
+		case rtypes.ConstraintType_CONSTRAINT_TYPE_MAX:
+			check.Expected = constraint.GetMaxValue()
+		case rtypes.ConstraintType_CONSTRAINT_TYPE_MIN:
+			check.Expected = constraint.GetMinValue()
+		case rtypes.ConstraintType_CONSTRAINT_TYPE_REGEXP:
+			check.Expected = constraint.GetRegexpValue()
+		case rtypes.ConstraintType_CONSTRAINT_TYPE_MAGIC_CONSTANT:
+			check.Expected = constraint.GetMagicConstantValue().String()
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
@@ -0,0 +1,113 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	ecommon "github.com/ethereum/go-ethereum/common"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	rethereum "github.com/vultisig/recipes/chain/evm/ethereum"
+)
+
+// TxLookupClient is satisfied by *ethclient.Client.
+type TxLookupClient interface {
+	ReceiptClient
+	TransactionByHash(ctx context.Context, hash ecommon.Hash) (tx *etypes.Transaction, isPending bool, err error)
+	NonceAt(ctx context.Context, account ecommon.Address, blockNumber *big.Int) (uint64, error)
+}
+
+// TxState describes what the chain knows about one of an intent's hashes.
+type TxState struct {
+	Hash     string
+	Found    bool
+	Pending  bool
+	Reverted bool
+}
+
+// UnsignedTxNonce returns the nonce baked into an unsigned payload built by the SDK.
+func UnsignedTxNonce(unsignedTx []byte) (uint64, error) {
+	txData, err := rethereum.DecodeUnsignedPayload(unsignedTx)
+	if err != nil {
+		return 0, fmt.Errorf("ethereum.DecodeUnsignedPayload: %w", err)
+	}
+	return etypes.NewTx(txData).Nonce(), nil
+}
+
+// feeBumpPercent is how much RepriceUnsignedTx raises both fee caps. Nodes
+// only accept a replacement for a pending nonce above a minimum bump, 10% by
+// default in geth.
+const feeBumpPercent = 25
+
+// RepriceUnsignedTx returns a copy of an unsigned dynamic-fee payload built by
+// the SDK with its tip and fee caps raised by feeBumpPercent. Nonce, gas and
+// calldata are unchanged, so the result replaces the original transaction.
+func RepriceUnsignedTx(unsignedTx []byte) ([]byte, error) {
+	txData, err := rethereum.DecodeUnsignedPayload(unsignedTx)
+	if err != nil {
+		return nil, fmt.Errorf("ethereum.DecodeUnsignedPayload: %w", err)
+	}
+	tx, ok := txData.(*etypes.DynamicFeeTx)
+	if !ok {
+		return nil, fmt.Errorf("unsupported transaction type %d", unsignedTx[0])
+	}
+
+	encoded, err := rlp.EncodeToBytes(rethereum.DynamicFeeTxWithoutSignature{
+		ChainID:    tx.ChainID,
+		Nonce:      tx.Nonce,
+		GasTipCap:  bumpFee(tx.GasTipCap),
+		GasFeeCap:  bumpFee(tx.GasFeeCap),
+		Gas:        tx.Gas,
+		To:         tx.To,
+		Value:      tx.Value,
+		Data:       tx.Data,
+		AccessList: tx.AccessList,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rlp.EncodeToBytes: %w", err)
+	}
+	return append([]byte{etypes.DynamicFeeTxType}, encoded...), nil
+}
+
+func bumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+feeBumpPercent))
+	bumped.Div(bumped, big.NewInt(100))
+	// A zero tip would otherwise stay zero.
+	if bumped.Cmp(fee) <= 0 {
+		bumped.Add(fee, big.NewInt(1))
+	}
+	return bumped
+}
+
+// LookupTransactions returns the state of the first hash the node knows about.
+// A zero TxState means none of the hashes were found.
+func LookupTransactions(ctx context.Context, client TxLookupClient, hashes []string) (TxState, error) {
+	for _, h := range hashes {
+		hash := ecommon.HexToHash(h)
+
+		receipt, err := client.TransactionReceipt(ctx, hash)
+		if err == nil {
+			return TxState{
+				Hash:     h,
+				Found:    true,
+				Reverted: receipt.Status != etypes.ReceiptStatusSuccessful,
+			}, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return TxState{}, fmt.Errorf("failed to get receipt for %s: %w", h, err)
+		}
+
+		_, isPending, err := client.TransactionByHash(ctx, hash)
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return TxState{}, fmt.Errorf("failed to get transaction %s: %w", h, err)
+		}
+		return TxState{Hash: h, Found: true, Pending: isPending}, nil
+	}
+	return TxState{}, nil
+}
@@ -0,0 +1,199 @@
+package evm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	ecommon "github.com/ethereum/go-ethereum/common"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	rethereum "github.com/vultisig/recipes/chain/evm/ethereum"
+)
+
+// testLookup knows the receipts of mined transactions and whether others are
+// still pending.
+type testLookup struct {
+	receipts map[ecommon.Hash]uint64
+	pending  map[ecommon.Hash]bool
+	err      error
+}
+
+func (l *testLookup) TransactionReceipt(_ context.Context, hash ecommon.Hash) (*etypes.Receipt, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	status, ok := l.receipts[hash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return &etypes.Receipt{Status: status}, nil
+}
+
+func (l *testLookup) TransactionByHash(_ context.Context, hash ecommon.Hash) (*etypes.Transaction, bool, error) {
+	isPending, ok := l.pending[hash]
+	if !ok {
+		return nil, false, ethereum.NotFound
+	}
+	return testTx(), isPending, nil
+}
+
+func (l *testLookup) NonceAt(context.Context, ecommon.Address, *big.Int) (uint64, error) {
+	return 0, nil
+}
+
+func TestLookupTransactions(t *testing.T) {
+	first := ecommon.HexToHash("0x01")
+	second := ecommon.HexToHash("0x02")
+
+	tests := []struct {
+		name   string
+		lookup *testLookup
+		hashes []ecommon.Hash
+		want   TxState
+	}{
+		{
+			name:   "mined",
+			lookup: &testLookup{receipts: map[ecommon.Hash]uint64{first: etypes.ReceiptStatusSuccessful}},
+			hashes: []ecommon.Hash{first},
+			want:   TxState{Hash: first.Hex(), Found: true},
+		},
+		{
+			name:   "reverted",
+			lookup: &testLookup{receipts: map[ecommon.Hash]uint64{first: etypes.ReceiptStatusFailed}},
+			hashes: []ecommon.Hash{first},
+			want:   TxState{Hash: first.Hex(), Found: true, Reverted: true},
+		},
+		{
+			name:   "pending",
+			lookup: &testLookup{pending: map[ecommon.Hash]bool{first: true}},
+			hashes: []ecommon.Hash{first},
+			want:   TxState{Hash: first.Hex(), Found: true, Pending: true},
+		},
+		{
+			name:   "not found",
+			lookup: &testLookup{},
+			hashes: []ecommon.Hash{first},
+			want:   TxState{},
+		},
+		{
+			name:   "no hashes",
+			lookup: &testLookup{},
+			want:   TxState{},
+		},
+		{
+			name:   "replacement mined",
+			lookup: &testLookup{receipts: map[ecommon.Hash]uint64{second: etypes.ReceiptStatusSuccessful}},
+			hashes: []ecommon.Hash{first, second},
+			want:   TxState{Hash: second.Hex(), Found: true},
+		},
+		{
+			name:   "replaced tx mined after all",
+			lookup: &testLookup{receipts: map[ecommon.Hash]uint64{first: etypes.ReceiptStatusSuccessful}},
+			hashes: []ecommon.Hash{first, second},
+			want:   TxState{Hash: first.Hex(), Found: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hashes []string
+			for _, h := range tt.hashes {
+				hashes = append(hashes, h.Hex())
+			}
+			got, err := LookupTransactions(context.Background(), tt.lookup, hashes)
+			if err != nil {
+				t.Fatalf("LookupTransactions: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("LookupTransactions = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupTransactionsNodeError(t *testing.T) {
+	nodeErr := errors.New("connection refused")
+	_, err := LookupTransactions(context.Background(), &testLookup{err: nodeErr}, []string{ecommon.HexToHash("0x01").Hex()})
+	if !errors.Is(err, nodeErr) {
+		t.Errorf("LookupTransactions error = %v, want %v", err, nodeErr)
+	}
+}
+
+func testUnsignedTx(t *testing.T, tipCap, feeCap int64) []byte {
+	t.Helper()
+
+	to := ecommon.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	raw, err := rlp.EncodeToBytes(rethereum.DynamicFeeTxWithoutSignature{
+		ChainID:   big.NewInt(1),
+		Nonce:     9,
+		GasTipCap: big.NewInt(tipCap),
+		GasFeeCap: big.NewInt(feeCap),
+		Gas:       60000,
+		To:        &to,
+		Value:     big.NewInt(0),
+		Data:      []byte{0xa9, 0x05, 0x9c, 0xbb},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode unsigned tx: %v", err)
+	}
+	return append([]byte{etypes.DynamicFeeTxType}, raw...)
+}
+
+func TestRepriceUnsignedTx(t *testing.T) {
+	tests := []struct {
+		name             string
+		tipCap, feeCap   int64
+		wantTip, wantCap int64
+	}{
+		{name: "bumped by a quarter", tipCap: 2_000_000_000, feeCap: 40_000_000_000, wantTip: 2_500_000_000, wantCap: 50_000_000_000},
+		{name: "rounded down", tipCap: 3, feeCap: 10, wantTip: 4, wantCap: 12},
+		{name: "zero tip", tipCap: 0, feeCap: 1, wantTip: 1, wantCap: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsigned := testUnsignedTx(t, tt.tipCap, tt.feeCap)
+			repriced, err := RepriceUnsignedTx(unsigned)
+			if err != nil {
+				t.Fatalf("RepriceUnsignedTx: %v", err)
+			}
+
+			before, err := rethereum.DecodeUnsignedPayload(unsigned)
+			if err != nil {
+				t.Fatalf("failed to decode original: %v", err)
+			}
+			after, err := rethereum.DecodeUnsignedPayload(repriced)
+			if err != nil {
+				t.Fatalf("failed to decode repriced tx: %v", err)
+			}
+			orig, got := etypes.NewTx(before), etypes.NewTx(after)
+			if got.GasTipCap().Int64() != tt.wantTip || got.GasFeeCap().Int64() != tt.wantCap {
+				t.Errorf("fees = %s/%s, want %d/%d", got.GasTipCap(), got.GasFeeCap(), tt.wantTip, tt.wantCap)
+			}
+			if got.Nonce() != orig.Nonce() || got.Gas() != orig.Gas() || *got.To() != *orig.To() ||
+				got.Value().Cmp(orig.Value()) != 0 || !bytes.Equal(got.Data(), orig.Data()) || got.ChainId().Cmp(orig.ChainId()) != 0 {
+				t.Errorf("repriced tx changed more than its fees: %+v, was %+v", after, before)
+			}
+		})
+	}
+}
+
+func TestRepriceUnsignedTxLegacy(t *testing.T) {
+	to := ecommon.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	raw, err := rlp.EncodeToBytes(rethereum.LegacyTxWithoutSignature{
+		Nonce:    9,
+		GasPrice: big.NewInt(1),
+		Gas:      21000,
+		To:       &to,
+		Value:    big.NewInt(1),
+	})
+	if err != nil {
+		t.Fatalf("failed to encode unsigned tx: %v", err)
+	}
+	_, err = RepriceUnsignedTx(append([]byte{etypes.LegacyTxType}, raw...))
+	if err == nil {
+		t.Error("RepriceUnsignedTx repriced a legacy transaction")
+	}
+}
@@ -0,0 +1,205 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/vultisig/verifier/plugin/keysign"
+	"github.com/vultisig/verifier/plugin/libhttp"
+	"github.com/vultisig/verifier/types"
+)
+
+const (
+	KeysignFailureRecipeDenied       = "recipe_denied"
+	KeysignFailureVerifierRejected   = "verifier_rejected"
+	KeysignFailureEmitterUnreachable = "emitter_unreachable"
+	KeysignFailurePluginPaused       = "plugin_paused"
+	KeysignFailureRelayUnreachable   = "relay_unreachable"
+	KeysignFailurePartyTimeout       = "party_timeout"
+	KeysignFailureAborted            = "aborted"
+	KeysignFailurePartiesUnresolved  = "parties_unresolved"
+	KeysignFailureSignatureMismatch  = "signature_mismatch"
+	KeysignFailureOther              = "other"
+)
+
+// errPartyTimeout is the cause of a keysign context whose own deadline fired,
+// as opposed to one cancelled or timed out by its caller.
+var errPartyTimeout = errors.New("parties did not finish signing in time")
+
+const (
+	KeysignStageEvaluate = "evaluate"
+	KeysignStagePrepare  = "prepare"
+	KeysignStageEmit     = "emit"
+	KeysignStageJoin     = "join"
+	KeysignStageSign     = "sign"
+	KeysignStageAssemble = "assemble"
+)
+
+// KeysignFailure describes why signing a payment failed. It is stored as the
+// fee's failure details, so its JSON form is part of the API.
+type KeysignFailure struct {
+	Category string `json:"category"`
+	Stage    string `json:"stage"`
+	Message  string `json:"message"`
+	// SessionID is the relay session, once the emitters were reached.
+	SessionID string `json:"session_id,omitempty"`
+	// Emitter names the emitter that failed to start the session.
+	Emitter    string `json:"emitter,omitempty"`
+	HTTPStatus int    `json:"http_status,omitempty"`
+	// PartiesExpected are the party ID prefixes the session waits for.
+	PartiesExpected []string `json:"parties_expected,omitempty"`
+	PartiesJoined   []string `json:"parties_joined,omitempty"`
+	PartiesMissing  []string `json:"parties_missing,omitempty"`
+	// RelayError is set when the joined parties could not be looked up.
+	RelayError string `json:"relay_error,omitempty"`
+
+	err error
+}
+
+func (f *KeysignFailure) Error() string {
+	return fmt.Sprintf("%s during %s: %v", f.Category, f.Stage, f.err)
+}
+
+func (f *KeysignFailure) Unwrap() error {
+	return f.err
+}
+
+func newKeysignFailure(category, stage string, err error) *KeysignFailure {
+	return &KeysignFailure{
+		Category: category,
+		Stage:    stage,
+		Message:  err.Error(),
+		err:      err,
+	}
+}
+
+// keysignSession is filled in by the recording emitters while keysign.Signer
+// runs, since the signer does not report the session it created.
+type keysignSession struct {
+	id         string
+	emitter    string
+	emitterErr error
+}
+
+type keysignSessionKey struct{}
+
+type recordingEmitter struct {
+	name  string
+	inner keysign.Emitter
+}
+
+// NewRecordingEmitter wraps an emitter so SignerService can tell which
+// session a failed keysign ran in and whether this emitter was the cause.
+func NewRecordingEmitter(name string, inner keysign.Emitter) keysign.Emitter {
+	return recordingEmitter{name: name, inner: inner}
+}
+
+func (e recordingEmitter) Sign(ctx context.Context, req types.PluginKeysignRequest) error {
+	err := e.inner.Sign(ctx, req)
+	session, ok := ctx.Value(keysignSessionKey{}).(*keysignSession)
+	if ok {
+		session.id = req.SessionID
+		if err != nil && session.emitterErr == nil {
+			session.emitter = e.name
+			session.emitterErr = err
+		}
+	}
+	return err
+}
+
+// SessionClient is satisfied by *relay.Client.
+type SessionClient interface {
+	GetSession(sessionID string) ([]string, error)
+}
+
+// KeysignDiagnoser classifies keysign.Signer failures and looks up which
+// parties joined the session.
+type KeysignDiagnoser struct {
+	relay SessionClient
+}
+
+func NewKeysignDiagnoser(relay SessionClient) *KeysignDiagnoser {
+	return &KeysignDiagnoser{
+		relay: relay,
+	}
+}
+
+// diagnose takes the party prefixes the failed signer waited for and whether
+// the keysign's own PartyTimeout fired.
+func (d *KeysignDiagnoser) diagnose(session *keysignSession, partyPrefixes []string, timedOut bool, err error) *KeysignFailure {
+	if session.emitterErr != nil {
+		f := newKeysignFailure(classifyEmitterError(session.emitterErr), KeysignStageEmit, err)
+		f.SessionID = session.id
+		f.Emitter = session.emitter
+		var httpErr *libhttp.HTTPError
+		if errors.As(session.emitterErr, &httpErr) {
+			f.HTTPStatus = httpErr.StatusCode
+		}
+		return f
+	}
+
+	var urlErr *url.Error
+	category := KeysignFailureOther
+	switch {
+	case timedOut:
+		category = KeysignFailurePartyTimeout
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		// The worker shut down, lost its lease or ran out of task time.
+		category = KeysignFailureAborted
+	case errors.As(err, &urlErr):
+		category = KeysignFailureRelayUnreachable
+	}
+
+	// Without a session the signer failed before reaching the emitters.
+	// Otherwise the parties the relay saw tell whether it was still waiting
+	// for them to join or for their signatures.
+	f := newKeysignFailure(category, KeysignStagePrepare, err)
+	f.SessionID = session.id
+	if session.id != "" {
+		f.Stage = KeysignStageJoin
+		d.addParties(f, partyPrefixes)
+		if f.RelayError == "" && len(f.PartiesMissing) == 0 {
+			f.Stage = KeysignStageSign
+		}
+	}
+	return f
+}
+
+func (d *KeysignDiagnoser) addParties(f *KeysignFailure, partyPrefixes []string) {
+	f.PartiesExpected = partyPrefixes
+
+	joined, err := d.relay.GetSession(f.SessionID)
+	if err != nil {
+		f.RelayError = err.Error()
+		return
+	}
+	f.PartiesJoined = joined
+
+	for _, prefix := range partyPrefixes {
+		found := false
+		for _, id := range joined {
+			if strings.HasPrefix(id, prefix+"-") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			f.PartiesMissing = append(f.PartiesMissing, prefix)
+		}
+	}
+}
+
+func classifyEmitterError(err error) string {
+	if errors.Is(err, keysign.ErrPluginPaused) {
+		return KeysignFailurePluginPaused
+	}
+	var httpErr *libhttp.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode < http.StatusInternalServerError {
+		return KeysignFailureVerifierRejected
+	}
+	return KeysignFailureEmitterUnreachable
+}
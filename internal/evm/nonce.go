@@ -0,0 +1,68 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/app-developer/internal/db"
+)
+
+// PendingNonceClient is satisfied by *ethclient.Client.
+type PendingNonceClient interface {
+	PendingNonceAt(ctx context.Context, account ecommon.Address) (uint64, error)
+}
+
+// NonceStore is satisfied by *db.PostgresBackend.
+type NonceStore interface {
+	ReserveNonce(ctx context.Context, policyID uuid.UUID, address string, chainNonce uint64) (*db.NonceReservation, error)
+}
+
+// NonceManager hands out nonces for the vault's address. The chain's pending
+// nonce is the floor; nonces above it that other pending fees hold are skipped,
+// and nonces abandoned by discarded intents are reused so no gap is left that
+// would stall later transactions.
+type NonceManager struct {
+	store  NonceStore
+	chain  PendingNonceClient
+	logger *logrus.Entry
+}
+
+func NewNonceManager(store NonceStore, chain PendingNonceClient, logger *logrus.Logger) *NonceManager {
+	return &NonceManager{
+		store:  store,
+		chain:  chain,
+		logger: logger.WithField("pkg", "evm.NonceManager"),
+	}
+}
+
+// Reserve returns the nonce for policyID's payment along with the pending
+// nonce it was reconciled against.
+func (m *NonceManager) Reserve(ctx context.Context, policyID uuid.UUID, address ecommon.Address) (nonce uint64, pending uint64, err error) {
+	pending, err = m.chain.PendingNonceAt(ctx, address)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get pending nonce: %w", err)
+	}
+
+	res, err := m.store.ReserveNonce(ctx, policyID, address.Hex(), pending)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	logger := m.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"policy_id":     policyID,
+		"address":       address.Hex(),
+		"nonce":         res.Nonce,
+		"pending_nonce": pending,
+	})
+	if res.ChainAhead {
+		logger.Warn("chain nonce moved past reserved nonces, transactions were sent outside the nonce manager")
+	}
+	if res.GapFilled {
+		logger.Info("reusing nonce abandoned by an earlier reservation")
+	}
+	return res.Nonce, pending, nil
+}
@@ -0,0 +1,158 @@
+package evm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vultisig/verifier/plugin/keysign"
+	"github.com/vultisig/verifier/vault"
+	vcommon "github.com/vultisig/vultisig-go/common"
+	"github.com/vultisig/vultisig-go/relay"
+)
+
+// PartyResolver works out which parties must sign for a vault. The plugin and
+// the verifier always take part; vaults whose threshold needs more signers
+// are completed with configured co-signers found among the vault's signers.
+// The result is cached per public key, since only a reshare changes a vault's
+// signers.
+type PartyResolver struct {
+	vaultStorage     vault.Storage
+	vaultSecret      string
+	pluginPrefix     string
+	verifierPrefix   string
+	coSignerPrefixes []string
+
+	mu      sync.Mutex
+	parties map[string][]string
+}
+
+func NewPartyResolver(
+	vaultStorage vault.Storage,
+	vaultSecret string,
+	pluginPrefix, verifierPrefix string,
+	coSignerPrefixes []string,
+) *PartyResolver {
+	return &PartyResolver{
+		vaultStorage:     vaultStorage,
+		vaultSecret:      vaultSecret,
+		pluginPrefix:     pluginPrefix,
+		verifierPrefix:   verifierPrefix,
+		coSignerPrefixes: coSignerPrefixes,
+		parties:          make(map[string][]string),
+	}
+}
+
+// Resolve returns the party prefixes a keysign for the vault must wait for,
+// read from the signers recorded in its backup on the first call for
+// publicKey. Co-signers are picked in configured order.
+func (r *PartyResolver) Resolve(publicKey, pluginID string) ([]string, error) {
+	r.mu.Lock()
+	cached, ok := r.parties[publicKey]
+	r.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	parties, err := r.resolve(publicKey, pluginID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.parties[publicKey] = parties
+	r.mu.Unlock()
+	return parties, nil
+}
+
+// Forget drops the cached parties of publicKey, so the next Resolve reads the
+// backup again. It is called after a reshare and after a failed keysign, since
+// a reshare run by another replica is not seen otherwise.
+func (r *PartyResolver) Forget(publicKey string) {
+	r.mu.Lock()
+	delete(r.parties, publicKey)
+	r.mu.Unlock()
+}
+
+func (r *PartyResolver) resolve(publicKey, pluginID string) ([]string, error) {
+	vlt, err := loadVaultBackup(r.vaultStorage, r.vaultSecret, publicKey, pluginID)
+	if err != nil {
+		return nil, err
+	}
+
+	signers := vlt.GetSigners()
+	threshold, err := vcommon.GetThreshold(len(signers))
+	if err != nil {
+		return nil, fmt.Errorf("vault has %d signers: %w", len(signers), err)
+	}
+	required := threshold + 1
+
+	var vaultPrefixes []string
+	for _, signer := range signers {
+		vaultPrefixes = append(vaultPrefixes, partyPrefix(signer))
+	}
+
+	parties := []string{r.pluginPrefix, r.verifierPrefix}
+	for _, prefix := range parties {
+		if !slices.Contains(vaultPrefixes, prefix) {
+			return nil, fmt.Errorf("party %s is not a signer of the vault", prefix)
+		}
+	}
+	for _, prefix := range r.coSignerPrefixes {
+		if len(parties) >= required {
+			break
+		}
+		if slices.Contains(vaultPrefixes, prefix) && !slices.Contains(parties, prefix) {
+			parties = append(parties, prefix)
+		}
+	}
+	if len(parties) < required {
+		return nil, fmt.Errorf("vault needs %d of its %d signers, only %d known co-signing parties are available: %s",
+			required, len(signers), len(parties), strings.Join(parties, ","))
+	}
+	return parties, nil
+}
+
+// partyPrefix strips the random suffix vcommon.GenerateLocalPartyId appends.
+func partyPrefix(partyID string) string {
+	i := strings.LastIndex(partyID, "-")
+	if i <= 0 {
+		return partyID
+	}
+	return partyID[:i]
+}
+
+// SignerPool hands out a keysign.Signer per party set, since a signer waits
+// for the parties it was built with.
+type SignerPool struct {
+	logger   *logrus.Logger
+	relay    *relay.Client
+	emitters []keysign.Emitter
+
+	mu      sync.Mutex
+	signers map[string]*keysign.Signer
+}
+
+func NewSignerPool(logger *logrus.Logger, relay *relay.Client, emitters []keysign.Emitter) *SignerPool {
+	return &SignerPool{
+		logger:   logger,
+		relay:    relay,
+		emitters: emitters,
+		signers:  make(map[string]*keysign.Signer),
+	}
+}
+
+func (p *SignerPool) For(partyPrefixes []string) KeysignSigner {
+	key := strings.Join(partyPrefixes, ",")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	signer, ok := p.signers[key]
+	if !ok {
+		signer = keysign.NewSigner(p.logger, p.relay, p.emitters, partyPrefixes)
+		p.signers[key] = signer
+	}
+	return signer
+}
@@ -0,0 +1,177 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/app-developer/internal/metrics"
+)
+
+// privateRelayPollInterval is how often inclusion of a privately submitted
+// transaction is checked, about once a block.
+const privateRelayPollInterval = 12 * time.Second
+
+// TxBroadcaster sends a signed transaction and names the endpoint that took it.
+// It is satisfied by *Broadcaster and *PrivateSubmitter.
+type TxBroadcaster interface {
+	Broadcast(ctx context.Context, tx *etypes.Transaction) (string, error)
+}
+
+// PrivateRelay is a relay accepting eth_sendPrivateTransaction, such as
+// Flashbots Protect.
+type PrivateRelay struct {
+	name   string
+	client *rpc.Client
+}
+
+func DialPrivateRelay(relayURL string) (*PrivateRelay, error) {
+	client, err := rpc.Dial(relayURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial private relay: %w", err)
+	}
+
+	name := "private-relay"
+	u, err := url.Parse(relayURL)
+	if err == nil && u.Host != "" {
+		name = u.Host
+	}
+	return &PrivateRelay{name: name, client: client}, nil
+}
+
+// SendPrivateTransaction hands tx to the relay, which keeps it out of the
+// public mempool until it is included.
+func (r *PrivateRelay) SendPrivateTransaction(ctx context.Context, tx *etypes.Transaction) error {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to encode transaction: %w", err)
+	}
+
+	var hash ecommon.Hash
+	err = r.client.CallContext(ctx, &hash, "eth_sendPrivateTransaction", map[string]any{
+		"tx": hexutil.Encode(raw),
+	})
+	if err != nil {
+		return fmt.Errorf("eth_sendPrivateTransaction: %w", err)
+	}
+	if hash != tx.Hash() {
+		return fmt.Errorf("relay returned hash %s for transaction %s", hash.Hex(), tx.Hash().Hex())
+	}
+	return nil
+}
+
+// PrivateSubmitter sends transactions through a private relay and waits for
+// them to be included. A transaction still not included when the deadline
+// passes is sent to the public broadcaster if fallback is enabled; the same
+// signed transaction is sent, so at most one copy can be mined.
+type PrivateSubmitter struct {
+	relay        *PrivateRelay
+	receipts     ReceiptClient
+	public       TxBroadcaster
+	deadline     time.Duration
+	fallback     bool
+	pollInterval time.Duration
+	metrics      metrics.ListingFeeMetrics
+	logger       *logrus.Entry
+}
+
+func NewPrivateSubmitter(
+	relay *PrivateRelay,
+	receipts ReceiptClient,
+	public TxBroadcaster,
+	deadline time.Duration,
+	fallback bool,
+	feeMetrics metrics.ListingFeeMetrics,
+	logger *logrus.Logger,
+) *PrivateSubmitter {
+	return &PrivateSubmitter{
+		relay:        relay,
+		receipts:     receipts,
+		public:       public,
+		deadline:     deadline,
+		fallback:     fallback,
+		pollInterval: privateRelayPollInterval,
+		metrics:      feeMetrics,
+		logger:       logger.WithField("pkg", "evm.PrivateSubmitter"),
+	}
+}
+
+// Broadcast returns once tx is included via the relay or, failing that, has
+// been accepted by a public endpoint.
+func (s *PrivateSubmitter) Broadcast(ctx context.Context, tx *etypes.Transaction) (string, error) {
+	logger := s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"relay":   s.relay.name,
+		"tx_hash": tx.Hash().Hex(),
+	})
+
+	sendCtx, cancel := context.WithTimeout(ctx, broadcastTimeout)
+	err := s.relay.SendPrivateTransaction(sendCtx, tx)
+	cancel()
+	if err != nil {
+		s.metrics.RecordBroadcast(s.relay.name, metrics.ResultError)
+		if !s.fallback {
+			return "", err
+		}
+		logger.WithError(err).Warn("private relay rejected transaction, falling back to public broadcast")
+		return s.broadcastPublic(ctx, tx)
+	}
+	s.metrics.RecordBroadcast(s.relay.name, metrics.ResultSuccess)
+
+	included, err := s.waitForInclusion(ctx, tx.Hash())
+	if err != nil {
+		return "", err
+	}
+	if included {
+		logger.Info("privately submitted transaction included")
+		return s.relay.name, nil
+	}
+
+	if !s.fallback {
+		return "", fmt.Errorf("transaction not included by private relay within %s", s.deadline)
+	}
+	logger.WithField("deadline", s.deadline).Warn("private relay deadline passed, falling back to public broadcast")
+	return s.broadcastPublic(ctx, tx)
+}
+
+func (s *PrivateSubmitter) broadcastPublic(ctx context.Context, tx *etypes.Transaction) (string, error) {
+	endpoint, err := s.public.Broadcast(ctx, tx)
+	if err != nil {
+		return "", fmt.Errorf("public fallback: %w", err)
+	}
+	return endpoint, nil
+}
+
+// waitForInclusion polls for txHash's receipt until the deadline passes.
+// A reverted transaction counts as included; reconciliation deals with it.
+func (s *PrivateSubmitter) waitForInclusion(ctx context.Context, txHash ecommon.Hash) (bool, error) {
+	deadline := time.NewTimer(s.deadline)
+	defer deadline.Stop()
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-deadline.C:
+			return false, nil
+		case <-ticker.C:
+			_, err := s.receipts.TransactionReceipt(ctx, txHash)
+			if err == nil {
+				return true, nil
+			}
+			if !errors.Is(err, ethereum.NotFound) {
+				s.logger.WithContext(ctx).WithError(err).WithField("tx_hash", txHash.Hex()).Warn("failed to poll transaction receipt")
+			}
+		}
+	}
+}
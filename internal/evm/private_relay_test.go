@@ -0,0 +1,162 @@
+package evm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/sirupsen/logrus"
+)
+
+// testRelay answers eth_sendPrivateTransaction like a relay that accepts
+// every transaction.
+func testRelay(t *testing.T) *PrivateRelay {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params []struct {
+				Tx string `json:"tx"`
+			} `json:"params"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil || req.Method != "eth_sendPrivateTransaction" || len(req.Params) != 1 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		tx := new(etypes.Transaction)
+		err = tx.UnmarshalBinary(hexutil.MustDecode(req.Params[0].Tx))
+		if err != nil {
+			http.Error(w, "bad transaction", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result":  tx.Hash(),
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	relay, err := DialPrivateRelay(server.URL)
+	if err != nil {
+		t.Fatalf("failed to dial relay: %v", err)
+	}
+	t.Cleanup(relay.client.Close)
+	return relay
+}
+
+// testReceipts reports a receipt for every transaction once included is set.
+type testReceipts struct {
+	included atomic.Bool
+}
+
+func (r *testReceipts) TransactionReceipt(_ context.Context, _ ecommon.Hash) (*etypes.Receipt, error) {
+	if !r.included.Load() {
+		return nil, ethereum.NotFound
+	}
+	return &etypes.Receipt{Status: etypes.ReceiptStatusSuccessful}, nil
+}
+
+type testBroadcaster struct {
+	sent []ecommon.Hash
+}
+
+func (b *testBroadcaster) Broadcast(_ context.Context, tx *etypes.Transaction) (string, error) {
+	b.sent = append(b.sent, tx.Hash())
+	return "public", nil
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) RecordTransition(string, string, int64) {}
+func (nopMetrics) ObserveExecute(time.Duration, string)   {}
+func (nopMetrics) ObserveKeysign(time.Duration, string)   {}
+func (nopMetrics) RecordKeysignFailure(string)            {}
+func (nopMetrics) RecordBroadcastError(string)            {}
+func (nopMetrics) RecordBroadcast(string, string)         {}
+func (nopMetrics) SetPendingQueue(int64, time.Duration)   {}
+
+func newTestSubmitter(t *testing.T, receipts ReceiptClient, public TxBroadcaster, fallback bool) *PrivateSubmitter {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	s := NewPrivateSubmitter(testRelay(t), receipts, public, 50*time.Millisecond, fallback, nopMetrics{}, logger)
+	s.pollInterval = 5 * time.Millisecond
+	return s
+}
+
+func testTx() *etypes.Transaction {
+	to := ecommon.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	return etypes.NewTx(&etypes.LegacyTx{
+		Nonce:    7,
+		To:       &to,
+		Value:    big.NewInt(1),
+		Gas:      21000,
+		GasPrice: big.NewInt(1),
+	})
+}
+
+func TestPrivateSubmitterIncluded(t *testing.T) {
+	receipts := &testReceipts{}
+	receipts.included.Store(true)
+	public := &testBroadcaster{}
+	s := newTestSubmitter(t, receipts, public, true)
+
+	endpoint, err := s.Broadcast(context.Background(), testTx())
+	if err != nil {
+		t.Fatalf("Broadcast: %v", err)
+	}
+	if endpoint != s.relay.name {
+		t.Errorf("endpoint = %q, want relay %q", endpoint, s.relay.name)
+	}
+	if len(public.sent) != 0 {
+		t.Errorf("public broadcaster got %d transactions, want none", len(public.sent))
+	}
+}
+
+func TestPrivateSubmitterDeadlineFallsBack(t *testing.T) {
+	public := &testBroadcaster{}
+	s := newTestSubmitter(t, &testReceipts{}, public, true)
+	tx := testTx()
+
+	endpoint, err := s.Broadcast(context.Background(), tx)
+	if err != nil {
+		t.Fatalf("Broadcast: %v", err)
+	}
+	if endpoint != "public" {
+		t.Errorf("endpoint = %q, want public", endpoint)
+	}
+	if len(public.sent) != 1 || public.sent[0] != tx.Hash() {
+		t.Errorf("public broadcaster got %v, want the same transaction once", public.sent)
+	}
+}
+
+func TestPrivateSubmitterDeadlineWithoutFallback(t *testing.T) {
+	public := &testBroadcaster{}
+	s := newTestSubmitter(t, &testReceipts{}, public, false)
+
+	_, err := s.Broadcast(context.Background(), testTx())
+	if err == nil {
+		t.Fatal("Broadcast succeeded, want deadline error")
+	}
+	if len(public.sent) != 0 {
+		t.Errorf("public broadcaster got %d transactions, want none", len(public.sent))
+	}
+}
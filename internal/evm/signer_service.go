@@ -4,74 +4,209 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"math/big"
+	"time"
 
 	ecommon "github.com/ethereum/go-ethereum/common"
 	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 	"github.com/vultisig/mobile-tss-lib/tss"
 	"github.com/vultisig/recipes/chain/evm/ethereum"
-	"github.com/vultisig/recipes/engine"
 	"github.com/vultisig/recipes/sdk/evm"
-	"github.com/vultisig/verifier/plugin/keysign"
-	"github.com/vultisig/verifier/plugin/tx_indexer"
 	"github.com/vultisig/verifier/plugin/tx_indexer/pkg/storage"
 	"github.com/vultisig/verifier/types"
 	rcommon "github.com/vultisig/vultisig-go/common"
+
+	"github.com/vultisig/app-developer/internal/logging"
+	"github.com/vultisig/app-developer/internal/metrics"
+	"github.com/vultisig/app-developer/internal/tracing"
 )
 
+// KeysignSessionStore is satisfied by *db.PostgresBackend.
+type KeysignSessionStore interface {
+	RecordKeysignSession(ctx context.Context, sessionID string, policyID, txIndexerID uuid.UUID, correlationID string) error
+}
+
+// TxIndexer tracks the transactions sent to keysign. It is satisfied by
+// *tx_indexer.Service.
+type TxIndexer interface {
+	CreateTx(ctx context.Context, req storage.CreateTxDto) (storage.Tx, error)
+}
+
+// KeysignParties is satisfied by *PartyResolver.
+type KeysignParties interface {
+	Resolve(publicKey, pluginID string) ([]string, error)
+	Forget(publicKey string)
+}
+
+// KeysignSigner is satisfied by *keysign.Signer.
+type KeysignSigner interface {
+	Sign(ctx context.Context, req types.PluginKeysignRequest) (map[string]tss.KeysignResponse, error)
+}
+
+// KeysignSigners is satisfied by *SignerPool.
+type KeysignSigners interface {
+	For(partyPrefixes []string) KeysignSigner
+}
+
 type SignerService struct {
-	sdk       *evm.SDK
-	chain     rcommon.Chain
-	signer    *keysign.Signer
-	txIndexer *tx_indexer.Service
+	sdk         *evm.SDK
+	chain       rcommon.Chain
+	signers     KeysignSigners
+	parties     KeysignParties
+	txIndexer   TxIndexer
+	metrics     metrics.ListingFeeMetrics
+	nonces      *NonceManager
+	simulator   *Simulator
+	broadcaster TxBroadcaster
+	diagnoser   *KeysignDiagnoser
+	sessions    KeysignSessionStore
+	// partyTimeout bounds one keysign, from emitting to the last signature.
+	partyTimeout time.Duration
+	logger       *logrus.Entry
 }
 
 func NewSignerService(
 	sdk *evm.SDK,
 	chain rcommon.Chain,
-	signer *keysign.Signer,
-	txIndexer *tx_indexer.Service,
+	signers KeysignSigners,
+	parties KeysignParties,
+	txIndexer TxIndexer,
+	feeMetrics metrics.ListingFeeMetrics,
+	nonces *NonceManager,
+	simulator *Simulator,
+	broadcaster TxBroadcaster,
+	diagnoser *KeysignDiagnoser,
+	sessions KeysignSessionStore,
+	partyTimeout time.Duration,
+	logger *logrus.Logger,
 ) *SignerService {
 	return &SignerService{
-		sdk:       sdk,
-		chain:     chain,
-		signer:    signer,
-		txIndexer: txIndexer,
+		sdk:          sdk,
+		chain:        chain,
+		signers:      signers,
+		parties:      parties,
+		txIndexer:    txIndexer,
+		metrics:      feeMetrics,
+		nonces:       nonces,
+		simulator:    simulator,
+		broadcaster:  broadcaster,
+		diagnoser:    diagnoser,
+		sessions:     sessions,
+		partyTimeout: partyTimeout,
+		logger:       logger.WithField("pkg", "evm.SignerService"),
+	}
+}
+
+// buildAttempts bounds how often BuildTransfer rebuilds a transfer whose nonce
+// moved because the pending nonce changed while it was being built.
+const buildAttempts = 3
+
+// BuildTransfer builds an unsigned ERC-20 transfer for policyID's fee using a
+// nonce reserved from the nonce manager, and returns it with that nonce.
+func (s *SignerService) BuildTransfer(
+	ctx context.Context,
+	policyID uuid.UUID,
+	from, to, token ecommon.Address,
+	amount *big.Int,
+) ([]byte, uint64, error) {
+	for attempt := 1; ; attempt++ {
+		nonce, pending, err := s.nonces.Reserve(ctx, policyID, from)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to reserve nonce: %w", err)
+		}
+
+		// The SDK adds the offset to the pending nonce it fetches itself.
+		unsignedTx, err := s.sdk.MakeTxTransferERC20(ctx, from, to, token, amount, nonce-pending)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to build ERC-20 transfer: %w", err)
+		}
+
+		built, err := UnsignedTxNonce(unsignedTx)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read transfer nonce: %w", err)
+		}
+		if built == nonce {
+			return unsignedTx, nonce, nil
+		}
+		if attempt == buildAttempts {
+			return nil, 0, fmt.Errorf("pending nonce kept moving: reserved %d, built %d", nonce, built)
+		}
 	}
 }
 
-func (s *SignerService) SignAndBroadcast(
+// Simulate dry-runs the transfer BuildTransfer would build for a fee, without
+// reserving a nonce, signing or broadcasting.
+func (s *SignerService) Simulate(
+	ctx context.Context,
+	policy types.PluginPolicy,
+	from, to, token ecommon.Address,
+	amount *big.Int,
+) (*SimulationResult, error) {
+	return s.simulator.Simulate(ctx, policy, from, to, token, amount)
+}
+
+// Sign evaluates unsignedTx, sent from from, against the policy, signs it
+// through keysign and returns the signed transaction. Nothing is broadcast; the
+// caller persists the result so it can be broadcast, and rebroadcast, without
+// another keysign. The evaluation report is returned whenever the recipe was
+// evaluated, including when it denied the transaction. Failures are returned
+// as *KeysignFailure.
+func (s *SignerService) Sign(
 	ctx context.Context,
 	fromChain rcommon.Chain,
 	policy types.PluginPolicy,
+	from ecommon.Address,
 	unsignedTx []byte,
-) (string, error) {
-	recipe, err := policy.GetRecipe()
+) (*etypes.Transaction, *EvaluationReport, error) {
+	report, err := EvaluatePolicy(policy, fromChain, from, unsignedTx)
 	if err != nil {
-		return "", fmt.Errorf("failed to unpack recipe: %w", err)
+		return nil, nil, newKeysignFailure(KeysignFailureOther, KeysignStageEvaluate, err)
 	}
-
-	eng, err := engine.NewEngine()
-	if err != nil {
-		return "", fmt.Errorf("failed to create engine: %w", err)
+	if !report.Allowed {
+		return nil, report, newKeysignFailure(KeysignFailureRecipeDenied, KeysignStageEvaluate, fmt.Errorf("failed to evaluate tx: %s", report.Error))
 	}
-	_, err = eng.Evaluate(recipe, fromChain, unsignedTx)
+
+	policyAttr := tracing.AttrPolicyID.String(policy.ID.String())
+
+	buildCtx, buildSpan := tracing.Start(ctx, "evm.buildKeysignRequest", policyAttr)
+	keysignRequest, err := s.buildKeysignRequest(buildCtx, policy, unsignedTx)
+	tracing.End(buildSpan, err)
 	if err != nil {
-		return "", fmt.Errorf("failed to evaluate tx: %w", err)
+		return nil, report, newKeysignFailure(KeysignFailureOther, KeysignStagePrepare, fmt.Errorf("failed to build keysign request: %w", err))
 	}
 
-	keysignRequest, err := s.buildKeysignRequest(ctx, policy, unsignedTx)
+	partyPrefixes, err := s.parties.Resolve(policy.PublicKey, policy.PluginID.String())
 	if err != nil {
-		return "", fmt.Errorf("failed to build keysign request: %w", err)
+		s.metrics.RecordKeysignFailure(KeysignFailurePartiesUnresolved)
+		return nil, report, newKeysignFailure(KeysignFailurePartiesUnresolved, KeysignStagePrepare, fmt.Errorf("failed to resolve signing parties: %w", err))
 	}
 
-	signatures, err := s.signer.Sign(ctx, keysignRequest)
+	session := &keysignSession{}
+	keysignStart := time.Now()
+	keysignCtx, cancel := context.WithTimeoutCause(context.WithValue(ctx, keysignSessionKey{}, session), s.partyTimeout, errPartyTimeout)
+	defer cancel()
+	signCtx, signSpan := tracing.Start(keysignCtx, "keysign.Signer.Sign", policyAttr)
+	signatures, err := s.signers.For(partyPrefixes).Sign(signCtx, keysignRequest)
+	tracing.End(signSpan, err)
+	s.recordSession(ctx, policy, session, keysignRequest)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign transaction: %w", err)
+		s.metrics.ObserveKeysign(time.Since(keysignStart), metrics.ResultError)
+		// The vault may have been reshared to other signers.
+		s.parties.Forget(policy.PublicKey)
+		timedOut := errors.Is(context.Cause(keysignCtx), errPartyTimeout)
+		failure := s.diagnoser.diagnose(session, partyPrefixes, timedOut, fmt.Errorf("failed to sign transaction: %w", err))
+		s.metrics.RecordKeysignFailure(failure.Category)
+		return nil, report, failure
 	}
+	s.metrics.ObserveKeysign(time.Since(keysignStart), metrics.ResultSuccess)
 
 	if len(signatures) != 1 {
-		return "", fmt.Errorf("expected 1 signature, got %d", len(signatures))
+		s.metrics.RecordKeysignFailure(KeysignFailureSignatureMismatch)
+		return nil, report, newKeysignFailure(KeysignFailureSignatureMismatch, KeysignStageSign, fmt.Errorf("expected 1 signature, got %d", len(signatures)))
 	}
 
 	var signature tss.KeysignResponse
@@ -79,12 +214,99 @@ func (s *SignerService) SignAndBroadcast(
 		signature = sig
 	}
 
-	tx, err := s.broadcast(ctx, unsignedTx, signature)
+	signedTx, err := s.signedTx(unsignedTx, signature)
 	if err != nil {
-		return "", fmt.Errorf("failed to broadcast transaction: %w", err)
+		return nil, report, newKeysignFailure(KeysignFailureOther, KeysignStageAssemble, fmt.Errorf("failed to assemble signed tx: %w", err))
+	}
+	return signedTx, report, nil
+}
+
+// Broadcast sends a raw signed transaction, as returned by Sign and encoded
+// with MarshalBinary, and returns its hash and the endpoint that accepted it.
+// Sending the same bytes again is safe: nodes that already have the
+// transaction accept it as known.
+func (s *SignerService) Broadcast(ctx context.Context, policy types.PluginPolicy, rawTx []byte) (string, string, error) {
+	tx := new(etypes.Transaction)
+	err := tx.UnmarshalBinary(rawTx)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode signed tx: %w", err)
 	}
 
-	return tx.Hash().Hex(), nil
+	broadcastCtx, broadcastSpan := tracing.Start(ctx, "evm.broadcast", tracing.AttrPolicyID.String(policy.ID.String()))
+	endpoint, err := s.broadcaster.Broadcast(broadcastCtx, tx)
+	tracing.End(broadcastSpan, err)
+	if err != nil {
+		s.metrics.RecordBroadcastError(ClassifyBroadcastError(err))
+		return "", "", fmt.Errorf("failed to broadcast transaction: %w", err)
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"policy_id": policy.ID,
+		"tx_hash":   tx.Hash().Hex(),
+		"endpoint":  endpoint,
+	}).Info("transaction accepted by broadcast endpoint")
+	return tx.Hash().Hex(), endpoint, nil
+}
+
+// ForgetParties drops the cached signing parties of the vault with publicKey,
+// e.g. once it has been reshared.
+func (s *SignerService) ForgetParties(publicKey string) {
+	s.parties.Forget(publicKey)
+}
+
+// recordSession stores which session signed which tx_indexer record, keyed by
+// the correlation ID of ctx. Failing to store it does not hold up the payment.
+func (s *SignerService) recordSession(ctx context.Context, policy types.PluginPolicy, session *keysignSession, req types.PluginKeysignRequest) {
+	if session.id == "" || len(req.Messages) == 0 {
+		return
+	}
+	txIndexerID := req.Messages[0].TxIndexerID
+	logger := s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"policy_id":     policy.ID,
+		"session_id":    session.id,
+		"tx_indexer_id": txIndexerID,
+	})
+
+	id, err := uuid.Parse(txIndexerID)
+	if err != nil {
+		logger.WithError(err).Warn("keysign request has an invalid tx_indexer ID")
+		return
+	}
+	correlationID := logging.CorrelationID(ctx)
+	if correlationID == "" {
+		correlationID = policy.ID.String()
+	}
+	err = s.sessions.RecordKeysignSession(ctx, session.id, policy.ID, id, correlationID)
+	if err != nil {
+		logger.WithError(err).Error("failed to record keysign session")
+		return
+	}
+	logger.Info("keysign session recorded")
+}
+
+// signedTx assembles the signed transaction exactly as sdk.Send does, so its
+// hash can be recorded before anything reaches the network.
+func (s *SignerService) signedTx(unsignedTx []byte, signature tss.KeysignResponse) (*etypes.Transaction, error) {
+	txData, err := ethereum.DecodeUnsignedPayload(unsignedTx)
+	if err != nil {
+		return nil, fmt.Errorf("ethereum.DecodeUnsignedPayload: %w", err)
+	}
+
+	evmID, err := s.chain.EvmID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get EVM ID: %w", err)
+	}
+
+	var sig []byte
+	sig = append(sig, ecommon.Hex2Bytes(signature.R)...)
+	sig = append(sig, ecommon.Hex2Bytes(signature.S)...)
+	sig = append(sig, ecommon.Hex2Bytes(signature.RecoveryID)...)
+
+	tx, err := etypes.NewTx(txData).WithSignature(etypes.LatestSignerForChainID(evmID), sig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply signature: %w", err)
+	}
+	return tx, nil
 }
 
 func (s *SignerService) buildKeysignRequest(
@@ -139,22 +361,3 @@ func (s *SignerService) buildKeysignRequest(
 		Transaction: base64.StdEncoding.EncodeToString(unsignedTx),
 	}, nil
 }
-
-func (s *SignerService) broadcast(
-	ctx context.Context,
-	unsignedTx []byte,
-	signature tss.KeysignResponse,
-) (*etypes.Transaction, error) {
-	tx, err := s.sdk.Send(
-		ctx,
-		unsignedTx,
-		ecommon.Hex2Bytes(signature.R),
-		ecommon.Hex2Bytes(signature.S),
-		ecommon.Hex2Bytes(signature.RecoveryID),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("s.sdk.Send: %w", err)
-	}
-
-	return tx, nil
-}
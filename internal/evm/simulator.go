@@ -0,0 +1,120 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	ecommon "github.com/ethereum/go-ethereum/common"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	rethereum "github.com/vultisig/recipes/chain/evm/ethereum"
+	"github.com/vultisig/recipes/sdk/evm"
+	"github.com/vultisig/verifier/types"
+	rcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// SimulationClient is satisfied by *ethclient.Client.
+type SimulationClient interface {
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+}
+
+// SimulationResult is what a fee payment would do, without it being signed
+// or sent. Failures of individual steps are reported here rather than as
+// errors so the caller sees how far the payment would get.
+type SimulationResult struct {
+	Nonce    uint64
+	GasLimit uint64
+	// BuildError is set when the transfer could not be built, which includes
+	// the SDK's own gas estimation failing.
+	BuildError string
+	// PolicyAllowed reports whether the policy's recipe permits the transfer.
+	PolicyAllowed bool
+	PolicyError   string
+	Evaluation    *EvaluationReport
+	// CallSucceeded reports whether eth_call of the transfer did not revert.
+	CallSucceeded bool
+	CallError     string
+	// TransferReturned is the decoded bool returned by the token's transfer.
+	TransferReturned *bool
+	GasEstimate      uint64
+	EstimateError    string
+}
+
+// Simulator dry-runs fee payments against the configured RPC, which may be a
+// fork of mainnet.
+type Simulator struct {
+	sdk    *evm.SDK
+	chain  rcommon.Chain
+	client SimulationClient
+}
+
+func NewSimulator(sdk *evm.SDK, chain rcommon.Chain, client SimulationClient) *Simulator {
+	return &Simulator{
+		sdk:    sdk,
+		chain:  chain,
+		client: client,
+	}
+}
+
+// Simulate builds the ERC-20 transfer a fee would be paid with, evaluates it
+// against the policy's recipe and runs it through eth_call and
+// eth_estimateGas. Nothing is signed, broadcast or reserved.
+func (s *Simulator) Simulate(
+	ctx context.Context,
+	policy types.PluginPolicy,
+	from, to, token ecommon.Address,
+	amount *big.Int,
+) (*SimulationResult, error) {
+	res := &SimulationResult{}
+
+	unsignedTx, err := s.sdk.MakeTxTransferERC20(ctx, from, to, token, amount, 0)
+	if err != nil {
+		res.BuildError = err.Error()
+		return res, nil
+	}
+	txData, err := rethereum.DecodeUnsignedPayload(unsignedTx)
+	if err != nil {
+		return nil, fmt.Errorf("ethereum.DecodeUnsignedPayload: %w", err)
+	}
+	tx := etypes.NewTx(txData)
+	res.Nonce = tx.Nonce()
+	res.GasLimit = tx.Gas()
+
+	report, err := EvaluatePolicy(policy, s.chain, from, unsignedTx)
+	if err != nil {
+		return nil, err
+	}
+	res.Evaluation = report
+	res.PolicyAllowed = report.Allowed
+	res.PolicyError = report.Error
+
+	msg := ethereum.CallMsg{
+		From:  from,
+		To:    tx.To(),
+		Value: tx.Value(),
+		Data:  tx.Data(),
+	}
+
+	out, err := s.client.CallContract(ctx, msg, nil)
+	if err != nil {
+		res.CallError = err.Error()
+	} else {
+		res.CallSucceeded = true
+		// Tokens that follow ERC-20 return a single ABI-encoded bool.
+		if len(out) == 32 {
+			ok := new(big.Int).SetBytes(out).Sign() != 0
+			res.TransferReturned = &ok
+		}
+	}
+
+	gas, err := s.client.EstimateGas(ctx, msg)
+	if err != nil {
+		res.EstimateError = err.Error()
+	} else {
+		res.GasEstimate = gas
+	}
+
+	return res, nil
+}
@@ -0,0 +1,58 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var erc20TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// ReceiptClient is satisfied by *ethclient.Client.
+type ReceiptClient interface {
+	TransactionReceipt(ctx context.Context, txHash ecommon.Hash) (*etypes.Receipt, error)
+}
+
+// VerifyERC20Transfer checks that txHash succeeded on-chain and transferred at
+// least amount of token from the payer to destination. It returns the
+// inclusion block number.
+func VerifyERC20Transfer(
+	ctx context.Context,
+	client ReceiptClient,
+	txHash ecommon.Hash,
+	token ecommon.Address,
+	from ecommon.Address,
+	destination ecommon.Address,
+	amount *big.Int,
+) (int64, error) {
+	receipt, err := client.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get transaction receipt: %w", err)
+	}
+	if receipt.Status != etypes.ReceiptStatusSuccessful {
+		return 0, fmt.Errorf("transaction %s reverted", txHash.Hex())
+	}
+
+	transferred := new(big.Int)
+	for _, log := range receipt.Logs {
+		if log.Address != token || len(log.Topics) != 3 || log.Topics[0] != erc20TransferTopic {
+			continue
+		}
+		if ecommon.BytesToAddress(log.Topics[1].Bytes()) != from ||
+			ecommon.BytesToAddress(log.Topics[2].Bytes()) != destination {
+			continue
+		}
+		transferred.Add(transferred, new(big.Int).SetBytes(log.Data))
+	}
+
+	if transferred.Cmp(amount) < 0 {
+		return 0, fmt.Errorf("transaction %s transferred %s from %s to %s, expected at least %s",
+			txHash.Hex(), transferred, from.Hex(), destination.Hex(), amount)
+	}
+
+	return receipt.BlockNumber.Int64(), nil
+}
@@ -0,0 +1,50 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/vultisig/verifier/plugin/keysign"
+	"github.com/vultisig/verifier/plugin/libhttp"
+	"github.com/vultisig/verifier/types"
+
+	"github.com/vultisig/app-developer/internal/logging"
+)
+
+type verifierEmitter struct {
+	endpoint string
+	token    string
+}
+
+// NewVerifierEmitter behaves like keysign.NewVerifierEmitter and also forwards
+// the correlation ID of ctx, so the verifier's logs for a keysign join up with
+// the fee that asked for it.
+func NewVerifierEmitter(url, token string) keysign.Emitter {
+	return verifierEmitter{
+		endpoint: url + "/plugin-signer/sign",
+		token:    token,
+	}
+}
+
+func (e verifierEmitter) Sign(ctx context.Context, req types.PluginKeysignRequest) error {
+	headers := map[string]string{
+		"Authorization": "Bearer " + e.token,
+		"Content-Type":  "application/json",
+	}
+	correlationID := logging.CorrelationID(ctx)
+	if correlationID != "" {
+		headers[logging.HeaderCorrelationID] = correlationID
+	}
+
+	_, err := libhttp.Call[string](ctx, http.MethodPost, e.endpoint, headers, req, nil)
+	if err != nil {
+		var httpErr *libhttp.HTTPError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusLocked {
+			return keysign.ErrPluginPaused
+		}
+		return fmt.Errorf("failed to make API call: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func PostgresCheck(pool *pgxpool.Pool) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		err := pool.Ping(ctx)
+		if err != nil {
+			return fmt.Errorf("postgres ping failed: %w", err)
+		}
+		return nil
+	})
+}
+
+// Pinger is satisfied by *asynq.Client and *asynq.Inspector.
+type Pinger interface {
+	Ping() error
+}
+
+func RedisCheck(p Pinger) Checker {
+	return CheckerFunc(func(_ context.Context) error {
+		err := p.Ping()
+		if err != nil {
+			return fmt.Errorf("redis ping failed: %w", err)
+		}
+		return nil
+	})
+}
+
+// HeaderReader is satisfied by *ethclient.Client.
+type HeaderReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// RPCCheck fails when the node is unreachable or its latest block is older
+// than maxLag. A node that stopped advancing or is syncing behind the network
+// serves an old head either way.
+func RPCCheck(client HeaderReader, maxLag time.Duration) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		head, err := client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("rpc HeaderByNumber failed: %w", err)
+		}
+
+		lag := time.Since(time.Unix(int64(head.Time), 0))
+		if maxLag > 0 && lag > maxLag {
+			return fmt.Errorf("rpc head at block %d is %s behind", head.Number.Uint64(), lag.Round(time.Second))
+		}
+		return nil
+	})
+}
+
+// VaultStorage is satisfied by vault.Storage.
+type VaultStorage interface {
+	Exist(fileName string) (bool, error)
+}
+
+func VaultStorageCheck(storage VaultStorage) Checker {
+	return CheckerFunc(func(_ context.Context) error {
+		_, err := storage.Exist("readyz-probe")
+		if err != nil {
+			return fmt.Errorf("vault storage unreachable: %w", err)
+		}
+		return nil
+	})
+}
+
+// minHeartbeatAge keeps a misconfigured zero interval from failing liveness
+// between any two beats.
+const minHeartbeatAge = time.Minute
+
+// Heartbeat tracks the last completed cycle of a background loop.
+type Heartbeat struct {
+	maxAge time.Duration
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// NewHeartbeat fails checks after maxAge without a beat, but never sooner than
+// minHeartbeatAge.
+func NewHeartbeat(maxAge time.Duration) *Heartbeat {
+	if maxAge < minHeartbeatAge {
+		maxAge = minHeartbeatAge
+	}
+	return &Heartbeat{
+		maxAge: maxAge,
+		last:   time.Now(),
+	}
+}
+
+func (h *Heartbeat) Beat() {
+	h.mu.Lock()
+	h.last = time.Now()
+	h.mu.Unlock()
+}
+
+func (h *Heartbeat) Check(_ context.Context) error {
+	h.mu.Lock()
+	last := h.last
+	h.mu.Unlock()
+
+	age := time.Since(last)
+	if age > h.maxAge {
+		return fmt.Errorf("no completed processing cycle for %s", age.Round(time.Second))
+	}
+	return nil
+}
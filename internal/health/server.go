@@ -2,28 +2,79 @@ package health
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+const checkTimeout = 5 * time.Second
+
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
 type Server struct {
-	port   int
-	server *http.Server
+	port      int
+	server    *http.Server
+	readiness map[string]Checker
+	liveness  Checker
 }
 
 func New(port int) *Server {
 	return &Server{
-		port: port,
+		port:      port,
+		readiness: make(map[string]Checker),
 	}
 }
 
+// AddReadinessCheck registers a dependency that must be reachable for /readyz to pass.
+func (s *Server) AddReadinessCheck(name string, checker Checker) {
+	s.readiness[name] = checker
+}
+
+// SetLivenessCheck makes /healthz fail when the checker fails.
+func (s *Server) SetLivenessCheck(checker Checker) {
+	s.liveness = checker
+}
+
 func (s *Server) Start(ctx context.Context, logger *logrus.Logger) error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if s.liveness != nil {
+			checkCtx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+			defer cancel()
+
+			err := s.liveness.Check(checkCtx)
+			if err != nil {
+				logger.WithError(err).Warn("liveness check failed")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(err.Error()))
+				return
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("OK"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		failures := s.runReadinessChecks(r.Context())
+		if len(failures) > 0 {
+			logger.WithField("failures", failures).Warn("readiness check failed")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_ = json.NewEncoder(w).Encode(map[string]any{"failures": failures})
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("OK"))
 	})
@@ -56,3 +107,29 @@ func (s *Server) Start(ctx context.Context, logger *logrus.Logger) error {
 
 	return nil
 }
+
+func (s *Server) runReadinessChecks(ctx context.Context) map[string]string {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failures = make(map[string]string)
+	)
+	for name, checker := range s.readiness {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := checker.Check(ctx)
+			if err != nil {
+				mu.Lock()
+				failures[name] = err.Error()
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return failures
+}
@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	FieldCorrelationID  = "correlation_id"
+	HeaderCorrelationID = "X-Correlation-ID"
+)
+
+type correlationKey struct{}
+
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// EchoMiddleware attaches a correlation ID to the request context. An
+// incoming X-Correlation-ID header wins; otherwise the policy ID path
+// parameter is used so HTTP logs line up with worker logs for the same fee.
+func EchoMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			id := c.Request().Header.Get(HeaderCorrelationID)
+			if id == "" {
+				id = c.Param("policyId")
+			}
+			if id == "" {
+				id = uuid.NewString()
+			}
+
+			c.Response().Header().Set(HeaderCorrelationID, id)
+			c.SetRequest(c.Request().WithContext(WithCorrelationID(c.Request().Context(), id)))
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Version is set at build time via -ldflags.
+var Version = "dev"
+
+// Config is embedded into each service config, so the variables are LOGLEVEL and LOGFORMAT.
+type Config struct {
+	LogLevel  string `default:"info"`
+	LogFormat string `default:"text"`
+}
+
+func New(cfg Config, service string) (*logrus.Logger, error) {
+	logger := logrus.New()
+
+	level, err := logrus.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", cfg.LogLevel, err)
+	}
+	logger.SetLevel(level)
+
+	switch cfg.LogFormat {
+	case "json":
+		logger.SetFormatter(&logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano})
+	case "text", "":
+		logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	default:
+		return nil, fmt.Errorf("invalid log format %q", cfg.LogFormat)
+	}
+
+	logger.AddHook(&fieldsHook{fields: logrus.Fields{
+		"service": service,
+		"version": Version,
+	}})
+	logger.AddHook(correlationHook{})
+
+	return logger, nil
+}
+
+// WithFields returns a logger that shares output, formatter, level and hooks
+// with logger and adds fields to every entry. Use it where a *logrus.Logger
+// is required; logger.WithField(...).Logger drops the field.
+func WithFields(logger *logrus.Logger, fields logrus.Fields) *logrus.Logger {
+	child := &logrus.Logger{
+		Out:          logger.Out,
+		Formatter:    logger.Formatter,
+		ReportCaller: logger.ReportCaller,
+		Level:        logger.GetLevel(),
+		ExitFunc:     logger.ExitFunc,
+		Hooks:        make(logrus.LevelHooks),
+	}
+	for level, hooks := range logger.Hooks {
+		child.Hooks[level] = append([]logrus.Hook(nil), hooks...)
+	}
+	child.AddHook(&fieldsHook{fields: fields})
+	return child
+}
+
+type fieldsHook struct {
+	fields logrus.Fields
+}
+
+func (h *fieldsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *fieldsHook) Fire(entry *logrus.Entry) error {
+	for k, v := range h.fields {
+		_, exists := entry.Data[k]
+		if !exists {
+			entry.Data[k] = v
+		}
+	}
+	return nil
+}
+
+type correlationHook struct{}
+
+func (correlationHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (correlationHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	id := CorrelationID(entry.Context)
+	if id != "" {
+		entry.Data[FieldCorrelationID] = id
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "developer"
+
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+// ListingFeeMetrics is shared by the worker consumer and the signer service.
+type ListingFeeMetrics interface {
+	RecordTransition(from, to string, count int64)
+	ObserveExecute(duration time.Duration, result string)
+	ObserveKeysign(duration time.Duration, result string)
+	RecordKeysignFailure(category string)
+	RecordBroadcastError(class string)
+	RecordBroadcast(endpoint, result string)
+	SetPendingQueue(depth int64, oldestAge time.Duration)
+}
+
+type listingFeeMetrics struct {
+	transitions      *prometheus.CounterVec
+	executeDuration  *prometheus.HistogramVec
+	keysignDuration  *prometheus.HistogramVec
+	keysignFailures  *prometheus.CounterVec
+	broadcastErrors  *prometheus.CounterVec
+	broadcasts       *prometheus.CounterVec
+	pendingDepth     prometheus.Gauge
+	oldestPendingAge prometheus.Gauge
+}
+
+func NewListingFeeMetrics() ListingFeeMetrics {
+	return &listingFeeMetrics{
+		transitions: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "listing_fee",
+			Name:      "transitions_total",
+			Help:      "Listing fee status transitions",
+		}, []string{"from", "to"}),
+		executeDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "listing_fee",
+			Name:      "execute_duration_seconds",
+			Help:      "Time spent executing a pending listing fee",
+			Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600},
+		}, []string{"result"}),
+		keysignDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "listing_fee",
+			Name:      "keysign_duration_seconds",
+			Help:      "Time spent waiting for the TSS keysign session",
+			Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600},
+		}, []string{"result"}),
+		keysignFailures: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "listing_fee",
+			Name:      "keysign_failures_total",
+			Help:      "Keysign failures by category",
+		}, []string{"category"}),
+		broadcastErrors: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "listing_fee",
+			Name:      "broadcast_errors_total",
+			Help:      "Transaction broadcast errors by class",
+		}, []string{"class"}),
+		broadcasts: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "listing_fee",
+			Name:      "broadcasts_total",
+			Help:      "Transaction sends by RPC endpoint and result",
+		}, []string{"endpoint", "result"}),
+		pendingDepth: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "listing_fee",
+			Name:      "pending",
+			Help:      "Number of listing fees in pending status",
+		}),
+		oldestPendingAge: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "listing_fee",
+			Name:      "oldest_pending_age_seconds",
+			Help:      "Age of the oldest pending listing fee",
+		}),
+	}
+}
+
+func (m *listingFeeMetrics) RecordTransition(from, to string, count int64) {
+	m.transitions.WithLabelValues(from, to).Add(float64(count))
+}
+
+func (m *listingFeeMetrics) ObserveExecute(duration time.Duration, result string) {
+	m.executeDuration.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+func (m *listingFeeMetrics) ObserveKeysign(duration time.Duration, result string) {
+	m.keysignDuration.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+func (m *listingFeeMetrics) RecordKeysignFailure(category string) {
+	m.keysignFailures.WithLabelValues(category).Inc()
+}
+
+func (m *listingFeeMetrics) RecordBroadcastError(class string) {
+	m.broadcastErrors.WithLabelValues(class).Inc()
+}
+
+func (m *listingFeeMetrics) RecordBroadcast(endpoint, result string) {
+	m.broadcasts.WithLabelValues(endpoint, result).Inc()
+}
+
+func (m *listingFeeMetrics) SetPendingQueue(depth int64, oldestAge time.Duration) {
+	m.pendingDepth.Set(float64(depth))
+	m.oldestPendingAge.Set(oldestAge.Seconds())
+}
+
+type nilListingFeeMetrics struct{}
+
+func NewNilListingFeeMetrics() ListingFeeMetrics {
+	return nilListingFeeMetrics{}
+}
+
+func (nilListingFeeMetrics) RecordTransition(string, string, int64) {}
+func (nilListingFeeMetrics) ObserveExecute(time.Duration, string)   {}
+func (nilListingFeeMetrics) ObserveKeysign(time.Duration, string)   {}
+func (nilListingFeeMetrics) RecordKeysignFailure(string)            {}
+func (nilListingFeeMetrics) RecordBroadcastError(string)            {}
+func (nilListingFeeMetrics) RecordBroadcast(string, string)         {}
+func (nilListingFeeMetrics) SetPendingQueue(int64, time.Duration)   {}
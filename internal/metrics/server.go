@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+type Config struct {
+	Enabled bool `default:"false"`
+	Port    int  `default:"8088"`
+}
+
+type Server struct {
+	port   int
+	server *http.Server
+}
+
+func New(port int) *Server {
+	return &Server{
+		port: port,
+	}
+}
+
+func (s *Server) Start(ctx context.Context, logger *logrus.Logger) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	s.server = &http.Server{
+		Addr:         fmt.Sprintf(":%d", s.port),
+		Handler:      mux,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  15 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		shutdownErr := s.server.Shutdown(shutdownCtx)
+		if shutdownErr != nil {
+			logger.Errorf("metrics server shutdown error: %v", shutdownErr)
+		}
+	}()
+
+	logger.Infof("metrics server listening on :%d", s.port)
+
+	err := s.server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("metrics server failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,475 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/app-developer/internal/config"
+	"github.com/vultisig/app-developer/internal/db"
+	"github.com/vultisig/app-developer/internal/evm"
+)
+
+const operatorContextKey = "operator"
+
+var listingFeeStatuses = map[string]bool{
+	"pending":   true,
+	"submitted": true,
+	"paid":      true,
+	"failed":    true,
+	"cancelled": true,
+	"expired":   true,
+}
+
+// AdminStore is satisfied by *db.PostgresBackend.
+type AdminStore interface {
+	ListListingFeesByStatus(ctx context.Context, status string, limit, offset int32) ([]db.ListingFee, error)
+	GetListingFeeByPolicyID(ctx context.Context, policyID uuid.UUID) (*db.ListingFee, error)
+	GetListingFeeBatch(ctx context.Context, leadPolicyID uuid.UUID) ([]db.ListingFee, error)
+	GetListingFeeIntent(ctx context.Context, policyID uuid.UUID) (*db.ListingFeeIntent, error)
+	GetListingFeeEvents(ctx context.Context, policyID uuid.UUID) ([]db.ListingFeeEvent, error)
+	GetListingFeeEvaluations(ctx context.Context, policyID uuid.UUID) ([]db.ListingFeeEvaluation, error)
+	GetAdminAuditRecords(ctx context.Context, policyID uuid.UUID) ([]db.AdminAuditRecord, error)
+	GetTxIndexerRecords(ctx context.Context, policyID uuid.UUID) ([]db.TxIndexerRecord, error)
+	RetryFailedFee(ctx context.Context, action db.AdminAction) error
+	MarkAsPaidManually(ctx context.Context, action db.AdminAction, txHash string, blockNumber int64) error
+	CancelPendingFee(ctx context.Context, action db.AdminAction) error
+}
+
+// AddressDeriver is satisfied by *evm.VaultAddressDeriver.
+type AddressDeriver interface {
+	DeriveAddress(ctx context.Context, publicKey, pluginID string) (ecommon.Address, error)
+}
+
+// AdminAPI exposes operator tooling for inspecting and intervening on listing fees.
+type AdminAPI struct {
+	db        AdminStore
+	receipts  evm.ReceiptClient
+	addresses AddressDeriver
+	pluginID  string
+	feeConfig config.FeeConfig
+	tokens    map[string]string
+	logger    *logrus.Logger
+}
+
+func NewAdminAPI(
+	database AdminStore,
+	receipts evm.ReceiptClient,
+	addresses AddressDeriver,
+	pluginID string,
+	feeConfig config.FeeConfig,
+	adminConfig config.AdminConfig,
+	logger *logrus.Logger,
+) *AdminAPI {
+	return &AdminAPI{
+		db:        database,
+		receipts:  receipts,
+		addresses: addresses,
+		pluginID:  pluginID,
+		feeConfig: feeConfig,
+		tokens:    adminConfig.Tokens,
+		logger:    logger,
+	}
+}
+
+func (a *AdminAPI) RegisterRoutes(e *echo.Echo) {
+	if len(a.tokens) == 0 {
+		a.logger.Warn("no admin tokens configured, admin API disabled")
+		return
+	}
+
+	admin := e.Group("/admin", a.authMiddleware)
+	admin.GET("/listing-fees", a.handleListListingFees)
+	admin.GET("/listing-fees/:policyId", a.handleGetListingFee)
+	admin.GET("/listing-fees/:policyId/transactions", a.handleGetTransactions)
+	admin.GET("/listing-fees/:policyId/signed-tx", a.handleGetSignedTx)
+	admin.GET("/listing-fees/:policyId/evaluations", a.handleGetEvaluations)
+	admin.POST("/listing-fees/:policyId/retry", a.handleRetry)
+	admin.POST("/listing-fees/:policyId/mark-paid", a.handleMarkPaid)
+	admin.POST("/listing-fees/:policyId/cancel", a.handleCancel)
+}
+
+// authMiddleware resolves the bearer token to an operator name, which is
+// recorded on every audit entry.
+func (a *AdminAPI) authMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+		if !ok || token == "" {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "missing bearer token"})
+		}
+
+		for operator, expected := range a.tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+				c.Set(operatorContextKey, operator)
+				return next(c)
+			}
+		}
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+	}
+}
+
+type adminListingFeeResponse struct {
+	listingFeeResponse
+	ID            uuid.UUID  `json:"id"`
+	BlockNumber   *int64     `json:"block_number,omitempty"`
+	Confirmations int        `json:"confirmations"`
+	SubmittedAt   *time.Time `json:"submitted_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (a *AdminAPI) toAdminListingFeeResponse(fee *db.ListingFee) adminListingFeeResponse {
+	return adminListingFeeResponse{
+		listingFeeResponse: toListingFeeResponse(fee, a.feeConfig),
+		ID:                 fee.ID,
+		BlockNumber:        fee.BlockNumber,
+		Confirmations:      fee.Confirmations,
+		SubmittedAt:        fee.SubmittedAt,
+		CreatedAt:          fee.CreatedAt,
+		UpdatedAt:          fee.UpdatedAt,
+	}
+}
+
+func (a *AdminAPI) handleListListingFees(c echo.Context) error {
+	status := c.QueryParam("status")
+	if !listingFeeStatuses[status] {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "status must be one of pending, submitted, paid, failed, cancelled, expired"})
+	}
+
+	limit, err := intQueryParam(c, "limit", 50)
+	if err != nil || limit <= 0 || limit > 500 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "limit must be between 1 and 500"})
+	}
+	offset, err := intQueryParam(c, "offset", 0)
+	if err != nil || offset < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "offset must be non-negative"})
+	}
+
+	fees, err := a.db.ListListingFeesByStatus(c.Request().Context(), status, int32(limit), int32(offset))
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to list listing fees")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+
+	resp := make([]adminListingFeeResponse, len(fees))
+	for i := range fees {
+		resp[i] = a.toAdminListingFeeResponse(&fees[i])
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (a *AdminAPI) handleGetListingFee(c echo.Context) error {
+	fee, err := a.loadFee(c)
+	if err != nil || fee == nil {
+		return err
+	}
+
+	audit, err := a.db.GetAdminAuditRecords(c.Request().Context(), fee.PolicyID)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to get admin audit records")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+
+	events, err := a.db.GetListingFeeEvents(c.Request().Context(), fee.PolicyID)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to get listing fee events")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+
+	batch, err := a.db.GetListingFeeBatch(c.Request().Context(), fee.PolicyID)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to get listing fee batch")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+	batchResp := make([]adminListingFeeResponse, len(batch))
+	for i := range batch {
+		batchResp[i] = a.toAdminListingFeeResponse(&batch[i])
+	}
+
+	evaluations, err := a.db.GetListingFeeEvaluations(c.Request().Context(), fee.PolicyID)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to get listing fee evaluations")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"listing_fee": a.toAdminListingFeeResponse(fee),
+		"audit":       audit,
+		"events":      toListingFeeEventResponses(events),
+		"batch":       batchResp,
+		"evaluations": toEvaluationResponses(evaluations),
+	})
+}
+
+func (a *AdminAPI) handleGetTransactions(c echo.Context) error {
+	policyID, err := uuid.Parse(c.Param("policyId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid policyId"})
+	}
+
+	records, err := a.db.GetTxIndexerRecords(c.Request().Context(), policyID)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to get tx_indexer records")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+	return c.JSON(http.StatusOK, records)
+}
+
+type signedTxResponse struct {
+	PolicyID          uuid.UUID `json:"policy_id"`
+	FromAddress       string    `json:"from_address"`
+	Nonce             uint64    `json:"nonce"`
+	TxHash            string    `json:"tx_hash"`
+	RawTx             string    `json:"raw_tx"`
+	BroadcastAttempts int       `json:"broadcast_attempts"`
+	BroadcastEndpoint *string   `json:"broadcast_endpoint,omitempty"`
+}
+
+// handleGetSignedTx exports the fee's signed payment so an operator can submit
+// the exact same bytes by hand, e.g. with eth_sendRawTransaction.
+func (a *AdminAPI) handleGetSignedTx(c echo.Context) error {
+	policyID, err := uuid.Parse(c.Param("policyId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid policyId"})
+	}
+
+	intent, err := a.db.GetListingFeeIntent(c.Request().Context(), policyID)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to get listing fee intent")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+	if intent == nil || intent.SignedTx == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "no signed transaction for listing fee"})
+	}
+
+	tx := new(etypes.Transaction)
+	err = tx.UnmarshalBinary(intent.SignedTx)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to decode signed tx")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "stored signed transaction is invalid"})
+	}
+
+	return c.JSON(http.StatusOK, signedTxResponse{
+		PolicyID:          policyID,
+		FromAddress:       intent.FromAddress,
+		Nonce:             intent.Nonce,
+		TxHash:            tx.Hash().Hex(),
+		RawTx:             hexutil.Encode(intent.SignedTx),
+		BroadcastAttempts: intent.BroadcastAttempts,
+		BroadcastEndpoint: intent.BroadcastEndpoint,
+	})
+}
+
+type evaluationResponse struct {
+	ID        int64           `json:"id"`
+	Allowed   bool            `json:"allowed"`
+	Report    json.RawMessage `json:"report"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func toEvaluationResponses(evaluations []db.ListingFeeEvaluation) []evaluationResponse {
+	resp := make([]evaluationResponse, len(evaluations))
+	for i, ev := range evaluations {
+		resp[i] = evaluationResponse{
+			ID:        ev.ID,
+			Allowed:   ev.Allowed,
+			Report:    ev.Report,
+			CreatedAt: ev.CreatedAt,
+		}
+	}
+	return resp
+}
+
+// handleGetEvaluations lists every recipe evaluation of the fee's payment,
+// newest first, each with the constraint checks that explain a denial.
+func (a *AdminAPI) handleGetEvaluations(c echo.Context) error {
+	policyID, err := uuid.Parse(c.Param("policyId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid policyId"})
+	}
+
+	evaluations, err := a.db.GetListingFeeEvaluations(c.Request().Context(), policyID)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to get listing fee evaluations")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+	return c.JSON(http.StatusOK, toEvaluationResponses(evaluations))
+}
+
+type adminActionRequest struct {
+	Reason string `json:"reason"`
+	TxHash string `json:"tx_hash"`
+}
+
+func (a *AdminAPI) handleRetry(c echo.Context) error {
+	action, _, err := a.bindAction(c, db.AdminActionRetry)
+	if err != nil || action == nil {
+		return err
+	}
+
+	fee, err := a.loadFee(c)
+	if err != nil || fee == nil {
+		return err
+	}
+	action.Details = map[string]any{
+		"previous_tx_hash":        fee.TxHash,
+		"previous_failure_reason": fee.FailureReason,
+	}
+
+	err = a.db.RetryFailedFee(c.Request().Context(), *action)
+	return a.actionResult(c, *action, err)
+}
+
+func (a *AdminAPI) handleMarkPaid(c echo.Context) error {
+	action, req, err := a.bindAction(c, db.AdminActionMarkPaid)
+	if err != nil || action == nil {
+		return err
+	}
+
+	if len(ecommon.FromHex(req.TxHash)) != ecommon.HashLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "tx_hash must be a 32-byte hex hash"})
+	}
+	txHash := ecommon.HexToHash(req.TxHash)
+
+	fee, err := a.loadFee(c)
+	if err != nil || fee == nil {
+		return err
+	}
+
+	// Only a transfer out of the fee's own vault pays for it.
+	payer, err := a.addresses.DeriveAddress(c.Request().Context(), fee.PublicKey, a.pluginID)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to derive vault address")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to derive vault address"})
+	}
+
+	// A batch lead's payment settles the fees in its batch too, so it has to
+	// cover all of them.
+	batch, err := a.db.GetListingFeeBatch(c.Request().Context(), fee.PolicyID)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to get listing fee batch")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+	amount := new(big.Int).Set(fee.Amount)
+	for _, covered := range batch {
+		if covered.Status == "pending" || covered.Status == "submitted" {
+			amount.Add(amount, covered.Amount)
+		}
+	}
+
+	blockNumber, err := evm.VerifyERC20Transfer(
+		c.Request().Context(),
+		a.receipts,
+		txHash,
+		ecommon.HexToAddress(a.feeConfig.VultTokenAddress),
+		payer,
+		ecommon.HexToAddress(fee.Destination),
+		amount,
+	)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "transaction verification failed: " + err.Error()})
+	}
+
+	action.Details = map[string]any{
+		"tx_hash":         txHash.Hex(),
+		"block_number":    blockNumber,
+		"previous_status": fee.Status,
+	}
+	err = a.db.MarkAsPaidManually(c.Request().Context(), *action, txHash.Hex(), blockNumber)
+	return a.actionResult(c, *action, err)
+}
+
+func (a *AdminAPI) handleCancel(c echo.Context) error {
+	action, _, err := a.bindAction(c, db.AdminActionCancel)
+	if err != nil || action == nil {
+		return err
+	}
+
+	err = a.db.CancelPendingFee(c.Request().Context(), *action)
+	return a.actionResult(c, *action, err)
+}
+
+// bindAction parses the policy ID and request body shared by all mutating
+// endpoints. A nil action with a nil error means a response was already written.
+func (a *AdminAPI) bindAction(c echo.Context, name string) (*db.AdminAction, adminActionRequest, error) {
+	var req adminActionRequest
+
+	policyID, err := uuid.Parse(c.Param("policyId"))
+	if err != nil {
+		return nil, req, c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid policyId"})
+	}
+
+	err = c.Bind(&req)
+	if err != nil {
+		return nil, req, c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return nil, req, c.JSON(http.StatusBadRequest, map[string]string{"error": "reason is required"})
+	}
+
+	operator, _ := c.Get(operatorContextKey).(string)
+	return &db.AdminAction{
+		Operator: operator,
+		Action:   name,
+		PolicyID: policyID,
+		Reason:   req.Reason,
+	}, req, nil
+}
+
+func (a *AdminAPI) actionResult(c echo.Context, action db.AdminAction, err error) error {
+	logger := a.logger.WithContext(c.Request().Context()).WithFields(logrus.Fields{
+		"operator":  action.Operator,
+		"action":    action.Action,
+		"policy_id": action.PolicyID,
+	})
+
+	if errors.Is(err, db.ErrListingFeeStateConflict) || errors.Is(err, db.ErrTxHashInUse) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		logger.WithError(err).Error("admin action failed")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+
+	logger.WithField("reason", action.Reason).Info("admin action applied")
+	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (a *AdminAPI) loadFee(c echo.Context) (*db.ListingFee, error) {
+	policyID, err := uuid.Parse(c.Param("policyId"))
+	if err != nil {
+		return nil, c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid policyId"})
+	}
+
+	fee, err := a.db.GetListingFeeByPolicyID(c.Request().Context(), policyID)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to get listing fee")
+		return nil, c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+	if fee == nil {
+		return nil, c.JSON(http.StatusNotFound, map[string]string{"error": "listing fee not found"})
+	}
+	return fee, nil
+}
+
+func intQueryParam(c echo.Context, name string, def int) (int, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return def, nil
+	}
+	return strconv.Atoi(raw)
+}
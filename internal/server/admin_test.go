@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	ecommon "github.com/ethereum/go-ethereum/common"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/app-developer/internal/config"
+	"github.com/vultisig/app-developer/internal/db"
+)
+
+var (
+	testToken    = ecommon.HexToAddress("0x2b0C1cdB5f3e8D0E1E7B2b5C2aA4f3F2bD6e9A10")
+	testTreasury = ecommon.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	testVault    = ecommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	testStranger = ecommon.HexToAddress("0x2222222222222222222222222222222222222222")
+	testFee      = big.NewInt(1000000)
+)
+
+// testAdminStore holds one fee and the hashes other fees already paid with.
+type testAdminStore struct {
+	AdminStore
+
+	fee     *db.ListingFee
+	batch   []db.ListingFee
+	claimed map[string]uuid.UUID
+
+	paidHash string
+}
+
+func (s *testAdminStore) GetListingFeeByPolicyID(_ context.Context, policyID uuid.UUID) (*db.ListingFee, error) {
+	if s.fee == nil || s.fee.PolicyID != policyID {
+		return nil, nil
+	}
+	return s.fee, nil
+}
+
+func (s *testAdminStore) GetListingFeeBatch(context.Context, uuid.UUID) ([]db.ListingFee, error) {
+	return s.batch, nil
+}
+
+func (s *testAdminStore) MarkAsPaidManually(_ context.Context, action db.AdminAction, txHash string, _ int64) error {
+	if owner, ok := s.claimed[txHash]; ok && owner != action.PolicyID {
+		return db.ErrTxHashInUse
+	}
+	s.paidHash = txHash
+	return nil
+}
+
+type testDeriver struct{}
+
+func (testDeriver) DeriveAddress(context.Context, string, string) (ecommon.Address, error) {
+	return testVault, nil
+}
+
+type testReceipts map[ecommon.Hash]*etypes.Receipt
+
+func (r testReceipts) TransactionReceipt(_ context.Context, hash ecommon.Hash) (*etypes.Receipt, error) {
+	receipt, ok := r[hash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func transferReceipt(from, to ecommon.Address, amount *big.Int) *etypes.Receipt {
+	return &etypes.Receipt{
+		Status:      etypes.ReceiptStatusSuccessful,
+		BlockNumber: big.NewInt(100),
+		Logs: []*etypes.Log{{
+			Address: testToken,
+			Topics: []ecommon.Hash{
+				crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")),
+				ecommon.BytesToHash(from.Bytes()),
+				ecommon.BytesToHash(to.Bytes()),
+			},
+			Data: ecommon.LeftPadBytes(amount.Bytes(), 32),
+		}},
+	}
+}
+
+func TestAdminMarkPaid(t *testing.T) {
+	otherFee := uuid.New()
+	claimedHash := ecommon.HexToHash("0xc1")
+
+	tests := []struct {
+		name    string
+		receipt *etypes.Receipt
+		hash    ecommon.Hash
+		batch   []db.ListingFee
+		want    int
+	}{
+		{
+			name:    "exact payment",
+			receipt: transferReceipt(testVault, testTreasury, testFee),
+			want:    http.StatusOK,
+		},
+		{
+			name:    "hash claimed by another fee",
+			receipt: transferReceipt(testVault, testTreasury, testFee),
+			hash:    claimedHash,
+			want:    http.StatusConflict,
+		},
+		{
+			name:    "amount too low",
+			receipt: transferReceipt(testVault, testTreasury, new(big.Int).Sub(testFee, big.NewInt(1))),
+			want:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:    "batch not covered",
+			receipt: transferReceipt(testVault, testTreasury, testFee),
+			batch:   []db.ListingFee{{PolicyID: uuid.New(), Amount: testFee, Status: "pending"}},
+			want:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:    "wrong recipient",
+			receipt: transferReceipt(testVault, testStranger, testFee),
+			want:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:    "paid from another vault",
+			receipt: transferReceipt(testStranger, testTreasury, testFee),
+			want:    http.StatusUnprocessableEntity,
+		},
+		{
+			name: "reverted",
+			receipt: &etypes.Receipt{
+				Status:      etypes.ReceiptStatusFailed,
+				BlockNumber: big.NewInt(100),
+			},
+			want: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash := tt.hash
+			if hash == (ecommon.Hash{}) {
+				hash = ecommon.HexToHash("0xa1")
+			}
+			store := &testAdminStore{
+				fee: &db.ListingFee{
+					PolicyID:    uuid.New(),
+					PublicKey:   "03aa",
+					Amount:      testFee,
+					Destination: testTreasury.Hex(),
+					Status:      "failed",
+				},
+				batch:   tt.batch,
+				claimed: map[string]uuid.UUID{claimedHash.Hex(): otherFee},
+			}
+
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			api := NewAdminAPI(
+				store,
+				testReceipts{hash: tt.receipt},
+				testDeriver{},
+				"vultisig-developer",
+				config.FeeConfig{VultTokenAddress: testToken.Hex()},
+				config.AdminConfig{Tokens: map[string]string{"alice": "secret"}},
+				logger,
+			)
+			e := echo.New()
+			api.RegisterRoutes(e)
+
+			body := `{"reason":"paid by hand","tx_hash":"` + hash.Hex() + `"}`
+			req := httptest.NewRequest(http.MethodPost, "/admin/listing-fees/"+store.fee.PolicyID.String()+"/mark-paid", strings.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer secret")
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusOK {
+				var resp map[string]string
+				err := json.Unmarshal(rec.Body.Bytes(), &resp)
+				if err != nil || resp["error"] == "" {
+					t.Errorf("response %s carries no error", rec.Body)
+				}
+				if store.paidHash != "" {
+					t.Errorf("fee marked paid with %s", store.paidHash)
+				}
+				return
+			}
+			if store.paidHash != hash.Hex() {
+				t.Errorf("fee marked paid with %q, want %s", store.paidHash, hash.Hex())
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/vultisig/app-developer/internal/config"
+	"github.com/vultisig/app-developer/internal/db"
+)
+
+// callerPublicKeyContextKey holds the vault a developer token is scoped to.
+// It is empty for the verifier, which may query any vault.
+const callerPublicKeyContextKey = "caller_public_key"
+
+const developerTokenIssuer = "app-developer"
+
+// DeveloperAuthStore is satisfied by *db.PostgresBackend.
+type DeveloperAuthStore interface {
+	IsKnownDeveloperPublicKey(ctx context.Context, publicKey string) (bool, error)
+	CreateDeveloperAuthChallenge(ctx context.Context, challenge db.DeveloperAuthChallenge) error
+	ConsumeDeveloperAuthChallenge(ctx context.Context, nonce string) (*db.DeveloperAuthChallenge, error)
+	DeleteExpiredDeveloperAuthChallenges(ctx context.Context, now time.Time) (int64, error)
+}
+
+// DeveloperAuth authenticates developers by an EIP-712 challenge signed with
+// their vault's address, and issues short-lived tokens scoped to that vault.
+type DeveloperAuth struct {
+	db            DeveloperAuthStore
+	addresses     AddressDeriver
+	pluginID      string
+	chainID       uint64
+	verifierToken string
+	jwtSecret     []byte
+	tokenTTL      time.Duration
+	challengeTTL  time.Duration
+	rateLimit     float64
+	rateBurst     int
+	purgeInterval time.Duration
+	logger        *logrus.Logger
+}
+
+func NewDeveloperAuth(
+	database DeveloperAuthStore,
+	addresses AddressDeriver,
+	pluginID string,
+	chainID uint64,
+	verifierToken string,
+	authConfig config.DeveloperAuthConfig,
+	logger *logrus.Logger,
+) *DeveloperAuth {
+	return &DeveloperAuth{
+		db:            database,
+		addresses:     addresses,
+		pluginID:      pluginID,
+		chainID:       chainID,
+		verifierToken: verifierToken,
+		jwtSecret:     []byte(authConfig.JWTSecret),
+		tokenTTL:      authConfig.TokenTTL,
+		challengeTTL:  authConfig.ChallengeTTL,
+		rateLimit:     authConfig.RateLimit,
+		rateBurst:     authConfig.RateBurst,
+		purgeInterval: authConfig.PurgeInterval,
+		logger:        logger,
+	}
+}
+
+func (a *DeveloperAuth) RegisterRoutes(e *echo.Echo) {
+	if len(a.jwtSecret) == 0 {
+		a.logger.Warn("no developer JWT secret configured, developer authentication disabled")
+		return
+	}
+
+	// Both endpoints are open and write to the database, so each client IP
+	// is limited.
+	limiter := middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Limit(a.rateLimit),
+			Burst:     a.rateBurst,
+			ExpiresIn: 3 * time.Minute,
+		}),
+		IdentifierExtractor: func(c echo.Context) (string, error) {
+			return c.RealIP(), nil
+		},
+		ErrorHandler: func(c echo.Context, err error) error {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "client not identified"})
+		},
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "too many requests"})
+		},
+	})
+
+	auth := e.Group("/api/auth", limiter)
+	auth.POST("/challenge", a.handleChallenge)
+	auth.POST("/token", a.handleToken)
+}
+
+// PurgeExpiredChallenges deletes expired challenges every purge interval
+// until ctx is done. It does nothing while developer authentication is
+// disabled.
+func (a *DeveloperAuth) PurgeExpiredChallenges(ctx context.Context) {
+	if len(a.jwtSecret) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(a.purgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := a.db.DeleteExpiredDeveloperAuthChallenges(ctx, time.Now())
+			if err != nil {
+				a.logger.WithError(err).Error("failed to purge expired auth challenges")
+				continue
+			}
+			if n > 0 {
+				a.logger.WithField("count", n).Debug("purged expired auth challenges")
+			}
+		}
+	}
+}
+
+// Middleware admits the verifier with its token and developers with a token
+// from handleToken. Developer requests are scoped to their own vault.
+func (a *DeveloperAuth) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+		if !ok || token == "" {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "missing bearer token"})
+		}
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(a.verifierToken)) == 1 {
+			c.Set(callerPublicKeyContextKey, "")
+			return next(c)
+		}
+
+		if len(a.jwtSecret) == 0 {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+		}
+		publicKey, err := a.parseToken(token)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+		}
+		c.Set(callerPublicKeyContextKey, publicKey)
+		return next(c)
+	}
+}
+
+// callerPublicKey returns the vault the request is scoped to, or "" when the
+// caller may query any vault.
+func callerPublicKey(c echo.Context) string {
+	publicKey, _ := c.Get(callerPublicKeyContextKey).(string)
+	return publicKey
+}
+
+type challengeRequest struct {
+	PublicKey string `json:"public_key"`
+}
+
+type challengeResponse struct {
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// TypedData is what the vault signs, e.g. with eth_signTypedData_v4.
+	TypedData apitypes.TypedData `json:"typed_data"`
+}
+
+func (a *DeveloperAuth) handleChallenge(c echo.Context) error {
+	var req challengeRequest
+	err := c.Bind(&req)
+	if err != nil || req.PublicKey == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "public_key is required"})
+	}
+
+	known, err := a.db.IsKnownDeveloperPublicKey(c.Request().Context(), req.PublicKey)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to look up public key for auth challenge")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+	if !known {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "no listing fee or policy for public_key"})
+	}
+
+	nonceBytes := make([]byte, 32)
+	_, err = rand.Read(nonceBytes)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to generate auth nonce")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create challenge"})
+	}
+
+	// Whole seconds in UTC, so the expiry reads back from the database as the
+	// same value that was signed.
+	challenge := db.DeveloperAuthChallenge{
+		Nonce:     hex.EncodeToString(nonceBytes),
+		PublicKey: req.PublicKey,
+		ExpiresAt: time.Now().UTC().Truncate(time.Second).Add(a.challengeTTL),
+	}
+	err = a.db.CreateDeveloperAuthChallenge(c.Request().Context(), challenge)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to create auth challenge")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+
+	return c.JSON(http.StatusOK, challengeResponse{
+		Nonce:     challenge.Nonce,
+		ExpiresAt: challenge.ExpiresAt,
+		TypedData: a.typedData(challenge),
+	})
+}
+
+type tokenRequest struct {
+	PublicKey string `json:"public_key"`
+	Nonce     string `json:"nonce"`
+	// Signature is the hex encoded 65-byte signature of the challenge's typed
+	// data.
+	Signature string `json:"signature"`
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// handleToken checks a signed challenge against the vault's address and
+// issues a token scoped to the vault. Each challenge can be answered once.
+func (a *DeveloperAuth) handleToken(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req tokenRequest
+	err := c.Bind(&req)
+	if err != nil || req.PublicKey == "" || req.Nonce == "" || req.Signature == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "public_key, nonce and signature are required"})
+	}
+
+	challenge, err := a.db.ConsumeDeveloperAuthChallenge(ctx, req.Nonce)
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("failed to consume auth challenge")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+	if challenge == nil || challenge.PublicKey != req.PublicKey || time.Now().After(challenge.ExpiresAt) {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired challenge"})
+	}
+
+	signer, err := a.recoverSigner(challenge, req.Signature)
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).WithField("public_key", challenge.PublicKey).Info("developer auth signature rejected")
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid signature"})
+	}
+
+	vaultAddr, err := a.addresses.DeriveAddress(ctx, challenge.PublicKey, a.pluginID)
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).WithField("public_key", challenge.PublicKey).Warn("failed to derive vault address for developer auth")
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "vault not found, is the plugin installed"})
+	}
+	if signer != vaultAddr {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "signature is not from the vault address"})
+	}
+
+	now := time.Now()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    developerTokenIssuer,
+		Subject:   challenge.PublicKey,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(a.tokenTTL)),
+	}).SignedString(a.jwtSecret)
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("failed to sign developer token")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to issue token"})
+	}
+
+	a.logger.WithContext(ctx).WithField("public_key", challenge.PublicKey).Info("developer token issued")
+	return c.JSON(http.StatusOK, tokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(a.tokenTTL.Seconds()),
+	})
+}
+
+func (a *DeveloperAuth) typedData(challenge db.DeveloperAuthChallenge) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+			},
+			"DeveloperAuth": {
+				{Name: "publicKey", Type: "string"},
+				{Name: "nonce", Type: "string"},
+				{Name: "expiresAt", Type: "uint256"},
+			},
+		},
+		PrimaryType: "DeveloperAuth",
+		Domain: apitypes.TypedDataDomain{
+			Name:    "Vultisig App Developer",
+			Version: "1",
+			ChainId: math.NewHexOrDecimal256(int64(a.chainID)),
+		},
+		Message: apitypes.TypedDataMessage{
+			"publicKey": challenge.PublicKey,
+			"nonce":     challenge.Nonce,
+			"expiresAt": strconv.FormatInt(challenge.ExpiresAt.Unix(), 10),
+		},
+	}
+}
+
+func (a *DeveloperAuth) recoverSigner(challenge *db.DeveloperAuthChallenge, signature string) (ecommon.Address, error) {
+	hash, _, err := apitypes.TypedDataAndHash(a.typedData(*challenge))
+	if err != nil {
+		return ecommon.Address{}, fmt.Errorf("failed to hash challenge: %w", err)
+	}
+
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != crypto.SignatureLength {
+		return ecommon.Address{}, fmt.Errorf("signature must be %d hex encoded bytes", crypto.SignatureLength)
+	}
+	// Wallets return v as 27 or 28.
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return ecommon.Address{}, fmt.Errorf("invalid signature: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+func (a *DeveloperAuth) parseToken(token string) (string, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return a.jwtSecret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(developerTokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return "", err
+	}
+	if claims.Subject == "" {
+		return "", fmt.Errorf("token has no subject")
+	}
+	return claims.Subject, nil
+}
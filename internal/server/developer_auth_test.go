@@ -0,0 +1,256 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/app-developer/internal/config"
+	"github.com/vultisig/app-developer/internal/db"
+)
+
+const testDeveloperKey = "03bb"
+
+// testAuthStore keeps challenges in memory. Consuming one removes it, so a
+// replay finds nothing.
+type testAuthStore struct {
+	challenges map[string]db.DeveloperAuthChallenge
+	purged     chan time.Time
+}
+
+func (s *testAuthStore) IsKnownDeveloperPublicKey(_ context.Context, publicKey string) (bool, error) {
+	return publicKey == testDeveloperKey, nil
+}
+
+func (s *testAuthStore) CreateDeveloperAuthChallenge(_ context.Context, challenge db.DeveloperAuthChallenge) error {
+	s.challenges[challenge.Nonce] = challenge
+	return nil
+}
+
+func (s *testAuthStore) ConsumeDeveloperAuthChallenge(_ context.Context, nonce string) (*db.DeveloperAuthChallenge, error) {
+	challenge, ok := s.challenges[nonce]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.challenges, nonce)
+	return &challenge, nil
+}
+
+func (s *testAuthStore) DeleteExpiredDeveloperAuthChallenges(_ context.Context, now time.Time) (int64, error) {
+	s.purged <- now
+	return 0, nil
+}
+
+type keyDeriver struct {
+	addr ecommon.Address
+}
+
+func (d keyDeriver) DeriveAddress(context.Context, string, string) (ecommon.Address, error) {
+	return d.addr, nil
+}
+
+func newTestDeveloperAuth(store *testAuthStore, vaultKey *ecdsa.PrivateKey, authConfig config.DeveloperAuthConfig) (*DeveloperAuth, *echo.Echo) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	auth := NewDeveloperAuth(
+		store,
+		keyDeriver{addr: crypto.PubkeyToAddress(vaultKey.PublicKey)},
+		"vultisig-developer",
+		1,
+		"verifier-token",
+		authConfig,
+		logger,
+	)
+	e := echo.New()
+	auth.RegisterRoutes(e)
+	e.GET("/me", func(c echo.Context) error {
+		return c.String(http.StatusOK, callerPublicKey(c))
+	}, auth.Middleware)
+	return auth, e
+}
+
+func postJSON(e *echo.Echo, path string, body any) *httptest.ResponseRecorder {
+	raw, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(raw)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+// signChallenge signs the typed data of a challenge as a wallet would, with v
+// as 27 or 28 when walletV is set.
+func signChallenge(t *testing.T, key *ecdsa.PrivateKey, typedData apitypes.TypedData, walletV bool) string {
+	t.Helper()
+
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		t.Fatalf("TypedDataAndHash: %v", err)
+	}
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		t.Fatalf("failed to sign challenge: %v", err)
+	}
+	if walletV {
+		sig[crypto.RecoveryIDOffset] += 27
+	}
+	return hexutil.Encode(sig)
+}
+
+func TestDeveloperAuthRoundTrip(t *testing.T) {
+	vaultKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	otherKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		challengeTTL time.Duration
+		key          *ecdsa.PrivateKey
+		walletV      bool
+		publicKey    string
+		replay       bool
+		want         int
+	}{
+		{name: "signed by the vault", key: vaultKey, want: http.StatusOK},
+		{name: "wallet recovery id", key: vaultKey, walletV: true, want: http.StatusOK},
+		{name: "replayed challenge", key: vaultKey, replay: true, want: http.StatusUnauthorized},
+		{name: "expired challenge", challengeTTL: -time.Second, key: vaultKey, want: http.StatusUnauthorized},
+		{name: "signed by another key", key: otherKey, want: http.StatusUnauthorized},
+		{name: "answered for another vault", key: vaultKey, publicKey: "03cc", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl := tt.challengeTTL
+			if ttl == 0 {
+				ttl = time.Minute
+			}
+			store := &testAuthStore{challenges: make(map[string]db.DeveloperAuthChallenge)}
+			_, e := newTestDeveloperAuth(store, vaultKey, config.DeveloperAuthConfig{
+				JWTSecret:    "jwt-secret",
+				TokenTTL:     time.Hour,
+				ChallengeTTL: ttl,
+				RateLimit:    100,
+				RateBurst:    100,
+			})
+
+			rec := postJSON(e, "/api/auth/challenge", challengeRequest{PublicKey: testDeveloperKey})
+			if rec.Code != http.StatusOK {
+				t.Fatalf("challenge status = %d: %s", rec.Code, rec.Body)
+			}
+			var challenge challengeResponse
+			err := json.Unmarshal(rec.Body.Bytes(), &challenge)
+			if err != nil {
+				t.Fatalf("failed to decode challenge: %v", err)
+			}
+
+			publicKey := tt.publicKey
+			if publicKey == "" {
+				publicKey = testDeveloperKey
+			}
+			tokenReq := tokenRequest{
+				PublicKey: publicKey,
+				Nonce:     challenge.Nonce,
+				Signature: signChallenge(t, tt.key, challenge.TypedData, tt.walletV),
+			}
+			if tt.replay {
+				rec = postJSON(e, "/api/auth/token", tokenReq)
+				if rec.Code != http.StatusOK {
+					t.Fatalf("first answer status = %d: %s", rec.Code, rec.Body)
+				}
+			}
+			rec = postJSON(e, "/api/auth/token", tokenReq)
+			if rec.Code != tt.want {
+				t.Fatalf("token status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+
+			var token tokenResponse
+			err = json.Unmarshal(rec.Body.Bytes(), &token)
+			if err != nil {
+				t.Fatalf("failed to decode token: %v", err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token.AccessToken)
+			rec = httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK || rec.Body.String() != testDeveloperKey {
+				t.Errorf("token scoped to %q (status %d), want %s", rec.Body, rec.Code, testDeveloperKey)
+			}
+		})
+	}
+}
+
+func TestDeveloperAuthRateLimit(t *testing.T) {
+	vaultKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	store := &testAuthStore{challenges: make(map[string]db.DeveloperAuthChallenge)}
+	_, e := newTestDeveloperAuth(store, vaultKey, config.DeveloperAuthConfig{
+		JWTSecret:    "jwt-secret",
+		ChallengeTTL: time.Minute,
+		RateLimit:    0.001,
+		RateBurst:    2,
+	})
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		rec := postJSON(e, "/api/auth/challenge", challengeRequest{PublicKey: testDeveloperKey})
+		if rec.Code != want {
+			t.Errorf("request %d status = %d, want %d", i+1, rec.Code, want)
+		}
+	}
+}
+
+func TestPurgeExpiredChallenges(t *testing.T) {
+	vaultKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	store := &testAuthStore{purged: make(chan time.Time)}
+	auth, _ := newTestDeveloperAuth(store, vaultKey, config.DeveloperAuthConfig{
+		JWTSecret:     "jwt-secret",
+		PurgeInterval: time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		auth.PurgeExpiredChallenges(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-store.purged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expired challenges were never purged")
+	}
+	cancel()
+	// Drain a purge that raced the cancellation.
+	for {
+		select {
+		case <-store.purged:
+		case <-done:
+			return
+		}
+	}
+}
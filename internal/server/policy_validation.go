@@ -0,0 +1,110 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	vtypes "github.com/vultisig/verifier/types"
+
+	"github.com/vultisig/app-developer/spec"
+)
+
+const pluginPolicyPath = "/plugin/policy"
+
+// PolicyValidator is satisfied by *spec.Spec.
+type PolicyValidator interface {
+	ValidatePluginPolicy(pol vtypes.PluginPolicy) error
+}
+
+// PolicyEnqueuer is satisfied by *tasks.Enqueuer.
+type PolicyEnqueuer interface {
+	EnqueueCreate(ctx context.Context, policyID uuid.UUID) error
+}
+
+// NewPolicyValidationMiddleware validates policies sent to the plugin server's
+// create and update endpoints before they reach its handlers, which answer
+// every validation failure with the same generic 400. Rejected policies get the
+// reason and a matching status instead; the handlers still run their own check
+// on the policies that pass. auth runs first so the lookups behind validation
+// are not exposed to unauthenticated callers.
+//
+// Created policies are handed to the worker straight away, with the request's
+// correlation ID, so the worker's logs for the fee join up with the request.
+func NewPolicyValidationMiddleware(
+	validator PolicyValidator,
+	auth echo.MiddlewareFunc,
+	enqueuer PolicyEnqueuer,
+	logger *logrus.Logger,
+) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		validate := auth(func(c echo.Context) error {
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to read request body"})
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			var pol vtypes.PluginPolicy
+			err = json.Unmarshal(body, &pol)
+			if err != nil {
+				// Leave malformed bodies to the plugin server's own binding.
+				return next(c)
+			}
+
+			err = validator.ValidatePluginPolicy(pol)
+			if err != nil {
+				status := policyRejectionStatus(err)
+				if status >= http.StatusInternalServerError {
+					logger.WithError(err).WithField("policy_id", pol.ID).Error("failed to validate policy")
+					return c.JSON(status, map[string]string{"error": "policy could not be validated, try again later"})
+				}
+				return c.JSON(status, map[string]string{"error": err.Error()})
+			}
+
+			err = next(c)
+			if err != nil || c.Request().Method != http.MethodPost || c.Response().Status >= http.StatusMultipleChoices {
+				return err
+			}
+			// A policy without an ID gets one from the plugin server; the worker's
+			// policy listener picks it up instead.
+			if pol.ID != uuid.Nil {
+				enqueueErr := enqueuer.EnqueueCreate(c.Request().Context(), pol.ID)
+				if enqueueErr != nil {
+					logger.WithContext(c.Request().Context()).WithError(enqueueErr).WithField("policy_id", pol.ID).Warn("failed to enqueue listing fee creation, leaving it to the policy listener")
+				}
+			}
+			return nil
+		})
+
+		return func(c echo.Context) error {
+			if c.Path() != pluginPolicyPath {
+				return next(c)
+			}
+			method := c.Request().Method
+			if method != http.MethodPost && method != http.MethodPut {
+				return next(c)
+			}
+			return validate(c)
+		}
+	}
+}
+
+func policyRejectionStatus(err error) int {
+	switch {
+	case errors.Is(err, spec.ErrPolicyCheckFailed):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, spec.ErrListingFeeExists):
+		return http.StatusConflict
+	case errors.Is(err, spec.ErrPluginNotOwned):
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -1,38 +1,91 @@
 package server
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
+	ecommon "github.com/ethereum/go-ethereum/common"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 	"github.com/vultisig/app-developer/internal/config"
 	"github.com/vultisig/app-developer/internal/db"
+	"github.com/vultisig/app-developer/internal/evm"
+	"github.com/vultisig/verifier/plugin/policy"
 )
 
 type DeveloperAPI struct {
 	db        *db.PostgresBackend
+	policySvc policy.Service
+	addresses *evm.VaultAddressDeriver
+	simulator *evm.Simulator
 	feeConfig config.FeeConfig
 	logger    *logrus.Logger
 }
 
 func NewDeveloperAPI(
 	database *db.PostgresBackend,
+	policySvc policy.Service,
+	addresses *evm.VaultAddressDeriver,
+	simulator *evm.Simulator,
 	feeConfig config.FeeConfig,
 	logger *logrus.Logger,
 ) *DeveloperAPI {
 	return &DeveloperAPI{
 		db:        database,
+		policySvc: policySvc,
+		addresses: addresses,
+		simulator: simulator,
 		feeConfig: feeConfig,
 		logger:    logger,
 	}
 }
 
-func (a *DeveloperAPI) RegisterRoutes(e *echo.Echo) {
-	api := e.Group("/api")
+// RegisterRoutes mounts the developer API behind auth, which scopes each
+// request to the caller's vault (see DeveloperAuth.Middleware).
+func (a *DeveloperAPI) RegisterRoutes(e *echo.Echo, auth echo.MiddlewareFunc) {
+	api := e.Group("/api", auth)
 	api.GET("/listing-fee/by-scope", a.handleGetListingFeeByScope)
 	api.GET("/listing-fee/paid", a.handleIsListingFeePaid)
+	api.GET("/listing-fee/:policyId/events", a.handleGetListingFeeEvents)
+	api.GET("/listing-fee/:policyId/ingestion", a.handleGetIngestionStatus)
+	api.POST("/listing-fee/:policyId/cancel", a.handleCancelListingFee)
+	api.POST("/listing-fee/:policyId/simulate", a.handleSimulateListingFee)
+}
+
+// authorizePolicy checks that a vault-scoped caller owns the policy and writes
+// a 403 or 500 response when it does not. Unscoped callers pass.
+func (a *DeveloperAPI) authorizePolicy(c echo.Context, policyID uuid.UUID) bool {
+	caller := callerPublicKey(c)
+	if caller == "" {
+		return true
+	}
+	ctx := c.Request().Context()
+
+	fee, err := a.db.GetListingFeeByPolicyID(ctx, policyID)
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("failed to get listing fee")
+		_ = c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+		return false
+	}
+	owner := ""
+	if fee != nil {
+		owner = fee.PublicKey
+	} else {
+		// No fee yet, e.g. while ingestion is pending, so ask the policy.
+		pol, err := a.policySvc.GetPluginPolicy(ctx, policyID)
+		if err == nil && pol != nil {
+			owner = pol.PublicKey
+		}
+	}
+
+	if owner != caller {
+		_ = c.JSON(http.StatusForbidden, map[string]string{"error": "policy belongs to another vault"})
+		return false
+	}
+	return true
 }
 
 type listingFeeResponse struct {
@@ -44,6 +97,12 @@ type listingFeeResponse struct {
 	TxHash         *string             `json:"tx_hash,omitempty"`
 	PaidAt         *time.Time          `json:"paid_at,omitempty"`
 	FailureReason  *string             `json:"failure_reason,omitempty"`
+	ExpiresAt      *time.Time          `json:"expires_at,omitempty"`
+	// FailureDetails is structured diagnostics for a failed fee, e.g. the
+	// keysign failure category and which parties joined the session.
+	FailureDetails json.RawMessage `json:"failure_details,omitempty"`
+	// BatchPolicyID is the fee whose transfer pays for this one as well.
+	BatchPolicyID *uuid.UUID `json:"batch_policy_id,omitempty"`
 }
 
 type paymentInstructions struct {
@@ -56,12 +115,20 @@ func (a *DeveloperAPI) handleGetListingFeeByScope(c echo.Context) error {
 	pubkey := c.QueryParam("pubkey")
 	pluginID := c.QueryParam("pluginId")
 
+	caller := callerPublicKey(c)
+	if pubkey == "" {
+		pubkey = caller
+	}
 	if pubkey == "" || pluginID == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "pubkey and pluginId are required"})
 	}
+	if caller != "" && pubkey != caller {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "pubkey belongs to another vault"})
+	}
 
 	fee, err := a.db.GetListingFeeByScope(c.Request().Context(), pubkey, pluginID)
 	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to get listing fee by scope")
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
 	}
 
@@ -83,9 +150,12 @@ func toListingFeeResponse(fee *db.ListingFee, feeConfig config.FeeConfig) listin
 			Amount:      fee.Amount.String(),
 			VultToken:   feeConfig.VultTokenAddress,
 		},
-		TxHash:        fee.TxHash,
-		PaidAt:        fee.PaidAt,
-		FailureReason: fee.FailureReason,
+		TxHash:         fee.TxHash,
+		PaidAt:         fee.PaidAt,
+		FailureReason:  fee.FailureReason,
+		ExpiresAt:      fee.ExpiresAt,
+		FailureDetails: fee.FailureDetails,
+		BatchPolicyID:  fee.BatchPolicyID,
 	}
 }
 
@@ -95,11 +165,241 @@ func (a *DeveloperAPI) handleIsListingFeePaid(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "pluginId is required"})
 	}
 
-	paid, err := a.db.IsListingFeePaidForPlugin(c.Request().Context(), pluginID)
+	// A vault-scoped caller only learns whether its own vault paid.
+	var paid bool
+	var err error
+	if caller := callerPublicKey(c); caller != "" {
+		paid, err = a.db.IsListingFeePaidForVaultPlugin(c.Request().Context(), caller, pluginID)
+	} else {
+		paid, err = a.db.IsListingFeePaidForPlugin(c.Request().Context(), pluginID)
+	}
 	if err != nil {
-		a.logger.WithError(err).Error("failed to check listing fee")
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to check listing fee")
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
 	}
 
 	return c.JSON(http.StatusOK, map[string]bool{"paid": paid})
 }
+
+type listingFeeEventResponse struct {
+	OldStatus *string   `json:"old_status,omitempty"`
+	NewStatus string    `json:"new_status"`
+	Actor     string    `json:"actor"`
+	TxHash    *string   `json:"tx_hash,omitempty"`
+	Reason    *string   `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toListingFeeEventResponses(events []db.ListingFeeEvent) []listingFeeEventResponse {
+	resp := make([]listingFeeEventResponse, len(events))
+	for i, ev := range events {
+		resp[i] = listingFeeEventResponse{
+			OldStatus: ev.OldStatus,
+			NewStatus: ev.NewStatus,
+			Actor:     ev.Actor,
+			TxHash:    ev.TxHash,
+			Reason:    ev.Reason,
+			CreatedAt: ev.CreatedAt,
+		}
+	}
+	return resp
+}
+
+func (a *DeveloperAPI) handleGetListingFeeEvents(c echo.Context) error {
+	policyID, err := uuid.Parse(c.Param("policyId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid policyId"})
+	}
+	if !a.authorizePolicy(c, policyID) {
+		return nil
+	}
+
+	events, err := a.db.GetListingFeeEvents(c.Request().Context(), policyID)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to get listing fee events")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+
+	if len(events) == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "listing fee not found"})
+	}
+
+	return c.JSON(http.StatusOK, toListingFeeEventResponses(events))
+}
+
+type ingestionStatusResponse struct {
+	PolicyID uuid.UUID `json:"policy_id"`
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// handleGetIngestionStatus tells a developer whether their policy produced a
+// listing fee yet, or why it was rejected.
+func (a *DeveloperAPI) handleGetIngestionStatus(c echo.Context) error {
+	policyID, err := uuid.Parse(c.Param("policyId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid policyId"})
+	}
+	if !a.authorizePolicy(c, policyID) {
+		return nil
+	}
+
+	fee, err := a.db.GetListingFeeByPolicyID(c.Request().Context(), policyID)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to get listing fee")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+	if fee != nil {
+		return c.JSON(http.StatusOK, ingestionStatusResponse{PolicyID: policyID, Status: "created"})
+	}
+
+	ingestionErr, err := a.db.GetPolicyIngestionError(c.Request().Context(), policyID)
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to get policy ingestion error")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+	if ingestionErr != nil {
+		return c.JSON(http.StatusOK, ingestionStatusResponse{
+			PolicyID: policyID,
+			Status:   "rejected",
+			Error:    ingestionErr.Error,
+		})
+	}
+
+	return c.JSON(http.StatusOK, ingestionStatusResponse{PolicyID: policyID, Status: "pending"})
+}
+
+type cancelListingFeeRequest struct {
+	Reason string `json:"reason"`
+}
+
+// handleCancelListingFee lets a developer back out of a fee that has not been
+// paid yet. The policy is deactivated along with the fee.
+func (a *DeveloperAPI) handleCancelListingFee(c echo.Context) error {
+	policyID, err := uuid.Parse(c.Param("policyId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid policyId"})
+	}
+	if !a.authorizePolicy(c, policyID) {
+		return nil
+	}
+
+	var req cancelListingFeeRequest
+	err = c.Bind(&req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+	if req.Reason == "" {
+		req.Reason = "cancelled by developer"
+	}
+
+	err = a.db.CancelListingFee(c.Request().Context(), policyID, req.Reason)
+	if errors.Is(err, db.ErrListingFeeStateConflict) {
+		fee, getErr := a.db.GetListingFeeByPolicyID(c.Request().Context(), policyID)
+		if getErr == nil && fee == nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "listing fee not found"})
+		}
+		return c.JSON(http.StatusConflict, map[string]string{"error": "listing fee can only be cancelled while pending and before payment is broadcast"})
+	}
+	if err != nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to cancel listing fee")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+
+	fee, err := a.db.GetListingFeeByPolicyID(c.Request().Context(), policyID)
+	if err != nil || fee == nil {
+		a.logger.WithContext(c.Request().Context()).WithError(err).Error("failed to get listing fee")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+	return c.JSON(http.StatusOK, toListingFeeResponse(fee, a.feeConfig))
+}
+
+type simulationResponse struct {
+	PolicyID         uuid.UUID             `json:"policy_id"`
+	FromAddress      string                `json:"from_address"`
+	Amount           string                `json:"amount"`
+	Nonce            uint64                `json:"nonce"`
+	GasLimit         uint64                `json:"gas_limit"`
+	BuildError       string                `json:"build_error,omitempty"`
+	PolicyAllowed    bool                  `json:"policy_allowed"`
+	PolicyError      string                `json:"policy_error,omitempty"`
+	Evaluation       *evm.EvaluationReport `json:"evaluation,omitempty"`
+	CallSucceeded    bool                  `json:"call_succeeded"`
+	CallError        string                `json:"call_error,omitempty"`
+	TransferReturned *bool                 `json:"transfer_returned,omitempty"`
+	GasEstimate      uint64                `json:"gas_estimate"`
+	EstimateError    string                `json:"estimate_error,omitempty"`
+	// WouldSucceed is true when every step passed.
+	WouldSucceed bool `json:"would_succeed"`
+}
+
+// handleSimulateListingFee dry-runs a pending fee's payment so a developer can
+// see whether it would go through, e.g. that the vault holds enough VULT,
+// without anything being signed or sent.
+func (a *DeveloperAPI) handleSimulateListingFee(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	policyID, err := uuid.Parse(c.Param("policyId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid policyId"})
+	}
+	if !a.authorizePolicy(c, policyID) {
+		return nil
+	}
+
+	fee, err := a.db.GetListingFeeByPolicyID(ctx, policyID)
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("failed to get listing fee")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "database error"})
+	}
+	if fee == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "listing fee not found"})
+	}
+	if fee.Status != "pending" {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "only pending listing fees can be simulated"})
+	}
+
+	pol, err := a.policySvc.GetPluginPolicy(ctx, policyID)
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("failed to get policy")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get policy"})
+	}
+
+	fromAddr, err := a.addresses.DeriveAddress(ctx, pol.PublicKey, pol.PluginID.String())
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("failed to derive vault address")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to derive vault address"})
+	}
+
+	res, err := a.simulator.Simulate(
+		ctx,
+		*pol,
+		fromAddr,
+		ecommon.HexToAddress(fee.Destination),
+		ecommon.HexToAddress(a.feeConfig.VultTokenAddress),
+		fee.Amount,
+	)
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("failed to simulate listing fee")
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "simulation failed"})
+	}
+
+	return c.JSON(http.StatusOK, simulationResponse{
+		PolicyID:         policyID,
+		FromAddress:      fromAddr.Hex(),
+		Amount:           fee.Amount.String(),
+		Nonce:            res.Nonce,
+		GasLimit:         res.GasLimit,
+		BuildError:       res.BuildError,
+		PolicyAllowed:    res.PolicyAllowed,
+		PolicyError:      res.PolicyError,
+		Evaluation:       res.Evaluation,
+		CallSucceeded:    res.CallSucceeded,
+		CallError:        res.CallError,
+		TransferReturned: res.TransferReturned,
+		GasEstimate:      res.GasEstimate,
+		EstimateError:    res.EstimateError,
+		WouldSucceed: res.BuildError == "" && res.PolicyAllowed && res.CallSucceeded &&
+			res.EstimateError == "" && (res.TransferReturned == nil || *res.TransferReturned),
+	})
+}
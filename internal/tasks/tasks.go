@@ -0,0 +1,148 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+
+	"github.com/vultisig/app-developer/internal/logging"
+)
+
+const (
+	TypeCreateListingFee  = "developer:listing_fee:create"
+	TypeExecuteListingFee = "developer:listing_fee:execute"
+	TypeSyncListingFee    = "developer:listing_fee:sync"
+)
+
+const maxRetry = 5
+
+type ListingFeePayload struct {
+	PolicyID uuid.UUID `json:"policy_id"`
+	// CorrelationID ties the task to the API request that caused it. It is
+	// omitted when it would just repeat the policy ID, the worker's default.
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// ParseListingFeePayload returns the payload of a listing fee task.
+func ParseListingFeePayload(t *asynq.Task) (ListingFeePayload, error) {
+	var payload ListingFeePayload
+	err := json.Unmarshal(t.Payload(), &payload)
+	if err != nil {
+		return ListingFeePayload{}, fmt.Errorf("failed to unmarshal %s payload: %w", t.Type(), err)
+	}
+	if payload.PolicyID == uuid.Nil {
+		return ListingFeePayload{}, fmt.Errorf("%s payload has no policy_id", t.Type())
+	}
+	return payload, nil
+}
+
+// Context returns ctx carrying the payload's correlation ID, or the policy ID
+// when the task did not come from a request.
+func (p ListingFeePayload) Context(ctx context.Context) context.Context {
+	if p.CorrelationID != "" {
+		return logging.WithCorrelationID(ctx, p.CorrelationID)
+	}
+	return logging.WithCorrelationID(ctx, p.PolicyID.String())
+}
+
+// Enqueuer schedules listing fee tasks. Each task is unique per type and
+// policy ID, so re-enqueueing work that is already queued, running or
+// retrying is a no-op.
+type Enqueuer struct {
+	client    *asynq.Client
+	inspector *asynq.Inspector
+	queue     string
+	timeout   time.Duration
+}
+
+// NewEnqueuer enqueues onto queue; timeout bounds a single task run and should
+// cover a full keysign round. The inspector clears archived tasks whose ID a
+// new task needs.
+func NewEnqueuer(client *asynq.Client, inspector *asynq.Inspector, queue string, timeout time.Duration) *Enqueuer {
+	return &Enqueuer{
+		client:    client,
+		inspector: inspector,
+		queue:     queue,
+		timeout:   timeout,
+	}
+}
+
+func (e *Enqueuer) EnqueueCreate(ctx context.Context, policyID uuid.UUID) error {
+	return e.enqueue(ctx, TypeCreateListingFee, policyID)
+}
+
+func (e *Enqueuer) EnqueueExecute(ctx context.Context, policyID uuid.UUID) error {
+	return e.enqueue(ctx, TypeExecuteListingFee, policyID)
+}
+
+func (e *Enqueuer) EnqueueSync(ctx context.Context, policyID uuid.UUID, delay time.Duration) error {
+	return e.enqueue(ctx, TypeSyncListingFee, policyID, asynq.ProcessIn(delay))
+}
+
+func (e *Enqueuer) enqueue(ctx context.Context, taskType string, policyID uuid.UUID, opts ...asynq.Option) error {
+	correlationID := logging.CorrelationID(ctx)
+	if correlationID == policyID.String() {
+		correlationID = ""
+	}
+	payload, err := json.Marshal(ListingFeePayload{PolicyID: policyID, CorrelationID: correlationID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s payload: %w", taskType, err)
+	}
+
+	// The ID, unlike asynq.Unique, ignores the payload, whose correlation ID
+	// differs between the request and the sweeps queueing the same work.
+	taskID := taskType + ":" + policyID.String()
+	opts = append(opts,
+		asynq.Queue(e.queue),
+		asynq.TaskID(taskID),
+		asynq.MaxRetry(maxRetry),
+		asynq.Timeout(e.timeout),
+	)
+	task := asynq.NewTask(taskType, payload, opts...)
+
+	_, err = e.client.EnqueueContext(ctx, task)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		requeued, clearErr := e.clearArchived(taskID)
+		if clearErr != nil {
+			return fmt.Errorf("failed to enqueue %s: %w", taskType, clearErr)
+		}
+		if !requeued {
+			return nil
+		}
+		_, err = e.client.EnqueueContext(ctx, task)
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			// Queued by someone else in the meantime.
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %s: %w", taskType, err)
+	}
+	return nil
+}
+
+// clearArchived deletes the task with taskID if it ran out of retries, since
+// an archived task keeps its ID and would block the work from being queued
+// again. It reports whether the ID is free again.
+func (e *Enqueuer) clearArchived(taskID string) (bool, error) {
+	info, err := e.inspector.GetTaskInfo(e.queue, taskID)
+	if errors.Is(err, asynq.ErrTaskNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect task %s: %w", taskID, err)
+	}
+	if info.State != asynq.TaskStateArchived {
+		return false, nil
+	}
+	err = e.inspector.DeleteTask(e.queue, taskID)
+	if err != nil && !errors.Is(err, asynq.ErrTaskNotFound) {
+		return false, fmt.Errorf("failed to delete archived task %s: %w", taskID, err)
+	}
+	return true, nil
+}
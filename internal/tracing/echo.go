@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EchoMiddleware starts a server span per request, continuing any trace
+// propagated by the caller.
+func EchoMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+			route := c.Path()
+			if route == "" {
+				route = req.URL.Path
+			}
+
+			ctx, span := otel.Tracer(instrumentationName).Start(
+				ctx,
+				fmt.Sprintf("%s %s", req.Method, route),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(req.Method),
+					semconv.HTTPRoute(route),
+					semconv.URLPath(req.URL.Path),
+				),
+			)
+			defer span.End()
+
+			if policyID := c.Param("policyId"); policyID != "" {
+				span.SetAttributes(AttrPolicyID.String(policyID))
+			}
+			if pluginID := c.QueryParam("pluginId"); pluginID != "" {
+				span.SetAttributes(AttrTargetPluginID.String(pluginID))
+			}
+
+			c.SetRequest(req.WithContext(ctx))
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			status := c.Response().Status
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+
+			return nil
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Package tracingtest records spans in memory for tests.
+package tracingtest
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// NewInMemoryProvider installs a synchronous tracer provider backed by an
+// in-memory exporter for the duration of the test, so it can assert on the
+// spans recorded through tracing.Start. The previous provider is restored on
+// cleanup.
+func NewInMemoryProvider(t testing.TB) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = provider.Shutdown(context.Background())
+	})
+	return exporter
+}
@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/app-developer/internal/tasks"
+)
+
+// HandleCreateListingFee creates the fee for a newly seen policy and queues
+// its execution.
+func (c *Consumer) HandleCreateListingFee(ctx context.Context, t *asynq.Task) error {
+	payload, err := tasks.ParseListingFeePayload(t)
+	if err != nil {
+		return fmt.Errorf("%w: %v", asynq.SkipRetry, err)
+	}
+	policyID := payload.PolicyID
+	ctx = payload.Context(ctx)
+
+	err = c.createListingFee(ctx, policyID)
+	if errors.Is(err, errInvalidPolicyConfig) {
+		c.logger.WithContext(ctx).WithError(err).WithField("policy_id", policyID).Warn("policy rejected")
+		return c.db.RecordPolicyIngestionError(ctx, policyID, err.Error())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create listing fee: %w", err)
+	}
+	return c.enqueuer.EnqueueExecute(ctx, policyID)
+}
+
+// HandleExecuteListingFee pays a pending fee. Unresolved payments are returned
+// as errors so asynq retries them with backoff; a fee that is no longer
+// pending, or is leased by another worker, is skipped.
+func (c *Consumer) HandleExecuteListingFee(ctx context.Context, t *asynq.Task) error {
+	payload, err := tasks.ParseListingFeePayload(t)
+	if err != nil {
+		return fmt.Errorf("%w: %v", asynq.SkipRetry, err)
+	}
+	policyID := payload.PolicyID
+	ctx = payload.Context(ctx)
+
+	fee, err := c.db.ClaimListingFee(ctx, policyID, c.workerConfig.ID, c.workerConfig.LeaseDuration)
+	if err != nil {
+		return err
+	}
+	if fee == nil {
+		c.logger.WithContext(ctx).WithField("policy_id", policyID).Debug("listing fee not claimable, skipping")
+		return nil
+	}
+
+	err = c.executeLeased(ctx, *fee)
+	if err != nil {
+		return err
+	}
+	return c.enqueuer.EnqueueSync(ctx, policyID, c.workerConfig.SyncDelay)
+}
+
+// HandleSyncListingFee settles a submitted fee from tx_indexer. A fee that is
+// still unconfirmed is picked up again by the next sweep; one whose
+// transaction was lost goes back to the worker to reconcile.
+func (c *Consumer) HandleSyncListingFee(ctx context.Context, t *asynq.Task) error {
+	payload, err := tasks.ParseListingFeePayload(t)
+	if err != nil {
+		return fmt.Errorf("%w: %v", asynq.SkipRetry, err)
+	}
+	policyID := payload.PolicyID
+	ctx = payload.Context(ctx)
+
+	paid, failed, err := c.db.SyncSubmittedFee(ctx, policyID)
+	if err != nil {
+		return err
+	}
+
+	logger := c.logger.WithContext(ctx).WithFields(logrus.Fields{"policy_id": policyID})
+	switch {
+	case paid:
+		c.metrics.RecordTransition("submitted", "paid", 1)
+		logger.Info("listing fee paid")
+	case failed:
+		c.metrics.RecordTransition("submitted", "failed", 1)
+		logger.Warn("listing fee payment failed on-chain")
+	default:
+		requeued, err := c.db.RequeueLostFee(ctx, policyID)
+		if err != nil {
+			return err
+		}
+		if requeued {
+			c.metrics.RecordTransition("submitted", "pending", 1)
+			logger.Warn("listing fee payment lost, reconciling")
+			return c.enqueuer.EnqueueExecute(ctx, policyID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package worker
+
+import "context"
+
+// LeaderElector decides whether this replica runs the worker's singleton stages.
+// *db.AdvisoryLeader implements it for multi-replica deployments.
+type LeaderElector interface {
+	IsLeader(ctx context.Context) (bool, error)
+}
+
+type soloLeader struct{}
+
+// NewSoloLeader is always the leader, for deployments with a single worker.
+func NewSoloLeader() LeaderElector {
+	return soloLeader{}
+}
+
+func (soloLeader) IsLeader(context.Context) (bool, error) {
+	return true, nil
+}
@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const listenerRetryDelay = 5 * time.Second
+
+// ListenForPolicies enqueues fee creation as soon as a policy is committed.
+// Notifications sent while the listener is reconnecting are lost; the sweep
+// in Run picks those policies up instead.
+func (c *Consumer) ListenForPolicies(ctx context.Context) {
+	c.logger.Info("policy listener started")
+	for {
+		err := c.db.ListenPolicyCreated(ctx, func(policyID uuid.UUID) {
+			enqueueErr := c.enqueuer.EnqueueCreate(ctx, policyID)
+			if enqueueErr != nil {
+				c.logger.WithError(enqueueErr).WithField("policy_id", policyID).Error("failed to enqueue listing fee creation")
+			}
+		})
+		if ctx.Err() != nil {
+			c.logger.Info("policy listener stopped")
+			return
+		}
+		c.logger.WithError(err).Warn("policy listener disconnected, reconnecting")
+
+		select {
+		case <-ctx.Done():
+			c.logger.Info("policy listener stopped")
+			return
+		case <-time.After(listenerRetryDelay):
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package worker
+
+import (
+	"context"
+	"sort"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/vultisig/app-developer/internal/db"
+)
+
+// fillNonceHoles looks for nonces that no transaction will ever use below a
+// signed payment, e.g. left by a fee cancelled or expired before it was
+// broadcast. The signed payment cannot be mined until the hole is filled.
+//
+// A hole is filled by moving down the highest unsigned intent of the same
+// address: it is discarded and rebuilt at the lowest free nonce on its next
+// execution. The signed payment itself is never re-signed at the lower nonce,
+// since its original transaction would still be valid and pay a second time,
+// and the verifier only co-signs the fee transfer, not a self-send. A hole
+// with nothing to move into it is reported for an operator to fill by hand.
+func (c *Consumer) fillNonceHoles(ctx context.Context) {
+	intents, err := c.db.GetLiveIntentNonces(ctx)
+	if err != nil {
+		c.logger.WithError(err).Error("failed to get live intent nonces")
+		return
+	}
+
+	byAddress := make(map[string][]db.IntentNonce)
+	for _, intent := range intents {
+		byAddress[intent.FromAddress] = append(byAddress[intent.FromAddress], intent)
+	}
+
+	for address, addrIntents := range byAddress {
+		c.fillAddressNonceHoles(ctx, address, addrIntents)
+	}
+}
+
+func (c *Consumer) fillAddressNonceHoles(ctx context.Context, address string, intents []db.IntentNonce) {
+	logger := c.logger.WithField("address", address)
+
+	var highestSigned *db.IntentNonce
+	occupied := make(map[uint64]bool, len(intents))
+	for i := range intents {
+		occupied[intents[i].Nonce] = true
+		if intents[i].Signed && (highestSigned == nil || intents[i].Nonce > highestSigned.Nonce) {
+			highestSigned = &intents[i]
+		}
+	}
+	if highestSigned == nil {
+		return
+	}
+
+	confirmed, err := c.chain.NonceAt(ctx, ecommon.HexToAddress(address), nil)
+	if err != nil {
+		logger.WithError(err).Error("failed to get confirmed nonce")
+		return
+	}
+
+	var holes []uint64
+	for nonce := confirmed; nonce < highestSigned.Nonce; nonce++ {
+		if !occupied[nonce] {
+			holes = append(holes, nonce)
+		}
+	}
+	if len(holes) == 0 {
+		return
+	}
+
+	// Only intents above every signed one can move without opening a new hole
+	// below a signed payment.
+	var movable []db.IntentNonce
+	for _, intent := range intents {
+		if !intent.Signed && intent.Nonce > highestSigned.Nonce {
+			movable = append(movable, intent)
+		}
+	}
+	sort.Slice(movable, func(i, j int) bool { return movable[i].Nonce > movable[j].Nonce })
+
+	for _, hole := range holes {
+		holeLogger := logger.WithField("nonce", hole).WithField("blocked_policy_id", highestSigned.PolicyID)
+
+		moved := false
+		for len(movable) > 0 && !moved {
+			intent := movable[0]
+			movable = movable[1:]
+
+			deleted, err := c.db.DeleteUnsignedListingFeeIntent(ctx, intent.PolicyID)
+			if err != nil {
+				holeLogger.WithError(err).WithField("policy_id", intent.PolicyID).Error("failed to discard intent to fill nonce hole")
+				continue
+			}
+			if !deleted {
+				continue
+			}
+			err = c.enqueuer.EnqueueExecute(ctx, intent.PolicyID)
+			if err != nil {
+				holeLogger.WithError(err).WithField("policy_id", intent.PolicyID).Error("failed to enqueue listing fee execution to fill nonce hole")
+			}
+			holeLogger.WithField("policy_id", intent.PolicyID).
+				WithField("old_nonce", intent.Nonce).
+				Warn("moved unsigned payment intent down to fill nonce hole")
+			moved = true
+		}
+		if !moved {
+			holeLogger.Error("nonce hole blocks a signed listing fee payment; send a transaction from the vault at this nonce to release it")
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package worker
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/vultisig/app-developer/internal/db"
+)
+
+// holeStore serves live intents and records which unsigned ones were dropped.
+type holeStore struct {
+	Store
+
+	intents []db.IntentNonce
+	deleted []uuid.UUID
+}
+
+func (s *holeStore) GetLiveIntentNonces(context.Context) ([]db.IntentNonce, error) {
+	return s.intents, nil
+}
+
+func (s *holeStore) DeleteUnsignedListingFeeIntent(_ context.Context, policyID uuid.UUID) (bool, error) {
+	for _, intent := range s.intents {
+		if intent.PolicyID == policyID && !intent.Signed {
+			s.deleted = append(s.deleted, policyID)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type testEnqueuer struct {
+	executed []uuid.UUID
+}
+
+func (e *testEnqueuer) EnqueueCreate(context.Context, uuid.UUID) error { return nil }
+
+func (e *testEnqueuer) EnqueueExecute(_ context.Context, policyID uuid.UUID) error {
+	e.executed = append(e.executed, policyID)
+	return nil
+}
+
+func (e *testEnqueuer) EnqueueSync(context.Context, uuid.UUID, time.Duration) error { return nil }
+
+func TestFillNonceHoles(t *testing.T) {
+	ids := make([]uuid.UUID, 4)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+	intent := func(i int, nonce uint64, signed bool) db.IntentNonce {
+		return db.IntentNonce{PolicyID: ids[i], FromAddress: testVault.Hex(), Nonce: nonce, Signed: signed}
+	}
+
+	tests := []struct {
+		name      string
+		confirmed uint64
+		intents   []db.IntentNonce
+		want      []uuid.UUID
+	}{
+		{
+			name:      "no hole",
+			confirmed: 3,
+			intents:   []db.IntentNonce{intent(0, 3, true), intent(1, 4, true), intent(2, 5, false)},
+		},
+		{
+			name:      "hole below unsigned intents only",
+			confirmed: 3,
+			intents:   []db.IntentNonce{intent(0, 4, false), intent(1, 6, false)},
+		},
+		{
+			name:      "hole below a signed payment",
+			confirmed: 3,
+			intents:   []db.IntentNonce{intent(0, 4, true), intent(1, 5, false), intent(2, 6, false)},
+			want:      []uuid.UUID{ids[2]},
+		},
+		{
+			name:      "two holes move the highest intents first",
+			confirmed: 3,
+			intents:   []db.IntentNonce{intent(0, 5, true), intent(1, 6, false), intent(2, 7, false), intent(3, 8, false)},
+			want:      []uuid.UUID{ids[3], ids[2]},
+		},
+		{
+			name:      "unsigned intent below the signed one stays",
+			confirmed: 3,
+			intents:   []db.IntentNonce{intent(0, 4, false), intent(1, 6, true)},
+		},
+		{
+			name:      "nonces below the confirmed nonce are no holes",
+			confirmed: 5,
+			intents:   []db.IntentNonce{intent(0, 5, true), intent(1, 6, false)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &holeStore{intents: tt.intents}
+			enqueuer := &testEnqueuer{}
+			c := &Consumer{
+				logger:   testLogger().WithField("pkg", "worker.Consumer"),
+				db:       store,
+				chain:    &testChain{nonce: tt.confirmed},
+				enqueuer: enqueuer,
+			}
+
+			c.fillNonceHoles(context.Background())
+
+			if !slices.Equal(store.deleted, tt.want) {
+				t.Errorf("dropped intents %v, want %v", store.deleted, tt.want)
+			}
+			if !slices.Equal(enqueuer.executed, tt.want) {
+				t.Errorf("re-enqueued %v, want %v", enqueuer.executed, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/hibiken/asynq"
+	"github.com/sirupsen/logrus"
+	vtypes "github.com/vultisig/verifier/types"
+)
+
+// errVaultResharing marks an execution skipped because the fee's vault is
+// being reshared. Keysign would run against a party set that is changing, so
+// the fee is left pending and resumed once the reshare finishes.
+var errVaultResharing = errors.New("vault reshare in progress")
+
+// WrapReshare wraps the reshare task handler so that fee executions for the
+// vault are paused while it runs, and queues the vault's pending fees again
+// afterwards with the vault's signers read afresh. A reshare keeps the vault's
+// public key, so addresses and intents built before it stay valid.
+func (c *Consumer) WrapReshare(next asynq.HandlerFunc) asynq.HandlerFunc {
+	return func(ctx context.Context, t *asynq.Task) error {
+		var req vtypes.ReshareRequest
+		err := json.Unmarshal(t.Payload(), &req)
+		if err != nil || req.PublicKey == "" {
+			// The reshare handler rejects the payload itself.
+			return next(ctx, t)
+		}
+
+		logger := c.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"public_key": req.PublicKey,
+			"plugin_id":  req.PluginID,
+			"session_id": req.SessionID,
+		})
+
+		err = c.db.StartVaultReshare(ctx, req.PublicKey, req.PluginID, req.SessionID)
+		if err != nil {
+			// The session will not wait for us, so run it unpaused.
+			logger.WithError(err).Error("failed to pause listing fees for reshare")
+		} else {
+			logger.Info("listing fees paused for vault reshare")
+		}
+
+		reshareErr := next(ctx, t)
+		if reshareErr != nil {
+			logger.WithError(reshareErr).Warn("vault reshare failed, resuming listing fees")
+		}
+		c.resumeAfterReshare(ctx, req, logger)
+		return reshareErr
+	}
+}
+
+// resumeAfterReshare runs whether or not the reshare succeeded. The vault's
+// backup is read back from storage first: while it cannot be loaded no keysign
+// can succeed, so the vault stays paused until the pause times out.
+func (c *Consumer) resumeAfterReshare(ctx context.Context, req vtypes.ReshareRequest, logger *logrus.Entry) {
+	c.signerService.ForgetParties(req.PublicKey)
+
+	err := c.addresses.CheckBackup(req.PublicKey, req.PluginID)
+	if err != nil {
+		logger.WithError(err).Error("vault backup unusable after reshare, listing fees stay paused")
+		return
+	}
+
+	err = c.db.FinishVaultReshare(ctx, req.PublicKey, req.SessionID)
+	if err != nil {
+		logger.WithError(err).Error("failed to lift reshare pause")
+		return
+	}
+
+	policyIDs, err := c.db.GetPendingPolicyIDsByPublicKey(ctx, req.PublicKey)
+	if err != nil {
+		logger.WithError(err).Error("failed to get pending listing fees after reshare")
+		return
+	}
+
+	for _, policyID := range policyIDs {
+		feeLogger := logger.WithField("policy_id", policyID)
+
+		err = c.enqueuer.EnqueueExecute(ctx, policyID)
+		if err != nil {
+			feeLogger.WithError(err).Error("failed to enqueue listing fee execution after reshare")
+			continue
+		}
+		feeLogger.Info("listing fee resumed after vault reshare")
+	}
+}
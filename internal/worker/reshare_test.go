@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	vtypes "github.com/vultisig/verifier/types"
+
+	"github.com/vultisig/app-developer/internal/config"
+	"github.com/vultisig/app-developer/spec"
+)
+
+// reshareStore records the reshare pause of one vault with pending fees.
+type reshareStore struct {
+	Store
+
+	pending  []uuid.UUID
+	paused   bool
+	finished bool
+}
+
+func (s *reshareStore) StartVaultReshare(context.Context, string, string, string) error {
+	s.paused = true
+	return nil
+}
+
+func (s *reshareStore) FinishVaultReshare(context.Context, string, string) error {
+	s.finished = true
+	return nil
+}
+
+func (s *reshareStore) GetPendingPolicyIDsByPublicKey(context.Context, string) ([]uuid.UUID, error) {
+	return s.pending, nil
+}
+
+// testBackups reports every vault backup as unusable with err, if set.
+type testBackups struct {
+	err     error
+	checked int
+}
+
+func (b *testBackups) DeriveAddress(context.Context, string, string) (ecommon.Address, error) {
+	return testVault, nil
+}
+
+func (b *testBackups) CheckBackup(string, string) error {
+	b.checked++
+	return b.err
+}
+
+func TestWrapReshareChecksBackup(t *testing.T) {
+	tests := []struct {
+		name        string
+		reshareErr  error
+		backupErr   error
+		wantResumed bool
+	}{
+		{name: "backup usable", wantResumed: true},
+		{name: "reshare failed, backup usable", reshareErr: errors.New("session timed out"), wantResumed: true},
+		{name: "backup unusable", backupErr: errors.New("failed to decrypt vault"), wantResumed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := newTestConsumer(t, testPolicy(t, 1), config.FeeConfig{})
+			store := &reshareStore{pending: []uuid.UUID{uuid.New(), uuid.New()}}
+			backups := &testBackups{err: tt.backupErr}
+			enqueuer := &testEnqueuer{}
+			tc.db, tc.addresses, tc.enqueuer = store, backups, enqueuer
+
+			payload, err := json.Marshal(vtypes.ReshareRequest{
+				PublicKey: testPublicKey,
+				PluginID:  spec.PluginDeveloper,
+				SessionID: uuid.NewString(),
+			})
+			if err != nil {
+				t.Fatalf("failed to marshal reshare request: %v", err)
+			}
+			handler := tc.WrapReshare(func(context.Context, *asynq.Task) error {
+				return tt.reshareErr
+			})
+			err = handler(context.Background(), asynq.NewTask("reshare", payload))
+			if !errors.Is(err, tt.reshareErr) {
+				t.Errorf("handler error = %v, want %v", err, tt.reshareErr)
+			}
+
+			if !store.paused {
+				t.Error("fees were not paused during the reshare")
+			}
+			if backups.checked != 1 {
+				t.Errorf("backup checked %d times, want once", backups.checked)
+			}
+			if store.finished != tt.wantResumed {
+				t.Errorf("pause lifted = %t, want %t", store.finished, tt.wantResumed)
+			}
+			var want []uuid.UUID
+			if tt.wantResumed {
+				want = store.pending
+			}
+			if !slices.Equal(enqueuer.executed, want) {
+				t.Errorf("re-enqueued %v, want %v", enqueuer.executed, want)
+			}
+		})
+	}
+}
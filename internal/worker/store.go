@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+
+	"github.com/vultisig/app-developer/internal/db"
+)
+
+// VaultAddresses resolves the address a vault pays from and checks the backup
+// it is derived from. It is satisfied by *evm.VaultAddressDeriver.
+type VaultAddresses interface {
+	DeriveAddress(ctx context.Context, publicKey, pluginID string) (ecommon.Address, error)
+	CheckBackup(publicKey, pluginID string) error
+}
+
+// Enqueuer schedules listing fee tasks. It is satisfied by *tasks.Enqueuer.
+type Enqueuer interface {
+	EnqueueCreate(ctx context.Context, policyID uuid.UUID) error
+	EnqueueExecute(ctx context.Context, policyID uuid.UUID) error
+	EnqueueSync(ctx context.Context, policyID uuid.UUID, delay time.Duration) error
+}
+
+// Store is the listing fee state the consumer works on. It is satisfied by
+// *db.PostgresBackend.
+type Store interface {
+	// Fees.
+	CreateListingFee(ctx context.Context, fee db.ListingFee, ttl time.Duration) (bool, error)
+	GetListingFeeByPolicyID(ctx context.Context, policyID uuid.UUID) (*db.ListingFee, error)
+	ClaimListingFee(ctx context.Context, policyID uuid.UUID, owner string, lease time.Duration) (*db.ListingFee, error)
+	RenewListingFeeLease(ctx context.Context, policyID uuid.UUID, owner string, lease time.Duration) (bool, error)
+	ReleaseListingFeeLease(ctx context.Context, policyID uuid.UUID, owner string) error
+	JoinListingFeeBatch(ctx context.Context, leadPolicyID uuid.UUID, publicKey string, maxFees int) ([]db.ListingFee, error)
+	MarkAsSubmitted(ctx context.Context, policyID uuid.UUID, txHash, endpoint string) error
+	MarkAsFailed(ctx context.Context, policyID uuid.UUID, reason string, details json.RawMessage) error
+	CancelOrphanedFee(ctx context.Context, policyID uuid.UUID) error
+	ExpireListingFee(ctx context.Context, policyID uuid.UUID) error
+	BackfillListingFeeExpiry(ctx context.Context, ttl time.Duration) (int64, error)
+	SyncSubmittedFee(ctx context.Context, policyID uuid.UUID) (paid bool, failed bool, err error)
+	RequeueLostFee(ctx context.Context, policyID uuid.UUID) (bool, error)
+	RecordListingFeeEvaluation(ctx context.Context, policyID uuid.UUID, allowed bool, report json.RawMessage) error
+
+	// Payment intents.
+	CreateListingFeeIntent(ctx context.Context, intent db.ListingFeeIntent) (*db.ListingFeeIntent, error)
+	GetListingFeeIntent(ctx context.Context, policyID uuid.UUID) (*db.ListingFeeIntent, error)
+	GetLiveIntentNonces(ctx context.Context) ([]db.IntentNonce, error)
+	RecordIntentSignedTx(ctx context.Context, policyID uuid.UUID, txHash string, signedTx []byte) error
+	RecordIntentBroadcastAttempt(ctx context.Context, policyID uuid.UUID) (int, error)
+	ReplaceIntentUnsignedTx(ctx context.Context, policyID uuid.UUID, unsignedTx, replacedSignedTx []byte) error
+	DeleteListingFeeIntent(ctx context.Context, policyID uuid.UUID) error
+	DeleteUnsignedListingFeeIntent(ctx context.Context, policyID uuid.UUID) (bool, error)
+
+	// Sweep queries.
+	GetUnprocessedPolicyIDs(ctx context.Context) ([]uuid.UUID, error)
+	GetOrphanedPendingPolicyIDs(ctx context.Context) ([]uuid.UUID, error)
+	GetExpiredPendingPolicyIDs(ctx context.Context) ([]uuid.UUID, error)
+	GetPaidActivePolicyIDs(ctx context.Context) ([]uuid.UUID, error)
+	GetPendingPolicyIDsByPublicKey(ctx context.Context, publicKey string) ([]uuid.UUID, error)
+	GetPendingListingFees(ctx context.Context) ([]db.ListingFee, error)
+	GetSubmittedListingFees(ctx context.Context) ([]db.ListingFee, error)
+	GetPendingListingFeeStats(ctx context.Context) (db.PendingStats, error)
+
+	// Policies and vaults.
+	DeactivatePolicy(ctx context.Context, policyID uuid.UUID, reason string) error
+	RecordPolicyIngestionError(ctx context.Context, policyID uuid.UUID, reason string) error
+	ListenPolicyCreated(ctx context.Context, fn func(policyID uuid.UUID)) error
+	IsVaultResharing(ctx context.Context, publicKey string, timeout time.Duration) (bool, error)
+	StartVaultReshare(ctx context.Context, publicKey, pluginID, sessionID string) error
+	FinishVaultReshare(ctx context.Context, publicKey, sessionID string) error
+}
@@ -2,6 +2,8 @@ package worker
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"time"
@@ -12,74 +14,140 @@ import (
 	"github.com/vultisig/app-developer/internal/config"
 	"github.com/vultisig/app-developer/internal/db"
 	"github.com/vultisig/app-developer/internal/evm"
-	"github.com/vultisig/mobile-tss-lib/tss"
-	evmsdk "github.com/vultisig/recipes/sdk/evm"
+	"github.com/vultisig/app-developer/internal/health"
+	"github.com/vultisig/app-developer/internal/logging"
+	"github.com/vultisig/app-developer/internal/metrics"
+	"github.com/vultisig/app-developer/internal/tracing"
+	"github.com/vultisig/app-developer/spec"
 	"github.com/vultisig/verifier/plugin/policy"
-	"github.com/vultisig/verifier/vault"
-	"github.com/vultisig/vultisig-go/address"
+	vtypes "github.com/vultisig/verifier/types"
 	vcommon "github.com/vultisig/vultisig-go/common"
 )
 
+// maxBroadcastAttempts is how many times one payment intent is broadcast before
+// each further failure is logged as an error for an operator. The fee itself
+// stays pending: a signed transaction may be mined at any time, so it only
+// fails once reconciliation sees its nonce spent by another transaction.
+const maxBroadcastAttempts = 5
+
+// errPaymentUnresolved marks failures after which a payment may already be on
+// the network. Such fees are left pending and reconciled on the next cycle.
+var errPaymentUnresolved = errors.New("payment outcome unresolved")
+
+// errInvalidPolicyConfig marks policies that can never produce a listing fee.
+// They are recorded for the developer instead of being retried.
+var errInvalidPolicyConfig = errors.New("invalid policy configuration")
+
+// errExecutionAborted marks an execution stopped because the fee was cancelled
+// before anything was broadcast.
+var errExecutionAborted = errors.New("listing fee execution aborted")
+
+// errDryRun marks an execution that stopped after simulating the payment
+// because the worker runs in dry-run mode. The fee is left pending.
+var errDryRun = errors.New("listing fee dry run")
+
 type Consumer struct {
-	logger        *logrus.Logger
+	logger        *logrus.Entry
 	policySvc     policy.Service
 	signerService *evm.SignerService
-	sdk           *evmsdk.SDK
-	db            *db.PostgresBackend
-	vaultStorage  vault.Storage
-	vaultSecret   string
+	chain         evm.TxLookupClient
+	db            Store
+	addresses     VaultAddresses
 	feeConfig     config.FeeConfig
+	metrics       metrics.ListingFeeMetrics
+	heartbeat     *health.Heartbeat
+	workerConfig  config.WorkerConfig
+	leader        LeaderElector
+	enqueuer      Enqueuer
 }
 
 func NewConsumer(
 	logger *logrus.Logger,
 	policySvc policy.Service,
 	signerService *evm.SignerService,
-	sdk *evmsdk.SDK,
-	database *db.PostgresBackend,
-	vaultStorage vault.Storage,
-	vaultSecret string,
+	chain evm.TxLookupClient,
+	database Store,
+	addresses VaultAddresses,
 	feeConfig config.FeeConfig,
+	feeMetrics metrics.ListingFeeMetrics,
+	heartbeat *health.Heartbeat,
+	workerConfig config.WorkerConfig,
+	leader LeaderElector,
+	enqueuer Enqueuer,
 ) *Consumer {
 	return &Consumer{
-		logger:        logger.WithField("pkg", "worker.Consumer").Logger,
+		logger:        logger.WithField("pkg", "worker.Consumer"),
 		policySvc:     policySvc,
 		signerService: signerService,
-		sdk:           sdk,
+		chain:         chain,
 		db:            database,
-		vaultStorage:  vaultStorage,
-		vaultSecret:   vaultSecret,
+		addresses:     addresses,
 		feeConfig:     feeConfig,
+		metrics:       feeMetrics,
+		heartbeat:     heartbeat,
+		workerConfig:  workerConfig,
+		leader:        leader,
+		enqueuer:      enqueuer,
 	}
 }
 
+// DefaultSweepInterval is used by Run when no interval is configured.
+const DefaultSweepInterval = 30 * time.Second
+
+// Run periodically sweeps for work the task queue may have missed: new
+// policies, fees left pending or submitted, and paid policies to deactivate.
+// The fees themselves are processed by the asynq task handlers.
 func (c *Consumer) Run(ctx context.Context, interval time.Duration) {
 	if interval == 0 {
-		interval = 30 * time.Second
+		interval = DefaultSweepInterval
 	}
-	c.logger.WithField("interval", interval).Info("listing fee processor started")
+	c.logger.WithField("interval", interval).Info("listing fee reconciliation sweep started")
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			c.process(ctx)
+			c.sweep(ctx)
+			c.heartbeat.Beat()
 		case <-ctx.Done():
-			c.logger.Info("listing fee processor stopped")
+			c.logger.Info("listing fee reconciliation sweep stopped")
 			return
 		}
 	}
 }
 
-func (c *Consumer) process(ctx context.Context) {
-	c.createListingFeesForNewPolicies(ctx)
-	c.executePendingFees(ctx)
-	c.syncSubmittedFees(ctx)
-	c.deactivatePaidPolicies(ctx)
+func (c *Consumer) sweep(ctx context.Context) {
+	ctx, span := tracing.Start(ctx, "worker.sweep")
+	defer span.End()
+
+	// Every stage acts on all fees at once, so only the leader sweeps.
+	leader, err := c.leader.IsLeader(ctx)
+	if err != nil {
+		c.logger.WithError(err).Error("failed to check leadership")
+		return
+	}
+	if !leader {
+		return
+	}
+
+	c.runStage(ctx, "enqueueNewPolicies", c.enqueueNewPolicies)
+	c.runStage(ctx, "cancelOrphanedFees", c.cancelOrphanedFees)
+	c.runStage(ctx, "expireStaleFees", c.expireStaleFees)
+	c.runStage(ctx, "fillNonceHoles", c.fillNonceHoles)
+	c.runStage(ctx, "enqueuePendingFees", c.enqueuePendingFees)
+	c.runStage(ctx, "enqueueSubmittedFees", c.enqueueSubmittedFees)
+	c.runStage(ctx, "deactivatePaidPolicies", c.deactivatePaidPolicies)
+	c.runStage(ctx, "reportPendingQueue", c.reportPendingQueue)
+}
+
+func (c *Consumer) runStage(ctx context.Context, name string, stage func(context.Context)) {
+	ctx, span := tracing.Start(ctx, "worker."+name)
+	defer span.End()
+	stage(ctx)
 }
 
-func (c *Consumer) createListingFeesForNewPolicies(ctx context.Context) {
+func (c *Consumer) enqueueNewPolicies(ctx context.Context) {
 	policyIDs, err := c.db.GetUnprocessedPolicyIDs(ctx)
 	if err != nil {
 		c.logger.WithError(err).Error("failed to get unprocessed policies")
@@ -87,9 +155,98 @@ func (c *Consumer) createListingFeesForNewPolicies(ctx context.Context) {
 	}
 
 	for _, policyID := range policyIDs {
-		err = c.createListingFee(ctx, policyID)
+		err = c.enqueuer.EnqueueCreate(ctx, policyID)
 		if err != nil {
-			c.logger.WithError(err).WithField("policy_id", policyID).Error("failed to create listing fee")
+			c.logger.WithError(err).WithField("policy_id", policyID).Error("failed to enqueue listing fee creation")
+		}
+	}
+}
+
+// cancelOrphanedFees cancels pending fees whose policy the developer
+// deactivated or deleted, so they are never signed.
+func (c *Consumer) cancelOrphanedFees(ctx context.Context) {
+	policyIDs, err := c.db.GetOrphanedPendingPolicyIDs(ctx)
+	if err != nil {
+		c.logger.WithError(err).Error("failed to get orphaned pending fees")
+		return
+	}
+
+	for _, policyID := range policyIDs {
+		logger := c.logger.WithContext(logging.WithCorrelationID(ctx, policyID.String())).WithField("policy_id", policyID)
+		err = c.db.CancelOrphanedFee(ctx, policyID)
+		if errors.Is(err, db.ErrListingFeeStateConflict) {
+			logger.Warn("policy inactive but payment may be in flight, leaving fee to reconciliation")
+			continue
+		}
+		if err != nil {
+			logger.WithError(err).Error("failed to cancel orphaned listing fee")
+			continue
+		}
+		c.metrics.RecordTransition("pending", "cancelled", 1)
+		logger.Info("listing fee cancelled (policy inactive or deleted)")
+	}
+}
+
+// expireStaleFees expires pending fees past their payment window so a quote
+// made at an old price is never executed, and deactivates their policies.
+func (c *Consumer) expireStaleFees(ctx context.Context) {
+	if c.feeConfig.PendingTTL > 0 {
+		backfilled, err := c.db.BackfillListingFeeExpiry(ctx, c.feeConfig.PendingTTL)
+		if err != nil {
+			c.logger.WithError(err).Error("failed to backfill listing fee expiry")
+		} else if backfilled > 0 {
+			c.logger.WithField("count", backfilled).Info("backfilled expiry of pending listing fees")
+		}
+	}
+
+	policyIDs, err := c.db.GetExpiredPendingPolicyIDs(ctx)
+	if err != nil {
+		c.logger.WithError(err).Error("failed to get expired pending fees")
+		return
+	}
+
+	for _, policyID := range policyIDs {
+		logger := c.logger.WithContext(logging.WithCorrelationID(ctx, policyID.String())).WithField("policy_id", policyID)
+		err = c.db.ExpireListingFee(ctx, policyID)
+		if errors.Is(err, db.ErrListingFeeStateConflict) {
+			logger.Warn("listing fee overdue but payment may be in flight, leaving fee to reconciliation")
+			continue
+		}
+		if err != nil {
+			logger.WithError(err).Error("failed to expire listing fee")
+			continue
+		}
+		c.metrics.RecordTransition("pending", "expired", 1)
+		logger.Info("listing fee expired")
+	}
+}
+
+func (c *Consumer) enqueuePendingFees(ctx context.Context) {
+	fees, err := c.db.GetPendingListingFees(ctx)
+	if err != nil {
+		c.logger.WithError(err).Error("failed to get pending listing fees")
+		return
+	}
+
+	for _, fee := range fees {
+		err = c.enqueuer.EnqueueExecute(ctx, fee.PolicyID)
+		if err != nil {
+			c.logger.WithError(err).WithField("policy_id", fee.PolicyID).Error("failed to enqueue listing fee execution")
+		}
+	}
+}
+
+func (c *Consumer) enqueueSubmittedFees(ctx context.Context) {
+	fees, err := c.db.GetSubmittedListingFees(ctx)
+	if err != nil {
+		c.logger.WithError(err).Error("failed to get submitted listing fees")
+		return
+	}
+
+	for _, fee := range fees {
+		err = c.enqueuer.EnqueueSync(ctx, fee.PolicyID, 0)
+		if err != nil {
+			c.logger.WithError(err).WithField("policy_id", fee.PolicyID).Error("failed to enqueue listing fee sync")
 		}
 	}
 }
@@ -102,13 +259,13 @@ func (c *Consumer) createListingFee(ctx context.Context, policyID uuid.UUID) err
 
 	recipe, err := pol.GetRecipe()
 	if err != nil {
-		return fmt.Errorf("failed to get recipe: %w", err)
+		return fmt.Errorf("%w: failed to get recipe: %v", errInvalidPolicyConfig, err)
 	}
 
 	cfgMap := recipe.GetConfiguration().AsMap()
 	targetPluginID, ok := cfgMap["targetPluginId"].(string)
 	if !ok || targetPluginID == "" {
-		return fmt.Errorf("missing targetPluginId in configuration")
+		return fmt.Errorf("%w: missing targetPluginId in configuration", errInvalidPolicyConfig)
 	}
 
 	amount := new(big.Int)
@@ -123,12 +280,17 @@ func (c *Consumer) createListingFee(ctx context.Context, policyID uuid.UUID) err
 		Status:         "pending",
 	}
 
-	err = c.db.CreateListingFee(ctx, fee)
+	created, err := c.db.CreateListingFee(ctx, fee, c.feeConfig.PendingTTL)
 	if err != nil {
 		return fmt.Errorf("failed to create listing fee: %w", err)
 	}
+	if !created {
+		// A redelivered task or a racing sweep got there first.
+		return nil
+	}
 
-	c.logger.WithFields(logrus.Fields{
+	c.metrics.RecordTransition("none", "pending", 1)
+	c.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"policy_id":        policyID,
 		"target_plugin_id": targetPluginID,
 	}).Info("listing fee created")
@@ -136,20 +298,6 @@ func (c *Consumer) createListingFee(ctx context.Context, policyID uuid.UUID) err
 	return nil
 }
 
-func (c *Consumer) syncSubmittedFees(ctx context.Context) {
-	paid, failed, err := c.db.SyncSubmittedFees(ctx)
-	if err != nil {
-		c.logger.WithError(err).Error("failed to sync submitted fees")
-		return
-	}
-	if paid > 0 || failed > 0 {
-		c.logger.WithFields(logrus.Fields{
-			"paid":   paid,
-			"failed": failed,
-		}).Info("synced submitted fees from tx_indexer")
-	}
-}
-
 // deactivatePaidPolicies marks policies as inactive once their listing fee is paid.
 // This also prevents charging a user twice: if a duplicate policy is created for the
 // same plugin, the paid policy is deactivated before the duplicate can be executed.
@@ -161,34 +309,134 @@ func (c *Consumer) deactivatePaidPolicies(ctx context.Context) {
 	}
 
 	for _, policyID := range policyIDs {
+		logger := c.logger.WithContext(logging.WithCorrelationID(ctx, policyID.String()))
 		err = c.db.DeactivatePolicy(ctx, policyID, "completed")
 		if err != nil {
-			c.logger.WithError(err).WithField("policy_id", policyID).Error("failed to deactivate policy")
+			logger.WithError(err).WithField("policy_id", policyID).Error("failed to deactivate policy")
 			continue
 		}
-		c.logger.WithField("policy_id", policyID).Info("policy deactivated (listing fee paid)")
+		logger.WithField("policy_id", policyID).Info("policy deactivated (listing fee paid)")
 	}
 }
 
-func (c *Consumer) executePendingFees(ctx context.Context) {
-	fees, err := c.db.GetPendingListingFees(ctx)
-	if err != nil {
-		c.logger.WithError(err).Error("failed to get pending listing fees")
+// executeLeased runs one claimed fee and hands its lease back, renewing it for
+// as long as the execution runs. If the worker dies first the lease simply
+// expires; the fee's payment intent keeps a second worker from paying twice.
+// Only errors worth retrying are returned.
+func (c *Consumer) executeLeased(ctx context.Context, fee db.ListingFee) error {
+	defer func() {
+		err := c.db.ReleaseListingFeeLease(ctx, fee.PolicyID, c.workerConfig.ID)
+		if err != nil {
+			c.logger.WithError(err).WithField("policy_id", fee.PolicyID).Error("failed to release listing fee lease")
+		}
+	}()
+
+	leaseCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go c.renewLease(leaseCtx, fee.PolicyID, cancel)
+
+	start := time.Now()
+	execCtx, span := tracing.Start(leaseCtx, "worker.execute",
+		tracing.AttrPolicyID.String(fee.PolicyID.String()),
+		tracing.AttrTargetPluginID.String(fee.TargetPluginID),
+	)
+	executeErr := c.execute(execCtx, fee.PolicyID)
+	tracing.End(span, executeErr)
+	if errors.Is(executeErr, errDryRun) {
+		return nil
+	}
+	if errors.Is(executeErr, errVaultResharing) {
+		c.logger.WithContext(execCtx).WithField("policy_id", fee.PolicyID).Info("vault reshare in progress, listing fee paused")
+		return nil
+	}
+	if errors.Is(executeErr, errExecutionAborted) {
+		c.logger.WithContext(execCtx).WithError(executeErr).WithField("policy_id", fee.PolicyID).Info("listing fee cancelled, execution aborted")
+		return nil
+	}
+	if errors.Is(executeErr, errPaymentUnresolved) {
+		c.metrics.ObserveExecute(time.Since(start), metrics.ResultError)
+		c.logger.WithContext(execCtx).WithError(executeErr).WithField("policy_id", fee.PolicyID).Warn("listing fee payment unresolved, will reconcile")
+		return executeErr
+	}
+	if executeErr != nil && leaseCtx.Err() != nil {
+		// Cut short by a lost lease or shutdown, not by the fee itself.
+		c.metrics.ObserveExecute(time.Since(start), metrics.ResultError)
+		return fmt.Errorf("listing fee execution interrupted: %w", executeErr)
+	}
+	if executeErr != nil {
+		c.metrics.ObserveExecute(time.Since(start), metrics.ResultError)
+		c.logger.WithContext(execCtx).WithError(executeErr).WithField("policy_id", fee.PolicyID).Error("failed to execute listing fee")
+		markErr := c.db.MarkAsFailed(execCtx, fee.PolicyID, executeErr.Error(), c.failureDetails(executeErr))
+		if errors.Is(markErr, db.ErrListingFeeStateConflict) {
+			// A signed payment is on record and may still be mined.
+			c.logger.WithContext(execCtx).WithField("policy_id", fee.PolicyID).Warn("listing fee has a signed payment, leaving it to reconciliation")
+			return fmt.Errorf("%w: %v", errPaymentUnresolved, executeErr)
+		}
+		if markErr != nil {
+			c.logger.WithContext(execCtx).WithError(markErr).Error("failed to mark listing fee as failed")
+			return markErr
+		}
+		c.metrics.RecordTransition("pending", "failed", 1)
+		return nil
+	}
+	c.metrics.ObserveExecute(time.Since(start), metrics.ResultSuccess)
+	c.metrics.RecordTransition("pending", "submitted", 1)
+	return nil
+}
+
+// renewLease extends the fee's lease every third of its duration until ctx is
+// done. A lease lost to another worker cancels the execution, which would
+// otherwise race the new owner.
+func (c *Consumer) renewLease(ctx context.Context, policyID uuid.UUID, cancel context.CancelFunc) {
+	if c.workerConfig.LeaseDuration <= 0 {
 		return
 	}
+	logger := c.logger.WithContext(ctx).WithField("policy_id", policyID)
+	ticker := time.NewTicker(c.workerConfig.LeaseDuration / 3)
+	defer ticker.Stop()
 
-	for _, fee := range fees {
-		executeErr := c.execute(ctx, fee.PolicyID)
-		if executeErr != nil {
-			c.logger.WithError(executeErr).WithField("policy_id", fee.PolicyID).Error("failed to execute listing fee")
-			markErr := c.db.MarkAsFailed(ctx, fee.PolicyID, executeErr.Error())
-			if markErr != nil {
-				c.logger.WithError(markErr).Error("failed to mark listing fee as failed")
+	for {
+		select {
+		case <-ticker.C:
+			held, err := c.db.RenewListingFeeLease(ctx, policyID, c.workerConfig.ID, c.workerConfig.LeaseDuration)
+			if err != nil {
+				logger.WithError(err).Warn("failed to renew listing fee lease")
+				continue
+			}
+			if !held {
+				logger.Error("listing fee lease lost, aborting execution")
+				cancel()
+				return
 			}
+		case <-ctx.Done():
+			return
 		}
 	}
 }
 
+// failureDetails returns the structured diagnostics carried by err, if any.
+func (c *Consumer) failureDetails(err error) json.RawMessage {
+	var keysignErr *evm.KeysignFailure
+	if !errors.As(err, &keysignErr) {
+		return nil
+	}
+	details, marshalErr := json.Marshal(keysignErr)
+	if marshalErr != nil {
+		c.logger.WithError(marshalErr).Error("failed to marshal keysign failure details")
+		return nil
+	}
+	return details
+}
+
+func (c *Consumer) reportPendingQueue(ctx context.Context) {
+	stats, err := c.db.GetPendingListingFeeStats(ctx)
+	if err != nil {
+		c.logger.WithError(err).Error("failed to get pending listing fee stats")
+		return
+	}
+	c.metrics.SetPendingQueue(stats.Count, stats.OldestAge)
+}
+
 func (c *Consumer) execute(ctx context.Context, policyID uuid.UUID) error {
 	fee, err := c.db.GetListingFeeByPolicyID(ctx, policyID)
 	if err != nil {
@@ -205,58 +453,408 @@ func (c *Consumer) execute(ctx context.Context, policyID uuid.UUID) error {
 	if err != nil {
 		return fmt.Errorf("failed to get policy: %w", err)
 	}
+	if !pol.Active {
+		err = c.db.CancelOrphanedFee(ctx, policyID)
+		if err == nil {
+			return fmt.Errorf("%w: policy is no longer active", errExecutionAborted)
+		}
+		// A conflict means a payment may be in flight; let the intent settle it.
+		if !errors.Is(err, db.ErrListingFeeStateConflict) {
+			return err
+		}
+	}
+
+	resharing, err := c.db.IsVaultResharing(ctx, fee.PublicKey, c.workerConfig.ResharePauseTimeout)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPaymentUnresolved, err)
+	}
+	if resharing {
+		return errVaultResharing
+	}
+
+	if c.workerConfig.DryRun {
+		return c.dryRun(ctx, fee, *pol)
+	}
+
+	intent, err := c.db.GetListingFeeIntent(ctx, policyID)
+	if err != nil {
+		return fmt.Errorf("failed to get listing fee intent: %w", err)
+	}
+	if intent != nil {
+		done, err := c.reconcileIntent(ctx, intent)
+		if err != nil || done {
+			return err
+		}
+		intent, err = c.db.GetListingFeeIntent(ctx, policyID)
+		if err != nil {
+			return fmt.Errorf("failed to get listing fee intent: %w", err)
+		}
+	}
+
+	if intent == nil {
+		// Only a fee with nothing in flight can expire; an existing intent is
+		// always settled first.
+		if fee.ExpiresAt != nil && time.Now().After(*fee.ExpiresAt) {
+			err = c.db.ExpireListingFee(ctx, policyID)
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("%w: payment window elapsed", errExecutionAborted)
+		}
+		intent, err = c.createIntent(ctx, fee, *pol)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.signAndBroadcast(ctx, *pol, intent)
+}
+
+// dryRun processes the fee as execute would up to simulation: the payment is
+// built and evaluated against the policy, then run through eth_call and
+// eth_estimateGas. Nothing is reserved, persisted or signed, so the fee stays
+// pending and no nonce or keysign session is spent on it.
+func (c *Consumer) dryRun(ctx context.Context, fee *db.ListingFee, pol vtypes.PluginPolicy) error {
+	intent, err := c.db.GetListingFeeIntent(ctx, fee.PolicyID)
+	if err != nil {
+		return fmt.Errorf("failed to get listing fee intent: %w", err)
+	}
+	if intent != nil {
+		// Left by a run with dry-run mode off; it may be on the network.
+		c.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"policy_id": fee.PolicyID,
+			"nonce":     intent.Nonce,
+		}).Warn("dry run: fee has a payment intent in flight, skipped")
+		return errDryRun
+	}
+
+	err = c.simulate(ctx, fee, pol)
+	if err != nil {
+		return err
+	}
+	return errDryRun
+}
 
-	fromAddr, err := c.deriveAddress(pol.PublicKey, pol.PluginID.String())
+// simulate dry-runs the fee's payment without reserving, building an intent
+// for or signing it, and logs what would have happened.
+func (c *Consumer) simulate(ctx context.Context, fee *db.ListingFee, pol vtypes.PluginPolicy) error {
+	fromAddr, err := c.senderAddress(ctx, pol)
 	if err != nil {
-		return fmt.Errorf("failed to derive sender address: %w", err)
+		return err
+	}
+
+	res, err := c.signerService.Simulate(
+		ctx,
+		pol,
+		fromAddr,
+		ecommon.HexToAddress(fee.Destination),
+		ecommon.HexToAddress(c.feeConfig.VultTokenAddress),
+		fee.Amount,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to simulate payment: %w", err)
+	}
+
+	logger := c.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"policy_id":      fee.PolicyID,
+		"from_address":   fromAddr.Hex(),
+		"amount":         fee.Amount.String(),
+		"nonce":          res.Nonce,
+		"policy_allowed": res.PolicyAllowed,
+		"call_succeeded": res.CallSucceeded,
+		"gas_estimate":   res.GasEstimate,
+	})
+	switch {
+	case res.BuildError != "":
+		logger.WithField("build_error", res.BuildError).Warn("dry run: payment could not be built")
+	case !res.PolicyAllowed || !res.CallSucceeded || res.EstimateError != "":
+		logger.WithFields(logrus.Fields{
+			"policy_error":   res.PolicyError,
+			"call_error":     res.CallError,
+			"estimate_error": res.EstimateError,
+		}).Warn("dry run: payment would fail")
+	default:
+		logger.Info("dry run: payment would succeed, not signed or broadcast")
+	}
+	return nil
+}
+
+// senderAddress derives the vault's address and checks it is the from_address
+// the developer configured, so a mismatched policy fails before anything is
+// built or signed.
+func (c *Consumer) senderAddress(ctx context.Context, pol vtypes.PluginPolicy) (ecommon.Address, error) {
+	recipe, err := pol.GetRecipe()
+	if err != nil {
+		return ecommon.Address{}, fmt.Errorf("failed to get recipe: %w", err)
+	}
+	configured, err := spec.FromAddress(recipe)
+	if err != nil {
+		return ecommon.Address{}, err
+	}
+
+	derived, err := c.addresses.DeriveAddress(ctx, pol.PublicKey, pol.PluginID.String())
+	if err != nil {
+		return ecommon.Address{}, fmt.Errorf("failed to derive sender address: %w", err)
+	}
+	if derived != configured {
+		return ecommon.Address{}, fmt.Errorf("vault address %s does not match configured from_address %s", derived.Hex(), configured.Hex())
+	}
+	return derived, nil
+}
+
+// createIntent builds the fee's transfer and persists it, with its nonce,
+// before anything is signed.
+func (c *Consumer) createIntent(ctx context.Context, fee *db.ListingFee, pol vtypes.PluginPolicy) (*db.ListingFeeIntent, error) {
+	fromAddr, err := c.senderAddress(ctx, pol)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := c.batchAmount(ctx, fee, pol)
+	if err != nil {
+		return nil, err
 	}
 
 	toAddr := ecommon.HexToAddress(fee.Destination)
 	tokenAddr := ecommon.HexToAddress(c.feeConfig.VultTokenAddress)
 
-	unsignedTx, err := c.sdk.MakeTxTransferERC20(ctx, fromAddr, toAddr, tokenAddr, fee.Amount, 0)
+	unsignedTx, nonce, err := c.signerService.BuildTransfer(ctx, fee.PolicyID, fromAddr, toAddr, tokenAddr, amount)
 	if err != nil {
-		return fmt.Errorf("failed to build ERC-20 transfer: %w", err)
+		return nil, err
 	}
 
-	txHash, err := c.signerService.SignAndBroadcast(ctx, vcommon.Ethereum, *pol, unsignedTx)
+	intent, err := c.db.CreateListingFeeIntent(ctx, db.ListingFeeIntent{
+		PolicyID:    fee.PolicyID,
+		FromAddress: fromAddr.Hex(),
+		Nonce:       nonce,
+		UnsignedTx:  unsignedTx,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to sign and broadcast: %w", err)
+		return nil, fmt.Errorf("failed to persist payment intent: %w", err)
+	}
+	return intent, nil
+}
+
+// batchAmount returns what the fee's transfer pays: its own amount plus that
+// of the vault's other pending fees it takes into its batch, as many as the
+// policy's amount cap and MaxBatchSize allow. The batch is fixed before the
+// transfer is built, and its fees cannot be cancelled while the transfer is
+// in flight.
+func (c *Consumer) batchAmount(ctx context.Context, fee *db.ListingFee, pol vtypes.PluginPolicy) (*big.Int, error) {
+	if c.feeConfig.MaxBatchSize <= 1 || fee.Amount.Sign() <= 0 {
+		return fee.Amount, nil
 	}
 
-	err = c.db.MarkAsSubmitted(ctx, policyID, txHash)
+	recipe, err := pol.GetRecipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recipe: %w", err)
+	}
+	limit, err := spec.PaymentLimit(recipe)
 	if err != nil {
-		return fmt.Errorf("failed to mark as submitted: %w", err)
+		return nil, fmt.Errorf("%w: %v", errInvalidPolicyConfig, err)
+	}
+	// A policy created before batching was enabled only allows its own fee.
+	maxFees := min(new(big.Int).Quo(limit, fee.Amount).Int64(), int64(c.feeConfig.MaxBatchSize)) - 1
+	if maxFees <= 0 {
+		return fee.Amount, nil
 	}
 
-	c.logger.WithFields(logrus.Fields{
-		"policy_id": policyID,
-		"tx_hash":   txHash,
-	}).Info("listing fee payment submitted")
+	batch, err := c.db.JoinListingFeeBatch(ctx, fee.PolicyID, fee.PublicKey, int(maxFees))
+	if err != nil {
+		return nil, err
+	}
 
-	return nil
+	total := new(big.Int).Set(fee.Amount)
+	for _, covered := range batch {
+		total.Add(total, covered.Amount)
+	}
+	if total.Cmp(limit) > 0 {
+		return nil, fmt.Errorf("batch total %s exceeds the policy's limit %s", total, limit)
+	}
+	if len(batch) > 0 {
+		c.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"policy_id":  fee.PolicyID,
+			"batch_size": len(batch) + 1,
+			"amount":     total.String(),
+		}).Info("paying pending listing fees from the same vault in one transfer")
+	}
+	return total, nil
 }
 
-func (c *Consumer) deriveAddress(publicKey string, pluginID string) (ecommon.Address, error) {
-	vaultContent, err := c.vaultStorage.GetVault(vcommon.GetVaultBackupFilename(publicKey, pluginID))
+// reconcileIntent resolves an intent left behind by an earlier attempt. It
+// returns done=true when the fee needs no new broadcast; done=false with the
+// intent still present means its nonce is free and it should be sent again,
+// and with the intent deleted means a fresh intent must be built.
+func (c *Consumer) reconcileIntent(ctx context.Context, intent *db.ListingFeeIntent) (bool, error) {
+	logger := c.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"policy_id": intent.PolicyID,
+		"nonce":     intent.Nonce,
+	})
+
+	// The confirmed nonce is read before the hashes are looked up, so a payment
+	// mined in between is found rather than mistaken for another transaction.
+	confirmed, err := c.chain.NonceAt(ctx, ecommon.HexToAddress(intent.FromAddress), nil)
+	if err != nil {
+		return false, fmt.Errorf("%w: failed to get confirmed nonce: %v", errPaymentUnresolved, err)
+	}
+
+	state, err := evm.LookupTransactions(ctx, c.chain, intent.TxHashes)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", errPaymentUnresolved, err)
+	}
+
+	if state.Found && !state.Reverted {
+		logger.WithField("tx_hash", state.Hash).Info("reconciled payment intent with known transaction")
+		// Which endpoint accepted it, if this worker ever sent it, is not
+		// known here; any endpoint already on record is kept.
+		return true, c.markSubmitted(ctx, intent.PolicyID, state.Hash, "")
+	}
+
+	if state.Found && state.Reverted {
+		// A reverted transfer moved no tokens, so the fee can be paid afresh.
+		logger.WithField("tx_hash", state.Hash).Warn("payment intent transaction reverted, discarding intent")
+		return false, c.db.DeleteListingFeeIntent(ctx, intent.PolicyID)
+	}
+
+	if confirmed > intent.Nonce {
+		// None of the intent's hashes was mined at a nonce that is now spent,
+		// so none of them ever can be.
+		err = c.db.DeleteListingFeeIntent(ctx, intent.PolicyID)
+		if err != nil {
+			return false, err
+		}
+		return false, fmt.Errorf("nonce %d was consumed by a transaction not signed for this fee", intent.Nonce)
+	}
+
+	if intent.SignedTx != nil {
+		logger.WithField("attempts", intent.BroadcastAttempts).Info("payment intent not on-chain, rebroadcasting signed tx")
+	} else {
+		logger.Info("payment intent not on-chain, signing with the same nonce")
+	}
+	return false, nil
+}
+
+// signAndBroadcast signs the intent's transaction unless a signed copy is
+// already on record, then broadcasts it. A failed broadcast is retried with the
+// stored bytes, so keysign runs once per intent unless the transaction is
+// refused as underpriced and has to be repriced.
+func (c *Consumer) signAndBroadcast(ctx context.Context, pol vtypes.PluginPolicy, intent *db.ListingFeeIntent) error {
+	signedTx := intent.SignedTx
+	if signedTx == nil {
+		tx, report, err := c.signerService.Sign(ctx, vcommon.Ethereum, pol, ecommon.HexToAddress(intent.FromAddress), intent.UnsignedTx)
+		if report != nil {
+			c.recordEvaluation(ctx, intent.PolicyID, report)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to sign: %w", err)
+		}
+		signedTx, err = tx.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("failed to encode signed tx: %w", err)
+		}
+		err = c.db.RecordIntentSignedTx(ctx, intent.PolicyID, tx.Hash().Hex(), signedTx)
+		if errors.Is(err, db.ErrListingFeeStateConflict) {
+			return fmt.Errorf("%w: %v", errExecutionAborted, err)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to persist signed tx: %w", err)
+		}
+
+		// A reshare may have started while keysign ran. The signed bytes are on
+		// record, so the broadcast waits for the reshare to finish like any
+		// other execution of the vault's fees.
+		resharing, err := c.db.IsVaultResharing(ctx, pol.PublicKey, c.workerConfig.ResharePauseTimeout)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errPaymentUnresolved, err)
+		}
+		if resharing {
+			return errVaultResharing
+		}
+	}
+
+	attempts, err := c.db.RecordIntentBroadcastAttempt(ctx, intent.PolicyID)
+	if errors.Is(err, db.ErrListingFeeStateConflict) {
+		return fmt.Errorf("%w: %v", errExecutionAborted, err)
+	}
 	if err != nil {
-		return ecommon.Address{}, fmt.Errorf("failed to get vault content: %w", err)
+		return fmt.Errorf("%w: %v", errPaymentUnresolved, err)
 	}
 
-	vlt, err := vcommon.DecryptVaultFromBackup(c.vaultSecret, vaultContent)
+	txHash, endpoint, err := c.signerService.Broadcast(ctx, pol, signedTx)
+	if err != nil && evm.ClassifyBroadcastError(err) == evm.BroadcastErrUnderpriced {
+		// The same bytes would be refused forever, so the next attempt signs a
+		// repriced transaction for the same nonce.
+		return c.repriceIntent(ctx, intent, signedTx, err)
+	}
 	if err != nil {
-		return ecommon.Address{}, fmt.Errorf("failed to decrypt vault: %w", err)
+		// Once signed the transaction may have reached the network, so the fee
+		// stays pending for reconciliation rather than failing, however often
+		// the broadcast fails. Every retry sends the same signed bytes, so at
+		// most one can be mined.
+		if attempts >= maxBroadcastAttempts {
+			c.logger.WithContext(ctx).WithError(err).WithFields(logrus.Fields{
+				"policy_id": intent.PolicyID,
+				"attempts":  attempts,
+			}).Error("payment still not broadcast, needs operator attention")
+		}
+		return fmt.Errorf("%w: failed to broadcast: %v", errPaymentUnresolved, err)
 	}
 
-	childPub, err := tss.GetDerivedPubKey(publicKey, vlt.GetHexChainCode(), vcommon.Ethereum.GetDerivePath(), false)
+	return c.markSubmitted(ctx, intent.PolicyID, txHash, endpoint)
+}
+
+// repriceIntent replaces an underpriced intent's transaction with one paying
+// higher fees at the same nonce. The fee stays pending and is signed again on
+// its next attempt; the old hash stays on record in case it is mined after all.
+func (c *Consumer) repriceIntent(ctx context.Context, intent *db.ListingFeeIntent, signedTx []byte, broadcastErr error) error {
+	repriced, err := evm.RepriceUnsignedTx(intent.UnsignedTx)
 	if err != nil {
-		return ecommon.Address{}, fmt.Errorf("failed to get derived pubkey: %w", err)
+		return fmt.Errorf("%w: failed to reprice underpriced tx: %v", errPaymentUnresolved, err)
 	}
+	err = c.db.ReplaceIntentUnsignedTx(ctx, intent.PolicyID, repriced, signedTx)
+	if errors.Is(err, db.ErrListingFeeStateConflict) {
+		return fmt.Errorf("%w: %v", errExecutionAborted, err)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPaymentUnresolved, err)
+	}
+
+	c.logger.WithContext(ctx).WithError(broadcastErr).WithFields(logrus.Fields{
+		"policy_id": intent.PolicyID,
+		"nonce":     intent.Nonce,
+	}).Warn("payment underpriced, repriced for re-signing at the same nonce")
+	return fmt.Errorf("%w: failed to broadcast: %v", errPaymentUnresolved, broadcastErr)
+}
 
-	addr, err := address.GetEVMAddress(childPub)
+// recordEvaluation stores the recipe evaluation of a payment for reviewers.
+// Failing to store it does not hold up the payment.
+func (c *Consumer) recordEvaluation(ctx context.Context, policyID uuid.UUID, report *evm.EvaluationReport) {
+	logger := c.logger.WithContext(ctx).WithField("policy_id", policyID)
+
+	raw, err := json.Marshal(report)
+	if err != nil {
+		logger.WithError(err).Error("failed to encode evaluation report")
+		return
+	}
+	err = c.db.RecordListingFeeEvaluation(ctx, policyID, report.Allowed, raw)
+	if err != nil {
+		logger.WithError(err).Error("failed to record evaluation report")
+	}
+}
+
+func (c *Consumer) markSubmitted(ctx context.Context, policyID uuid.UUID, txHash, endpoint string) error {
+	tracing.Annotate(ctx, tracing.AttrTxHash.String(txHash))
+
+	err := c.db.MarkAsSubmitted(ctx, policyID, txHash, endpoint)
 	if err != nil {
-		return ecommon.Address{}, fmt.Errorf("failed to get address: %w", err)
+		return fmt.Errorf("%w: failed to mark as submitted: %v", errPaymentUnresolved, err)
 	}
 
-	return ecommon.HexToAddress(addr), nil
+	c.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"policy_id": policyID,
+		"tx_hash":   txHash,
+		"endpoint":  endpoint,
+	}).Info("listing fee payment submitted")
+
+	return nil
 }
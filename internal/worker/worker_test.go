@@ -0,0 +1,648 @@
+package worker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	ecommon "github.com/ethereum/go-ethereum/common"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/vultisig/mobile-tss-lib/tss"
+	rethereum "github.com/vultisig/recipes/chain/evm/ethereum"
+	rtypes "github.com/vultisig/recipes/types"
+	"github.com/vultisig/verifier/plugin/policy"
+	"github.com/vultisig/verifier/plugin/tx_indexer/pkg/storage"
+	vtypes "github.com/vultisig/verifier/types"
+	vcommon "github.com/vultisig/vultisig-go/common"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/vultisig/app-developer/internal/config"
+	"github.com/vultisig/app-developer/internal/db"
+	"github.com/vultisig/app-developer/internal/evm"
+	"github.com/vultisig/app-developer/internal/tracing"
+	"github.com/vultisig/app-developer/internal/tracing/tracingtest"
+	"github.com/vultisig/app-developer/spec"
+)
+
+const (
+	testFeeAmount      = "1000000"
+	testTokenAddress   = "0x2b0C1cdB5f3e8D0E1E7B2b5C2aA4f3F2bD6e9A10"
+	testTreasury       = "0x000000000000000000000000000000000000dEaD"
+	testTargetPluginID = "vultisig-dca-0000"
+	testPublicKey      = "03aa"
+)
+
+var testVault = ecommon.HexToAddress("0x1111111111111111111111111111111111111111")
+
+// testStore keeps one fee and its intent in memory, the way the listing fee
+// tables would. Methods a test does not expect to be called panic through the
+// nil embedded Store.
+type testStore struct {
+	Store
+
+	fee       *db.ListingFee
+	intent    *db.ListingFeeIntent
+	resharing bool
+	batch     []db.ListingFee
+
+	submittedHash     string
+	submittedEndpoint string
+	failedReason      string
+	evaluations       int
+}
+
+func (s *testStore) GetListingFeeByPolicyID(_ context.Context, _ uuid.UUID) (*db.ListingFee, error) {
+	return s.fee, nil
+}
+
+func (s *testStore) ReleaseListingFeeLease(context.Context, uuid.UUID, string) error {
+	return nil
+}
+
+func (s *testStore) IsVaultResharing(context.Context, string, time.Duration) (bool, error) {
+	return s.resharing, nil
+}
+
+func (s *testStore) GetListingFeeIntent(context.Context, uuid.UUID) (*db.ListingFeeIntent, error) {
+	if s.intent == nil {
+		return nil, nil
+	}
+	intent := *s.intent
+	return &intent, nil
+}
+
+func (s *testStore) CreateListingFeeIntent(_ context.Context, intent db.ListingFeeIntent) (*db.ListingFeeIntent, error) {
+	if s.intent == nil {
+		s.intent = &intent
+	}
+	return s.GetListingFeeIntent(context.Background(), intent.PolicyID)
+}
+
+func (s *testStore) DeleteListingFeeIntent(context.Context, uuid.UUID) error {
+	s.intent = nil
+	return nil
+}
+
+func (s *testStore) RecordIntentSignedTx(_ context.Context, _ uuid.UUID, txHash string, signedTx []byte) error {
+	s.intent.SignedTx = signedTx
+	s.intent.TxHashes = append(s.intent.TxHashes, txHash)
+	return nil
+}
+
+func (s *testStore) RecordIntentBroadcastAttempt(context.Context, uuid.UUID) (int, error) {
+	s.intent.BroadcastAttempts++
+	return s.intent.BroadcastAttempts, nil
+}
+
+func (s *testStore) ReplaceIntentUnsignedTx(_ context.Context, _ uuid.UUID, unsignedTx, _ []byte) error {
+	s.intent.UnsignedTx = unsignedTx
+	s.intent.SignedTx = nil
+	s.intent.BroadcastAttempts = 0
+	return nil
+}
+
+func (s *testStore) RecordListingFeeEvaluation(context.Context, uuid.UUID, bool, json.RawMessage) error {
+	s.evaluations++
+	return nil
+}
+
+func (s *testStore) JoinListingFeeBatch(_ context.Context, _ uuid.UUID, _ string, maxFees int) ([]db.ListingFee, error) {
+	return s.batch[:min(maxFees, len(s.batch))], nil
+}
+
+func (s *testStore) MarkAsSubmitted(_ context.Context, _ uuid.UUID, txHash, endpoint string) error {
+	s.submittedHash = txHash
+	s.submittedEndpoint = endpoint
+	return nil
+}
+
+func (s *testStore) MarkAsFailed(_ context.Context, _ uuid.UUID, reason string, _ json.RawMessage) error {
+	s.failedReason = reason
+	return nil
+}
+
+type testPolicies struct {
+	policy.Service
+	pol vtypes.PluginPolicy
+}
+
+func (p testPolicies) GetPluginPolicy(context.Context, uuid.UUID) (*vtypes.PluginPolicy, error) {
+	pol := p.pol
+	return &pol, nil
+}
+
+// testChain is a node that knows the given receipts and pending transactions
+// and reports nonce as the vault's confirmed nonce.
+type testChain struct {
+	nonce    uint64
+	receipts map[ecommon.Hash]*etypes.Receipt
+	pending  map[ecommon.Hash]bool
+}
+
+func (c *testChain) TransactionReceipt(_ context.Context, hash ecommon.Hash) (*etypes.Receipt, error) {
+	receipt, ok := c.receipts[hash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func (c *testChain) TransactionByHash(_ context.Context, hash ecommon.Hash) (*etypes.Transaction, bool, error) {
+	isPending, ok := c.pending[hash]
+	if !ok {
+		return nil, false, ethereum.NotFound
+	}
+	return testSignedTx(0), isPending, nil
+}
+
+func (c *testChain) NonceAt(context.Context, ecommon.Address, *big.Int) (uint64, error) {
+	return c.nonce, nil
+}
+
+type testIndexer struct{}
+
+func (testIndexer) CreateTx(context.Context, storage.CreateTxDto) (storage.Tx, error) {
+	return storage.Tx{ID: uuid.New()}, nil
+}
+
+type testParties struct{}
+
+func (testParties) Resolve(string, string) ([]string, error) {
+	return []string{"plugin", "verifier"}, nil
+}
+
+func (testParties) Forget(string) {}
+
+// testSigner answers every keysign with the same well-formed signature.
+type testSigner struct {
+	signs int
+}
+
+func (s *testSigner) For([]string) evm.KeysignSigner {
+	return s
+}
+
+func (s *testSigner) Sign(_ context.Context, req vtypes.PluginKeysignRequest) (map[string]tss.KeysignResponse, error) {
+	s.signs++
+	return map[string]tss.KeysignResponse{
+		req.Messages[0].Hash: {
+			R:          strings.Repeat("11", 32),
+			S:          strings.Repeat("22", 32),
+			RecoveryID: "00",
+		},
+	}, nil
+}
+
+type testSessions struct{}
+
+func (testSessions) RecordKeysignSession(context.Context, string, uuid.UUID, uuid.UUID, string) error {
+	return nil
+}
+
+// testBroadcaster accepts every transaction unless err is set.
+type testBroadcaster struct {
+	err  error
+	sent []ecommon.Hash
+}
+
+func (b *testBroadcaster) Broadcast(_ context.Context, tx *etypes.Transaction) (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+	b.sent = append(b.sent, tx.Hash())
+	return "public", nil
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) RecordTransition(string, string, int64) {}
+func (nopMetrics) ObserveExecute(time.Duration, string)   {}
+func (nopMetrics) ObserveKeysign(time.Duration, string)   {}
+func (nopMetrics) RecordKeysignFailure(string)            {}
+func (nopMetrics) RecordBroadcastError(string)            {}
+func (nopMetrics) RecordBroadcast(string, string)         {}
+func (nopMetrics) SetPendingQueue(int64, time.Duration)   {}
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// testPolicy is a listing fee policy as the plugin suggests it, paying the
+// treasury from testVault.
+func testPolicy(t *testing.T, maxBatchSize int) vtypes.PluginPolicy {
+	t.Helper()
+
+	s := spec.NewSpec(testTokenAddress, testTreasury, testFeeAmount, maxBatchSize, nil, nil, nil)
+	suggest, err := s.Suggest(context.Background(), map[string]any{
+		"targetPluginId": testTargetPluginID,
+		"asset":          map[string]any{"address": testVault.Hex()},
+	})
+	if err != nil {
+		t.Fatalf("Suggest: %v", err)
+	}
+	raw, err := proto.Marshal(&rtypes.Policy{
+		Id:    "listing-fee",
+		Rules: suggest.GetRules(),
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal recipe: %v", err)
+	}
+	return vtypes.PluginPolicy{
+		ID:        uuid.New(),
+		PublicKey: testPublicKey,
+		PluginID:  vtypes.PluginID(spec.PluginDeveloper),
+		Active:    true,
+		Recipe:    base64.StdEncoding.EncodeToString(raw),
+	}
+}
+
+// testUnsignedTx encodes an ERC-20 transfer of amount to the treasury the way
+// the SDK builds it for keysign.
+func testUnsignedTx(t *testing.T, nonce uint64, amount *big.Int, tipCap, feeCap int64) []byte {
+	t.Helper()
+
+	token := ecommon.HexToAddress(testTokenAddress)
+	data := append([]byte{0xa9, 0x05, 0x9c, 0xbb}, ecommon.LeftPadBytes(ecommon.HexToAddress(testTreasury).Bytes(), 32)...)
+	data = append(data, ecommon.LeftPadBytes(amount.Bytes(), 32)...)
+	raw, err := rlp.EncodeToBytes(rethereum.DynamicFeeTxWithoutSignature{
+		ChainID:   big.NewInt(1),
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(tipCap),
+		GasFeeCap: big.NewInt(feeCap),
+		Gas:       60000,
+		To:        &token,
+		Value:     big.NewInt(0),
+		Data:      data,
+	})
+	if err != nil {
+		t.Fatalf("failed to encode unsigned tx: %v", err)
+	}
+	return append([]byte{etypes.DynamicFeeTxType}, raw...)
+}
+
+func testSignedTx(nonce uint64) *etypes.Transaction {
+	to := ecommon.HexToAddress(testTreasury)
+	return etypes.NewTx(&etypes.LegacyTx{Nonce: nonce, To: &to, Value: big.NewInt(1), Gas: 21000, GasPrice: big.NewInt(1)})
+}
+
+type testConsumer struct {
+	*Consumer
+	store       *testStore
+	chain       *testChain
+	signer      *testSigner
+	broadcaster *testBroadcaster
+	policy      vtypes.PluginPolicy
+}
+
+// newTestConsumer builds a consumer whose signer service runs the real
+// evaluation, keysign request and broadcast code against in-memory parties.
+func newTestConsumer(t *testing.T, pol vtypes.PluginPolicy, feeConfig config.FeeConfig) *testConsumer {
+	t.Helper()
+
+	amount, _ := new(big.Int).SetString(testFeeAmount, 10)
+	tc := &testConsumer{
+		store: &testStore{
+			fee: &db.ListingFee{
+				PolicyID:       pol.ID,
+				PublicKey:      pol.PublicKey,
+				TargetPluginID: testTargetPluginID,
+				Amount:         amount,
+				Destination:    testTreasury,
+				Status:         "pending",
+			},
+		},
+		chain:       &testChain{},
+		signer:      &testSigner{},
+		broadcaster: &testBroadcaster{},
+		policy:      pol,
+	}
+	logger := testLogger()
+	signerService := evm.NewSignerService(
+		nil,
+		vcommon.Ethereum,
+		tc.signer,
+		testParties{},
+		testIndexer{},
+		nopMetrics{},
+		nil,
+		nil,
+		tc.broadcaster,
+		nil,
+		testSessions{},
+		time.Minute,
+		logger,
+	)
+	tc.Consumer = NewConsumer(
+		logger,
+		testPolicies{pol: pol},
+		signerService,
+		tc.chain,
+		tc.store,
+		nil,
+		feeConfig,
+		nopMetrics{},
+		nil,
+		config.WorkerConfig{ID: "test-worker"},
+		NewSoloLeader(),
+		nil,
+	)
+	return tc
+}
+
+func spanAttr(span tracetest.SpanStub, key attribute.Key) string {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestExecuteSpanChain(t *testing.T) {
+	exporter := tracingtest.NewInMemoryProvider(t)
+
+	pol := testPolicy(t, 1)
+	tc := newTestConsumer(t, pol, config.FeeConfig{})
+	amount, _ := new(big.Int).SetString(testFeeAmount, 10)
+	// An intent left by an attempt that died before keysign.
+	tc.store.intent = &db.ListingFeeIntent{
+		PolicyID:    pol.ID,
+		FromAddress: testVault.Hex(),
+		Nonce:       3,
+		UnsignedTx:  testUnsignedTx(t, 3, amount, 1, 100),
+	}
+	tc.chain.nonce = 3
+
+	err := tc.executeLeased(context.Background(), *tc.store.fee)
+	if err != nil {
+		t.Fatalf("executeLeased: %v", err)
+	}
+	if tc.store.submittedHash == "" {
+		t.Fatal("fee was not marked submitted")
+	}
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	execute, ok := spans["worker.execute"]
+	if !ok {
+		t.Fatalf("no worker.execute span among %v", spans)
+	}
+	if got := spanAttr(execute, tracing.AttrPolicyID); got != pol.ID.String() {
+		t.Errorf("execute %s = %q, want %q", tracing.AttrPolicyID, got, pol.ID)
+	}
+	if got := spanAttr(execute, tracing.AttrTargetPluginID); got != testTargetPluginID {
+		t.Errorf("execute %s = %q, want %q", tracing.AttrTargetPluginID, got, testTargetPluginID)
+	}
+	if got := spanAttr(execute, tracing.AttrTxHash); got != tc.store.submittedHash {
+		t.Errorf("execute %s = %q, want %q", tracing.AttrTxHash, got, tc.store.submittedHash)
+	}
+
+	for _, name := range []string{"evm.buildKeysignRequest", "keysign.Signer.Sign", "evm.broadcast"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("no %s span", name)
+			continue
+		}
+		if span.Parent.SpanID() != execute.SpanContext.SpanID() {
+			t.Errorf("%s is not a child of worker.execute", name)
+		}
+		if span.SpanContext.TraceID() != execute.SpanContext.TraceID() {
+			t.Errorf("%s is in another trace", name)
+		}
+		if got := spanAttr(span, tracing.AttrPolicyID); got != pol.ID.String() {
+			t.Errorf("%s %s = %q, want %q", name, tracing.AttrPolicyID, got, pol.ID)
+		}
+	}
+	if spans["keysign.Signer.Sign"].StartTime.Before(spans["evm.buildKeysignRequest"].EndTime) {
+		t.Error("keysign started before its request was built")
+	}
+	if spans["evm.broadcast"].StartTime.Before(spans["keysign.Signer.Sign"].EndTime) {
+		t.Error("broadcast started before keysign finished")
+	}
+}
+
+func TestReconcileIntent(t *testing.T) {
+	oldHash := ecommon.HexToHash("0x0a")
+	newHash := ecommon.HexToHash("0x0b")
+	mined := &etypes.Receipt{Status: etypes.ReceiptStatusSuccessful}
+	reverted := &etypes.Receipt{Status: etypes.ReceiptStatusFailed}
+
+	tests := []struct {
+		name          string
+		hashes        []ecommon.Hash
+		chain         testChain
+		wantDone      bool
+		wantErr       bool
+		wantSubmitted ecommon.Hash
+		wantIntent    bool
+	}{
+		{
+			name:          "mined",
+			hashes:        []ecommon.Hash{oldHash},
+			chain:         testChain{nonce: 6, receipts: map[ecommon.Hash]*etypes.Receipt{oldHash: mined}},
+			wantDone:      true,
+			wantSubmitted: oldHash,
+			wantIntent:    true,
+		},
+		{
+			name:   "reverted",
+			hashes: []ecommon.Hash{oldHash},
+			chain:  testChain{nonce: 6, receipts: map[ecommon.Hash]*etypes.Receipt{oldHash: reverted}},
+		},
+		{
+			name:          "pending",
+			hashes:        []ecommon.Hash{oldHash},
+			chain:         testChain{nonce: 5, pending: map[ecommon.Hash]bool{oldHash: true}},
+			wantDone:      true,
+			wantSubmitted: oldHash,
+			wantIntent:    true,
+		},
+		{
+			name:       "not found with nonce free",
+			hashes:     []ecommon.Hash{oldHash},
+			chain:      testChain{nonce: 5},
+			wantIntent: true,
+		},
+		{
+			name:       "never signed",
+			chain:      testChain{nonce: 5},
+			wantIntent: true,
+		},
+		{
+			name:    "not found with nonce spent",
+			hashes:  []ecommon.Hash{oldHash},
+			chain:   testChain{nonce: 6},
+			wantErr: true,
+		},
+		{
+			name:          "replacement mined",
+			hashes:        []ecommon.Hash{oldHash, newHash},
+			chain:         testChain{nonce: 6, receipts: map[ecommon.Hash]*etypes.Receipt{newHash: mined}},
+			wantDone:      true,
+			wantSubmitted: newHash,
+			wantIntent:    true,
+		},
+		{
+			name:          "replaced tx mined after all",
+			hashes:        []ecommon.Hash{oldHash, newHash},
+			chain:         testChain{nonce: 6, receipts: map[ecommon.Hash]*etypes.Receipt{oldHash: mined}},
+			wantDone:      true,
+			wantSubmitted: oldHash,
+			wantIntent:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := newTestConsumer(t, testPolicy(t, 1), config.FeeConfig{})
+			*tc.chain = tt.chain
+			var hashes []string
+			for _, h := range tt.hashes {
+				hashes = append(hashes, h.Hex())
+			}
+			tc.store.intent = &db.ListingFeeIntent{
+				PolicyID:    tc.policy.ID,
+				FromAddress: testVault.Hex(),
+				Nonce:       5,
+				TxHashes:    hashes,
+			}
+
+			done, err := tc.reconcileIntent(context.Background(), tc.store.intent)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reconcileIntent error = %v, want error %v", err, tt.wantErr)
+			}
+			if done != tt.wantDone {
+				t.Errorf("done = %v, want %v", done, tt.wantDone)
+			}
+			wantSubmitted := ""
+			if tt.wantSubmitted != (ecommon.Hash{}) {
+				wantSubmitted = tt.wantSubmitted.Hex()
+			}
+			if tc.store.submittedHash != wantSubmitted {
+				t.Errorf("submitted hash = %q, want %q", tc.store.submittedHash, wantSubmitted)
+			}
+			if (tc.store.intent != nil) != tt.wantIntent {
+				t.Errorf("intent kept = %v, want %v", tc.store.intent != nil, tt.wantIntent)
+			}
+		})
+	}
+}
+
+func TestUnderpricedBroadcastReprices(t *testing.T) {
+	tc := newTestConsumer(t, testPolicy(t, 1), config.FeeConfig{})
+	amount, _ := new(big.Int).SetString(testFeeAmount, 10)
+	unsigned := testUnsignedTx(t, 4, amount, 1_000_000_000, 20_000_000_000)
+	tc.store.intent = &db.ListingFeeIntent{
+		PolicyID:    tc.policy.ID,
+		FromAddress: testVault.Hex(),
+		Nonce:       4,
+		UnsignedTx:  unsigned,
+	}
+	tc.chain.nonce = 4
+	tc.broadcaster.err = errors.New("replacement transaction underpriced")
+
+	err := tc.execute(context.Background(), tc.policy.ID)
+	if !errors.Is(err, errPaymentUnresolved) {
+		t.Fatalf("execute error = %v, want %v", err, errPaymentUnresolved)
+	}
+	if tc.store.intent.SignedTx != nil {
+		t.Error("underpriced signed tx kept for rebroadcast")
+	}
+	firstHash := tc.store.intent.TxHashes[0]
+
+	repriced, err := rethereum.DecodeUnsignedPayload(tc.store.intent.UnsignedTx)
+	if err != nil {
+		t.Fatalf("failed to decode repriced tx: %v", err)
+	}
+	tx := etypes.NewTx(repriced)
+	if tx.Nonce() != 4 {
+		t.Errorf("repriced nonce = %d, want 4", tx.Nonce())
+	}
+	if tx.GasTipCap().Int64() != 1_250_000_000 || tx.GasFeeCap().Int64() != 25_000_000_000 {
+		t.Errorf("repriced fees = %s/%s, want 1250000000/25000000000", tx.GasTipCap(), tx.GasFeeCap())
+	}
+
+	// The next attempt signs the repriced transaction for the same nonce.
+	tc.broadcaster.err = nil
+	err = tc.execute(context.Background(), tc.policy.ID)
+	if err != nil {
+		t.Fatalf("execute after repricing: %v", err)
+	}
+	if tc.signer.signs != 2 {
+		t.Errorf("keysign ran %d times, want 2", tc.signer.signs)
+	}
+	if len(tc.store.intent.TxHashes) != 2 || tc.store.intent.TxHashes[0] != firstHash {
+		t.Errorf("tx hashes = %v, want the underpriced hash kept and one replacement", tc.store.intent.TxHashes)
+	}
+	if tc.store.submittedHash != tc.store.intent.TxHashes[1] {
+		t.Errorf("submitted %s, want the replacement %s", tc.store.submittedHash, tc.store.intent.TxHashes[1])
+	}
+}
+
+func TestBatchAmount(t *testing.T) {
+	fee, _ := new(big.Int).SetString(testFeeAmount, 10)
+	members := func(n int, amount *big.Int) []db.ListingFee {
+		batch := make([]db.ListingFee, n)
+		for i := range batch {
+			batch[i] = db.ListingFee{PolicyID: uuid.New(), PublicKey: testPublicKey, Amount: amount, Status: "pending"}
+		}
+		return batch
+	}
+	fees := func(n int64) *big.Int { return new(big.Int).Mul(fee, big.NewInt(n)) }
+
+	tests := []struct {
+		name        string
+		policyBatch int
+		configBatch int
+		batch       []db.ListingFee
+		want        *big.Int
+		wantErr     bool
+	}{
+		{name: "batching disabled", policyBatch: 1, configBatch: 1, batch: members(3, fee), want: fees(1)},
+		{name: "policy from before batching", policyBatch: 1, configBatch: 5, batch: members(3, fee), want: fees(1)},
+		{name: "no other pending fees", policyBatch: 5, configBatch: 5, want: fees(1)},
+		{name: "fee times members", policyBatch: 5, configBatch: 5, batch: members(3, fee), want: fees(4)},
+		{name: "capped at the policy's batch size", policyBatch: 3, configBatch: 5, batch: members(6, fee), want: fees(3)},
+		{name: "capped at the configured batch size", policyBatch: 5, configBatch: 2, batch: members(6, fee), want: fees(2)},
+		{name: "members above the constraint", policyBatch: 3, configBatch: 3, batch: members(2, fees(2)), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pol := testPolicy(t, tt.policyBatch)
+			tc := newTestConsumer(t, pol, config.FeeConfig{MaxBatchSize: tt.configBatch})
+			tc.store.batch = tt.batch
+
+			got, err := tc.batchAmount(context.Background(), tc.store.fee, pol)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("batchAmount = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("batchAmount: %v", err)
+			}
+			if got.Cmp(tt.want) != 0 {
+				t.Errorf("batchAmount = %s, want %s", got, tt.want)
+			}
+			if limit := fees(int64(max(tt.policyBatch, 1))); got.Cmp(limit) > 0 {
+				t.Errorf("batchAmount = %s exceeds the policy's limit %s", got, limit)
+			}
+		})
+	}
+}
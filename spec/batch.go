@@ -0,0 +1,66 @@
+package spec
+
+import (
+	"fmt"
+	"math/big"
+
+	rtypes "github.com/vultisig/recipes/types"
+)
+
+// BatchAmount is the total paid in one transfer covering count fees.
+func BatchAmount(feeAmount string, count int) (*big.Int, error) {
+	fee, ok := new(big.Int).SetString(feeAmount, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid fee amount %q", feeAmount)
+	}
+	return fee.Mul(fee, big.NewInt(int64(count))), nil
+}
+
+// amountConstraint is the amount a policy lets the plugin transfer. With
+// batching enabled it is capped at MaxBatchSize fees, so one policy's transfer
+// can also pay the vault's other pending fees; otherwise it is exactly one fee.
+func (s *Spec) amountConstraint() (*rtypes.Constraint, error) {
+	if s.MaxBatchSize <= 1 {
+		return &rtypes.Constraint{
+			Type:     rtypes.ConstraintType_CONSTRAINT_TYPE_FIXED,
+			Value:    &rtypes.Constraint_FixedValue{FixedValue: s.FeeAmount},
+			Required: true,
+		}, nil
+	}
+	limit, err := BatchAmount(s.FeeAmount, s.MaxBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	return &rtypes.Constraint{
+		Type:     rtypes.ConstraintType_CONSTRAINT_TYPE_MAX,
+		Value:    &rtypes.Constraint_MaxValue{MaxValue: limit.String()},
+		Required: true,
+	}, nil
+}
+
+// PaymentLimit returns the largest amount the policy's recipe lets one
+// transfer carry: its fixed amount, or the cap of a batch-enabled policy.
+func PaymentLimit(recipe *rtypes.Policy) (*big.Int, error) {
+	for _, rule := range recipe.GetRules() {
+		for _, pc := range rule.GetParameterConstraints() {
+			if pc.GetParameterName() != "amount" {
+				continue
+			}
+			var value string
+			switch pc.GetConstraint().GetType() {
+			case rtypes.ConstraintType_CONSTRAINT_TYPE_FIXED:
+				value = pc.GetConstraint().GetFixedValue()
+			case rtypes.ConstraintType_CONSTRAINT_TYPE_MAX:
+				value = pc.GetConstraint().GetMaxValue()
+			default:
+				return nil, fmt.Errorf("unsupported amount constraint %s", pc.GetConstraint().GetType())
+			}
+			limit, ok := new(big.Int).SetString(value, 10)
+			if !ok {
+				return nil, fmt.Errorf("invalid amount %q", value)
+			}
+			return limit, nil
+		}
+	}
+	return nil, fmt.Errorf("amount constraint is required")
+}
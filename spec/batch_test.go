@@ -0,0 +1,74 @@
+package spec
+
+import (
+	"math/big"
+	"testing"
+
+	rtypes "github.com/vultisig/recipes/types"
+)
+
+func TestBatchAmount(t *testing.T) {
+	tests := []struct {
+		name    string
+		fee     string
+		count   int
+		want    string
+		wantErr bool
+	}{
+		{name: "single fee", fee: "1000000", count: 1, want: "1000000"},
+		{name: "fee times members", fee: "1000000", count: 4, want: "4000000"},
+		{name: "beyond int64", fee: "10000000000000000000", count: 10, want: "100000000000000000000"},
+		{name: "invalid fee", fee: "1e6", count: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BatchAmount(tt.fee, tt.count)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("BatchAmount = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BatchAmount: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("BatchAmount = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaymentLimit(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxBatchSize int
+		wantType     rtypes.ConstraintType
+		want         int64
+	}{
+		{name: "batching disabled", maxBatchSize: 1, wantType: rtypes.ConstraintType_CONSTRAINT_TYPE_FIXED, want: 1000000},
+		{name: "batch of ten", maxBatchSize: 10, wantType: rtypes.ConstraintType_CONSTRAINT_TYPE_MAX, want: 10000000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSpec("0x2b0C1cdB5f3e8D0E1E7B2b5C2aA4f3F2bD6e9A10", "0x000000000000000000000000000000000000dEaD", "1000000", tt.maxBatchSize, nil, nil, nil)
+			constraint, err := s.amountConstraint()
+			if err != nil {
+				t.Fatalf("amountConstraint: %v", err)
+			}
+			if constraint.GetType() != tt.wantType {
+				t.Errorf("constraint type = %s, want %s", constraint.GetType(), tt.wantType)
+			}
+
+			limit, err := PaymentLimit(&rtypes.Policy{Rules: []*rtypes.Rule{{
+				ParameterConstraints: []*rtypes.ParameterConstraint{{ParameterName: "amount", Constraint: constraint}},
+			}}})
+			if err != nil {
+				t.Fatalf("PaymentLimit: %v", err)
+			}
+			if limit.Cmp(big.NewInt(tt.want)) != 0 {
+				t.Errorf("PaymentLimit = %s, want %d", limit, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package spec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	rtypes "github.com/vultisig/recipes/types"
+	"github.com/vultisig/verifier/types"
+)
+
+// policyCheckTimeout bounds the lookups behind ValidatePluginPolicy, which the
+// plugin server calls without a request context.
+const policyCheckTimeout = 10 * time.Second
+
+var (
+	// ErrPolicyRejected marks a policy that breaks a listing fee rule.
+	ErrPolicyRejected = errors.New("listing fee policy rejected")
+	// ErrPluginNotOwned marks a policy paying for a plugin the payer does not own.
+	ErrPluginNotOwned = errors.New("target plugin is not owned by the payer")
+	// ErrListingFeeExists marks a policy for a scope that already has a fee.
+	ErrListingFeeExists = errors.New("listing fee already exists for target plugin")
+	// ErrPolicyCheckFailed marks a rule that could not be checked.
+	ErrPolicyCheckFailed = errors.New("failed to check listing fee policy")
+)
+
+type ListingFeeLookup interface {
+	HasActiveListingFee(ctx context.Context, publicKey, targetPluginID string) (bool, error)
+	HasListingFee(ctx context.Context, policyID uuid.UUID) (bool, error)
+}
+
+type AddressDeriver interface {
+	DeriveAddress(ctx context.Context, publicKey string, pluginID string) (ecommon.Address, error)
+}
+
+// PluginOwnerLookup reports whether a plugin is registered with the verifier,
+// where developers create their draft plugins, and whether publicKey owns it.
+type PluginOwnerLookup interface {
+	GetPluginOwnership(ctx context.Context, pluginID, publicKey string) (registered bool, owned bool, err error)
+}
+
+// validateListingFeeRules enforces the rules the recipe schema can't express.
+func (s *Spec) validateListingFeeRules(pol types.PluginPolicy) error {
+	ctx, cancel := context.WithTimeout(context.Background(), policyCheckTimeout)
+	defer cancel()
+
+	recipe, err := pol.GetRecipe()
+	if err != nil {
+		return fmt.Errorf("%w: failed to get recipe: %v", ErrPolicyRejected, err)
+	}
+
+	cfgMap := recipe.GetConfiguration().AsMap()
+	targetPluginID, _ := cfgMap["targetPluginId"].(string)
+	if targetPluginID == "" {
+		return fmt.Errorf("%w: targetPluginId is required", ErrPolicyRejected)
+	}
+	if feeAmount, ok := cfgMap["feeAmount"].(string); ok && feeAmount != s.FeeAmount {
+		return fmt.Errorf("%w: feeAmount must be %s, got %s", ErrPolicyRejected, s.FeeAmount, feeAmount)
+	}
+	wantAmount, err := s.amountConstraint()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPolicyCheckFailed, err)
+	}
+
+	for _, rule := range recipe.GetRules() {
+		for _, pc := range rule.GetParameterConstraints() {
+			value := pc.GetConstraint().GetFixedValue()
+			switch pc.GetParameterName() {
+			case "asset":
+				if !strings.EqualFold(value, s.VultTokenAddress) {
+					return fmt.Errorf("%w: asset must be %s, got %s", ErrPolicyRejected, s.VultTokenAddress, value)
+				}
+			case "amount":
+				got := pc.GetConstraint()
+				if got.GetType() != wantAmount.GetType() ||
+					got.GetFixedValue() != wantAmount.GetFixedValue() ||
+					got.GetMaxValue() != wantAmount.GetMaxValue() {
+					return fmt.Errorf("%w: amount must be %s", ErrPolicyRejected, describeConstraint(wantAmount))
+				}
+			case "to_address":
+				if !strings.EqualFold(value, s.TreasuryAddress) {
+					return fmt.Errorf("%w: to_address must be the treasury %s, got %s", ErrPolicyRejected, s.TreasuryAddress, value)
+				}
+			}
+		}
+	}
+	fromAddress, err := FromAddress(recipe)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPolicyRejected, err)
+	}
+
+	if s.owners != nil {
+		registered, owned, err := s.owners.GetPluginOwnership(ctx, targetPluginID, pol.PublicKey)
+		if err != nil {
+			return fmt.Errorf("%w: failed to get target plugin: %v", ErrPolicyCheckFailed, err)
+		}
+		if !registered {
+			return fmt.Errorf("%w: target plugin %s is not registered", ErrPolicyRejected, targetPluginID)
+		}
+		if !owned {
+			return fmt.Errorf("%w: %s", ErrPluginNotOwned, targetPluginID)
+		}
+	}
+
+	err = s.checkNoActiveListingFee(ctx, pol, targetPluginID)
+	if err != nil {
+		return err
+	}
+
+	vaultAddr, err := s.addresses.DeriveAddress(ctx, pol.PublicKey, pol.PluginID.String())
+	if err != nil {
+		return fmt.Errorf("%w: failed to derive vault address, is the plugin installed: %v", ErrPolicyRejected, err)
+	}
+	if fromAddress != vaultAddr {
+		return fmt.Errorf("%w: from_address must be the vault address %s, got %s", ErrPolicyRejected, vaultAddr.Hex(), fromAddress.Hex())
+	}
+
+	return nil
+}
+
+// FromAddress returns the from_address the developer configured as the
+// asset's address, which the fee is paid from.
+func FromAddress(recipe *rtypes.Policy) (ecommon.Address, error) {
+	for _, rule := range recipe.GetRules() {
+		for _, pc := range rule.GetParameterConstraints() {
+			if pc.GetParameterName() != "from_address" {
+				continue
+			}
+			value := pc.GetConstraint().GetFixedValue()
+			if !ecommon.IsHexAddress(value) {
+				return ecommon.Address{}, fmt.Errorf("from_address %q is not an address", value)
+			}
+			return ecommon.HexToAddress(value), nil
+		}
+	}
+	return ecommon.Address{}, errors.New("from_address constraint is required")
+}
+
+// checkNoActiveListingFee lets a policy through when the active fee in its
+// scope is its own, so updating an existing policy is not rejected.
+func (s *Spec) checkNoActiveListingFee(ctx context.Context, pol types.PluginPolicy, targetPluginID string) error {
+	if pol.ID != uuid.Nil {
+		own, err := s.listingFees.HasListingFee(ctx, pol.ID)
+		if err != nil {
+			return fmt.Errorf("%w: failed to get listing fee: %v", ErrPolicyCheckFailed, err)
+		}
+		if own {
+			return nil
+		}
+	}
+
+	exists, err := s.listingFees.HasActiveListingFee(ctx, pol.PublicKey, targetPluginID)
+	if err != nil {
+		return fmt.Errorf("%w: failed to check active listing fee: %v", ErrPolicyCheckFailed, err)
+	}
+	if exists {
+		return fmt.Errorf("%w: %s", ErrListingFeeExists, targetPluginID)
+	}
+	return nil
+}
+
+func describeConstraint(c *rtypes.Constraint) string {
+	if c.GetType() == rtypes.ConstraintType_CONSTRAINT_TYPE_MAX {
+		return "at most " + c.GetMaxValue()
+	}
+	return c.GetFixedValue()
+}
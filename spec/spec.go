@@ -16,13 +16,31 @@ type Spec struct {
 	VultTokenAddress string
 	TreasuryAddress  string
 	FeeAmount        string
+	MaxBatchSize     int
+
+	listingFees ListingFeeLookup
+	addresses   AddressDeriver
+	owners      PluginOwnerLookup
 }
 
-func NewSpec(vultTokenAddress, treasuryAddress, feeAmount string) *Spec {
+// NewSpec builds the plugin spec. listingFees and addresses back the business
+// rules in ValidatePluginPolicy and must be set. owners may be nil, in which
+// case the target plugin's registration and ownership are not checked.
+func NewSpec(
+	vultTokenAddress, treasuryAddress, feeAmount string,
+	maxBatchSize int,
+	listingFees ListingFeeLookup,
+	addresses AddressDeriver,
+	owners PluginOwnerLookup,
+) *Spec {
 	return &Spec{
 		VultTokenAddress: vultTokenAddress,
 		TreasuryAddress:  treasuryAddress,
 		FeeAmount:        feeAmount,
+		MaxBatchSize:     maxBatchSize,
+		listingFees:      listingFees,
+		addresses:        addresses,
+		owners:           owners,
 	}
 }
 
@@ -115,12 +133,18 @@ func (s *Spec) GetRecipeSpecification() (*rtypes.RecipeSchema, error) {
 	}, nil
 }
 
+// ValidatePluginPolicy checks pol against the recipe schema and the listing
+// fee rules.
 func (s *Spec) ValidatePluginPolicy(pol types.PluginPolicy) error {
 	spec, err := s.GetRecipeSpecification()
 	if err != nil {
 		return fmt.Errorf("failed to get recipe spec: %w", err)
 	}
-	return plugin.ValidatePluginPolicy(pol, spec)
+	err = plugin.ValidatePluginPolicy(pol, spec)
+	if err != nil {
+		return err
+	}
+	return s.validateListingFeeRules(pol)
 }
 
 func (s *Spec) Suggest(_ context.Context, cfg map[string]any) (*rtypes.PolicySuggest, error) {
@@ -128,6 +152,10 @@ func (s *Spec) Suggest(_ context.Context, cfg map[string]any) (*rtypes.PolicySug
 	if !ok {
 		return nil, fmt.Errorf("'targetPluginId' is required")
 	}
+	amount, err := s.amountConstraint()
+	if err != nil {
+		return nil, err
+	}
 
 	assetMap, ok := cfg["asset"].(map[string]any)
 	if !ok {
@@ -164,13 +192,7 @@ func (s *Spec) Suggest(_ context.Context, cfg map[string]any) (*rtypes.PolicySug
 		},
 		{
 			ParameterName: "amount",
-			Constraint: &rtypes.Constraint{
-				Type: rtypes.ConstraintType_CONSTRAINT_TYPE_FIXED,
-				Value: &rtypes.Constraint_FixedValue{
-					FixedValue: s.FeeAmount,
-				},
-				Required: true,
-			},
+			Constraint:    amount,
 		},
 		{
 			ParameterName: "to_address",
@@ -201,6 +223,11 @@ func (s *Spec) Suggest(_ context.Context, cfg map[string]any) (*rtypes.PolicySug
 }
 
 func (s *Spec) buildSupportedResources() []*rtypes.ResourcePattern {
+	amountType := rtypes.ConstraintType_CONSTRAINT_TYPE_FIXED
+	if s.MaxBatchSize > 1 {
+		amountType = rtypes.ConstraintType_CONSTRAINT_TYPE_MAX
+	}
+
 	var resources []*rtypes.ResourcePattern
 	for _, chain := range SupportedChains {
 		chainNameLower := strings.ToLower(chain.String())
@@ -226,7 +253,7 @@ func (s *Spec) buildSupportedResources() []*rtypes.ResourcePattern {
 				},
 				{
 					ParameterName:  "amount",
-					SupportedTypes: rtypes.ConstraintType_CONSTRAINT_TYPE_FIXED,
+					SupportedTypes: amountType,
 					Required:       true,
 				},
 				{